@@ -0,0 +1,5 @@
+// Package pigeonv1 contém o código gerado a partir de messenger.proto. Para gerar de novo,
+// com protoc, protoc-gen-go e protoc-gen-go-grpc no PATH: go generate ./api/proto/...
+package pigeonv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative pigeon/v1/messenger.proto
@@ -0,0 +1,252 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: pigeon/v1/messenger.proto
+
+package pigeonv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Messenger_SendMessage_FullMethodName       = "/pigeon.v1.Messenger/SendMessage"
+	Messenger_ListConversations_FullMethodName = "/pigeon.v1.Messenger/ListConversations"
+	Messenger_FetchHistory_FullMethodName      = "/pigeon.v1.Messenger/FetchHistory"
+	Messenger_Events_FullMethodName            = "/pigeon.v1.Messenger/Events"
+)
+
+// MessengerClient is the client API for Messenger service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Messenger expõe as conversas para serviços internos (bots, ferramenta de suporte) sobre a
+// mesma camada de serviços da API REST. Toda chamada leva o token de acesso da API no
+// metadata "authorization", no formato "Bearer <token>"; o idioma das mensagens de erro vem
+// do "accept-language" ou da preferência salva pelo usuário.
+type MessengerClient interface {
+	// Envia uma mensagem, como o POST /v1/conversations/:id/messages.
+	SendMessage(ctx context.Context, in *SendMessageRequest, opts ...grpc.CallOption) (*SendMessageResponse, error)
+	// Lista as conversas com a última mensagem de cada uma, como o GET /v1/conversations.
+	ListConversations(ctx context.Context, in *ListConversationsRequest, opts ...grpc.CallOption) (*ListConversationsResponse, error)
+	// Mensagens trocadas com outro usuário, como o GET /v1/conversations/:id/messages.
+	FetchHistory(ctx context.Context, in *FetchHistoryRequest, opts ...grpc.CallOption) (*FetchHistoryResponse, error)
+	// Eventos em tempo real, os mesmos do WebSocket versionado. O cliente envia mensagens pelo
+	// stream e recebe as confirmações (com reply_to) e as mensagens de todas as conversas. Com
+	// o metadata "last-event-id", retoma a partir do id do último evento recebido.
+	Events(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ClientEvent, ServerEvent], error)
+}
+
+type messengerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMessengerClient(cc grpc.ClientConnInterface) MessengerClient {
+	return &messengerClient{cc}
+}
+
+func (c *messengerClient) SendMessage(ctx context.Context, in *SendMessageRequest, opts ...grpc.CallOption) (*SendMessageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SendMessageResponse)
+	err := c.cc.Invoke(ctx, Messenger_SendMessage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messengerClient) ListConversations(ctx context.Context, in *ListConversationsRequest, opts ...grpc.CallOption) (*ListConversationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListConversationsResponse)
+	err := c.cc.Invoke(ctx, Messenger_ListConversations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messengerClient) FetchHistory(ctx context.Context, in *FetchHistoryRequest, opts ...grpc.CallOption) (*FetchHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FetchHistoryResponse)
+	err := c.cc.Invoke(ctx, Messenger_FetchHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messengerClient) Events(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ClientEvent, ServerEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Messenger_ServiceDesc.Streams[0], Messenger_Events_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ClientEvent, ServerEvent]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Messenger_EventsClient = grpc.BidiStreamingClient[ClientEvent, ServerEvent]
+
+// MessengerServer is the server API for Messenger service.
+// All implementations must embed UnimplementedMessengerServer
+// for forward compatibility.
+//
+// Messenger expõe as conversas para serviços internos (bots, ferramenta de suporte) sobre a
+// mesma camada de serviços da API REST. Toda chamada leva o token de acesso da API no
+// metadata "authorization", no formato "Bearer <token>"; o idioma das mensagens de erro vem
+// do "accept-language" ou da preferência salva pelo usuário.
+type MessengerServer interface {
+	// Envia uma mensagem, como o POST /v1/conversations/:id/messages.
+	SendMessage(context.Context, *SendMessageRequest) (*SendMessageResponse, error)
+	// Lista as conversas com a última mensagem de cada uma, como o GET /v1/conversations.
+	ListConversations(context.Context, *ListConversationsRequest) (*ListConversationsResponse, error)
+	// Mensagens trocadas com outro usuário, como o GET /v1/conversations/:id/messages.
+	FetchHistory(context.Context, *FetchHistoryRequest) (*FetchHistoryResponse, error)
+	// Eventos em tempo real, os mesmos do WebSocket versionado. O cliente envia mensagens pelo
+	// stream e recebe as confirmações (com reply_to) e as mensagens de todas as conversas. Com
+	// o metadata "last-event-id", retoma a partir do id do último evento recebido.
+	Events(grpc.BidiStreamingServer[ClientEvent, ServerEvent]) error
+	mustEmbedUnimplementedMessengerServer()
+}
+
+// UnimplementedMessengerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMessengerServer struct{}
+
+func (UnimplementedMessengerServer) SendMessage(context.Context, *SendMessageRequest) (*SendMessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendMessage not implemented")
+}
+func (UnimplementedMessengerServer) ListConversations(context.Context, *ListConversationsRequest) (*ListConversationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListConversations not implemented")
+}
+func (UnimplementedMessengerServer) FetchHistory(context.Context, *FetchHistoryRequest) (*FetchHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchHistory not implemented")
+}
+func (UnimplementedMessengerServer) Events(grpc.BidiStreamingServer[ClientEvent, ServerEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedMessengerServer) mustEmbedUnimplementedMessengerServer() {}
+func (UnimplementedMessengerServer) testEmbeddedByValue()                   {}
+
+// UnsafeMessengerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MessengerServer will
+// result in compilation errors.
+type UnsafeMessengerServer interface {
+	mustEmbedUnimplementedMessengerServer()
+}
+
+func RegisterMessengerServer(s grpc.ServiceRegistrar, srv MessengerServer) {
+	// If the following call pancis, it indicates UnimplementedMessengerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Messenger_ServiceDesc, srv)
+}
+
+func _Messenger_SendMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessengerServer).SendMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Messenger_SendMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessengerServer).SendMessage(ctx, req.(*SendMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Messenger_ListConversations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListConversationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessengerServer).ListConversations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Messenger_ListConversations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessengerServer).ListConversations(ctx, req.(*ListConversationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Messenger_FetchHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessengerServer).FetchHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Messenger_FetchHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessengerServer).FetchHistory(ctx, req.(*FetchHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Messenger_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MessengerServer).Events(&grpc.GenericServerStream[ClientEvent, ServerEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Messenger_EventsServer = grpc.BidiStreamingServer[ClientEvent, ServerEvent]
+
+// Messenger_ServiceDesc is the grpc.ServiceDesc for Messenger service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Messenger_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pigeon.v1.Messenger",
+	HandlerType: (*MessengerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SendMessage",
+			Handler:    _Messenger_SendMessage_Handler,
+		},
+		{
+			MethodName: "ListConversations",
+			Handler:    _Messenger_ListConversations_Handler,
+		},
+		{
+			MethodName: "FetchHistory",
+			Handler:    _Messenger_FetchHistory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Events",
+			Handler:       _Messenger_Events_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "pigeon/v1/messenger.proto",
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"messenger-pigeon-app/pkg/asyncapi"
+	"messenger-pigeon-app/pkg/openapi"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitAsyncAPI publica em /asyncapi.json o documento dos eventos WebSocket, com um canal por
+// rota WebSocket /v1. Deve ser chamado antes de InitOpenAPI, para que a rota seja documentada.
+func InitAsyncAPI(r *gin.Engine) {
+	var endpoints []asyncapi.Endpoint
+	for key, doc := range routeDocs() {
+		if doc.WebSocket && doc.Deprecated == "" {
+			endpoints = append(endpoints, asyncapi.Endpoint{Path: key[len("GET "):], Summary: doc.Summary})
+		}
+	}
+
+	document := asyncapi.Build(openapi.Info{
+		Title:   "Messenger Pigeon WebSocket",
+		Version: "1.0.0",
+		Description: "Eventos dos subprotocolos pigeon.v1.json (frames de texto) e pigeon.v1.msgpack " +
+			"(frames binários em MessagePack, com o mesmo schema), negociados pelo Sec-WebSocket-Protocol. " +
+			"Todo frame é um envelope {type, id, timestamp, payload, meta}. Sem esses subprotocolos, os " +
+			"frames seguem o formato legado: model.UserMessage ou uma lista delas. Os mesmos eventos " +
+			"são entregues por SSE (GET /v1/events) e long-poll (GET /v1/events/poll).",
+	}, endpoints)
+
+	r.GET("/asyncapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, document)
+	})
+}
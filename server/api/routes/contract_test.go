@@ -0,0 +1,192 @@
+package routes
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/config/keys"
+	"messenger-pigeon-app/config/middleware"
+	"messenger-pigeon-app/internal/testdb"
+	"messenger-pigeon-app/pkg/openapi"
+	"messenger-pigeon-app/pkg/ratelimit"
+	"messenger-pigeon-app/pkg/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openContractStore simula o banco com a usuária ana (7, sem ícone, sessão "s1") e o
+// usuário bruno (8, com ícone), que trocaram duas mensagens.
+func openContractStore(t *testing.T) *testdb.Store {
+	t.Helper()
+	db, store := testdb.Open()
+	database.SetDB(db)
+	t.Cleanup(func() { database.SetDB(nil) })
+	// Limites zerados a cada teste, que com -count repetiria as mesmas requisições
+	ratelimit.Initialize(db)
+
+	store.Rows("SELECT EXISTS(SELECT 1 FROM user_session", []string{"active"}, []driver.Value{true})
+	store.Rows("SELECT locale FROM user", []string{"locale"}, []driver.Value{"pt-BR"})
+	store.Handle("SELECT name, username, icon FROM user", func(args []driver.Value) testdb.Result {
+		columns := []string{"name", "username", "icon"}
+		if args[0] == int64(8) {
+			return testdb.Result{Columns: columns, Rows: [][]driver.Value{{"Bruno", "bruno", []byte{0x89, 'P', 'N', 'G'}}}}
+		}
+		return testdb.Result{Columns: columns, Rows: [][]driver.Value{{"Ana", "ana", nil}}}
+	})
+	store.Handle("SELECT id FROM user WHERE username", func(args []driver.Value) testdb.Result {
+		if args[0] == "bruno" {
+			return testdb.Result{Columns: []string{"id"}, Rows: [][]driver.Value{{int64(8)}}}
+		}
+		return testdb.Result{Columns: []string{"id"}}
+	})
+	store.Rows("SELECT username FROM user WHERE id", []string{"username"}, []driver.Value{"ana"})
+	store.Rows("JOIN user ON user.id = user_message.messageBy",
+		[]string{"message_id", "messageBy", "content", "id", "username", "name", "icon", "created_at", "client_message_id"},
+		[]driver.Value{int64(1), int64(7), "oi", int64(7), "ana", "Ana", nil, "2026-10-19 10:30:00", ""},
+		[]driver.Value{int64(2), int64(8), "olá", int64(8), "bruno", "Bruno", []byte{0x89, 'P', 'N', 'G'}, "2026-10-19 10:31:00", "c-2"},
+	)
+	// Nenhuma conversa ainda: a lista vazia é codificada como null
+	store.Rows("JOIN user ON (user.id = user_message.messageTo", []string{"user_id", "username", "name", "icon", "content", "created_at"})
+	store.Rows("SELECT id, user_id, device, ip, created_at", []string{"id", "user_id", "device", "ip", "created_at", "last_used_at", "expires_at"},
+		[]driver.Value{"s1", int64(7), "Firefox", "127.0.0.1", "2026-10-19 10:00:00", "2026-10-19 10:30:00", "2026-11-18 10:30:00"},
+	)
+	return store
+}
+
+// startContractServer sobe as rotas da API com o documento OpenAPI, como o comando serve.
+func startContractServer(t *testing.T) (*httptest.Server, *openapi.Document) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Locale())
+	InitRoutes(r.Group("/"))
+	InitOpenAPI(r)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var document openapi.Document
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		t.Fatalf("decoding /openapi.json: %v", err)
+	}
+	return server, &document
+}
+
+// As respostas das rotas REST seguem os schemas publicados em /openapi.json.
+func TestResponsesMatchOpenAPI(t *testing.T) {
+	t.Setenv("SESSION_SECRET", "contract-secret")
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_JWKS_URL", "")
+	if err := keys.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	openContractStore(t)
+	server, document := startContractServer(t)
+
+	token, _, err := services.IssueAccessToken(context.Background(), 7, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		method, path, spec string
+		body               string
+		auth               bool
+		status             int
+	}{
+		{"GET", "/healthz", "/healthz", "", false, http.StatusOK},
+		{"GET", "/.well-known/jwks.json", "/.well-known/jwks.json", "", false, http.StatusOK},
+		{"GET", "/v1/me", "/v1/me", "", true, http.StatusOK},
+		{"GET", "/v1/me", "/v1/me", "", false, http.StatusUnauthorized},
+		{"GET", "/v1/conversations", "/v1/conversations", "", true, http.StatusOK},
+		{"GET", "/v1/conversations/bruno", "/v1/conversations/{id}", "", true, http.StatusOK},
+		{"GET", "/v1/conversations/nobody", "/v1/conversations/{id}", "", true, http.StatusNotFound},
+		{"GET", "/v1/conversations/bruno/messages", "/v1/conversations/{id}/messages", "", true, http.StatusOK},
+		{"GET", "/v1/sessions", "/v1/sessions", "", true, http.StatusOK},
+		{"POST", "/v1/ws/tickets", "/v1/ws/tickets", "", true, http.StatusOK},
+		{"POST", "/v1/auth/signup", "/v1/auth/signup", `{"username":"x"}`, false, http.StatusBadRequest},
+		{"POST", "/chat/bruno", "/chat/{username}", "", true, http.StatusOK},
+		{"POST", "/messages", "/messages", "", true, http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, server.URL+tc.path, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			if tc.auth {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			raw, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tc.status {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tc.status, raw)
+			}
+
+			schema, err := document.ResponseSchema(tc.method, tc.spec, resp.StatusCode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var body interface{}
+			if err := json.Unmarshal(raw, &body); err != nil {
+				t.Fatalf("response is not JSON: %v: %s", err, raw)
+			}
+			if err := document.Validate(schema, body); err != nil {
+				t.Fatalf("response does not match the spec: %v\n%s", err, raw)
+			}
+		})
+	}
+}
+
+// O validador rejeita respostas fora do schema, para que o teste acima não passe por engano.
+func TestValidateRejectsMismatches(t *testing.T) {
+	_, document := startContractServer(t)
+	schema, err := document.ResponseSchema("GET", "/v1/conversations/{id}/messages", http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := map[string]interface{}{"messages": []interface{}{map[string]interface{}{
+		"messagesession": true, "post-id": 1.0, "post-user-id": 7.0, "user-id": 7.0, "content": "oi",
+		"icon": nil, "iconbase64": "", "createdby": "ana", "createdbyname": "Ana",
+		"message-by": 0.0, "message-to": 0.0, "hourminute": "10:30",
+	}}}
+	if err := document.Validate(schema, valid); err != nil {
+		t.Fatalf("valid body rejected: %v", err)
+	}
+
+	if err := document.Validate(schema, map[string]interface{}{}); err == nil {
+		t.Error("missing property: body accepted")
+	}
+	mismatches := map[string]func(message map[string]interface{}){
+		"wrong type":        func(m map[string]interface{}) { m["post-id"] = "1" },
+		"undocumented":      func(m map[string]interface{}) { m["extra"] = 1.0 },
+		"null not nullable": func(m map[string]interface{}) { m["content"] = nil },
+		"invalid base64":    func(m map[string]interface{}) { m["icon"] = "not base64!" },
+	}
+	for name, mutate := range mismatches {
+		message := map[string]interface{}{}
+		for key, value := range valid["messages"].([]interface{})[0].(map[string]interface{}) {
+			message[key] = value
+		}
+		mutate(message)
+		if err := document.Validate(schema, map[string]interface{}{"messages": []interface{}{message}}); err == nil {
+			t.Errorf("%s: body accepted", name)
+		}
+	}
+}
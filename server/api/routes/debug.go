@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"messenger-pigeon-app/config/middleware"
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitDebug publica os perfis do pprof em /debug/pprof, restritos aos usuários de
+// ADMIN_USER_IDS. Deve ser chamado depois de InitOpenAPI, para que essas rotas fiquem fora
+// do documento.
+func InitDebug(r *gin.Engine) {
+	debug := r.Group("/debug/pprof")
+	debug.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	debug.GET("/", gin.WrapF(pprof.Index))
+	debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	debug.GET("/profile", gin.WrapF(pprof.Profile))
+	debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+	debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+	debug.GET("/trace", gin.WrapF(pprof.Trace))
+	// Perfis nomeados (heap, goroutine, allocs, block, mutex, threadcreate), servidos pelo Index
+	debug.GET("/:profile", gin.WrapF(pprof.Index))
+}
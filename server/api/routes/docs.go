@@ -0,0 +1,194 @@
+package routes
+
+import (
+	"messenger-pigeon-app/config/keys"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/controllers"
+	"messenger-pigeon-app/pkg/openapi"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitOpenAPI publica em /openapi.json o documento OpenAPI gerado a partir da tabela de rotas.
+// Deve ser chamado depois de InitRoutes, para que todas as rotas já estejam registradas.
+func InitOpenAPI(r *gin.Engine) {
+	var document *openapi.Document
+	r.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, document)
+	})
+
+	document = openapi.Build(openapi.Info{
+		Title:       "Messenger Pigeon API",
+		Version:     "1.0.0",
+		Description: "API REST e WebSocket do Messenger Pigeon. As rotas sem versão são aliases obsoletos das rotas /v1.",
+	}, r.Routes(), routeDocs())
+}
+
+// Formatos de resposta montados nos controllers com gin.H
+var (
+	messageResponse = openapi.Object{"message": ""}
+	partnerResponse = openapi.Object{"id": "", "username": "", "name": "", "iconBase64": ""}
+	currentUsername = openapi.Object{"username": ""}
+	idempotencyKey  = openapi.Parameter{
+		Name:        "Idempotency-Key",
+		In:          "header",
+		Description: "Id gerado pelo cliente; reenvios com o mesmo id devolvem a mensagem original",
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+	lastEventID = openapi.Parameter{
+		Name:        "Last-Event-ID",
+		In:          "header",
+		Description: "Id do último evento recebido, para retomar a partir dele",
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+	eventCursor = openapi.Parameter{
+		Name:        "cursor",
+		In:          "query",
+		Description: "Cursor para retomar (o id do último evento ou o cursor do último long-poll); sem ele, a partir de agora",
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+	pollTimeout = openapi.Parameter{
+		Name:        "timeout",
+		In:          "query",
+		Description: "Espera máxima em segundos (padrão 25, máximo 60)",
+		Schema:      &openapi.Schema{Type: "integer"},
+	}
+	connectionsUser = openapi.Parameter{
+		Name:        "userId",
+		In:          "query",
+		Description: "Restringe a lista a um usuário",
+		Schema:      &openapi.Schema{Type: "integer"},
+	}
+	websocketProtocol = openapi.Parameter{
+		Name:        "Sec-WebSocket-Protocol",
+		In:          "header",
+		Description: "pigeon.v1.json ou pigeon.v1.msgpack para os eventos descritos em /asyncapi.json; pigeon (legado) para os frames sem envelope",
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+)
+
+// routeDocs descreve as rotas por "MÉTODO caminho". As rotas legadas herdam a descrição da
+// rota /v1 que as substitui, exceto quando respondem em outro formato.
+func routeDocs() map[string]openapi.Route {
+	auth := []string{"auth"}
+	conversations := []string{"conversations"}
+	realtime := []string{"realtime"}
+	sessions := []string{"sessions"}
+	twoFactor := []string{"two-factor"}
+	account := []string{"account"}
+	ops := []string{"ops"}
+	admin := []string{"admin"}
+	readiness := openapi.Object{"status": "", "checks": openapi.Object{"database": "", "migrations": "", "bus": ""}}
+
+	docs := map[string]openapi.Route{
+		"GET /.well-known/jwks.json": {Summary: "Chaves públicas de verificação dos tokens", Tags: []string{"keys"}, Responses: map[int]interface{}{200: keys.JWKS{}}},
+		"GET /openapi.json":          {Summary: "Este documento", Tags: []string{"docs"}, Responses: map[int]interface{}{200: openapi.Object{}}},
+		"GET /asyncapi.json":         {Summary: "Documento AsyncAPI dos eventos WebSocket", Tags: []string{"docs"}, Responses: map[int]interface{}{200: openapi.Object{}}},
+		"GET /metrics":               {Summary: "Métricas do Prometheus (text/plain); exige o METRICS_TOKEN como Bearer, se configurado", Tags: ops, Responses: map[int]interface{}{200: nil}},
+		"GET /healthz": {Summary: "Sonda de vida: o processo está no ar", Tags: ops,
+			Responses: map[int]interface{}{200: openapi.Object{"status": ""}}},
+		"GET /readyz": {Summary: "Sonda de prontidão: banco, migrações e pools de entrega; 503 se alguma verificação falhar", Tags: ops,
+			Responses: map[int]interface{}{200: readiness, 503: readiness}},
+
+		"POST /v1/auth/signup": {Summary: "Cadastra um usuário", Tags: auth, Request: model.User{},
+			Responses: map[int]interface{}{201: openapi.Object{"id": int64(0), "message": ""}}},
+		"POST /v1/auth/login": {Summary: "Entra com usuário ou email e senha", Tags: auth, Request: model.UserLogin{},
+			Responses: map[int]interface{}{200: openapi.OneOf{
+				services.AuthTokens{},
+				openapi.Object{"mfaRequired": true, "mfaToken": "", "expiresAt": time.Time{}},
+			}}},
+		"POST /v1/auth/login/2fa": {Summary: "Conclui o login com o segundo fator", Tags: auth, Request: model.TwoFactorLogin{},
+			Responses: map[int]interface{}{200: services.AuthTokens{}}},
+		"POST /v1/auth/refresh": {Summary: "Troca o refresh token por um novo par de tokens", Tags: auth, Request: model.RefreshTokenRequest{},
+			Responses: map[int]interface{}{200: services.AuthTokens{}}},
+		"POST /v1/auth/logout": {Summary: "Encerra a sessão atual", Tags: auth, Auth: true,
+			Responses: map[int]interface{}{200: messageResponse}},
+		"POST /v1/auth/verify-email": {Summary: "Confirma o email com o token recebido", Tags: account, Request: model.AccountToken{},
+			Responses: map[int]interface{}{200: messageResponse}},
+		"POST /v1/auth/verify-email/resend": {Summary: "Reenvia o email de verificação", Tags: account, Auth: true,
+			Responses: map[int]interface{}{200: messageResponse}},
+		"POST /v1/auth/forgot-password": {Summary: "Envia o link de redefinição de senha", Tags: account, Request: model.ForgotPassword{},
+			Responses: map[int]interface{}{200: messageResponse}},
+		"POST /v1/auth/reset-password": {Summary: "Redefine a senha com o token recebido", Tags: account, Request: model.ResetPassword{},
+			Responses: map[int]interface{}{200: messageResponse}},
+
+		"GET /v1/me": {Summary: "Perfil do usuário autenticado", Tags: account, Auth: true,
+			Responses: map[int]interface{}{200: openapi.Object{"id": 0, "username": "", "name": "", "iconBase64": "", "locale": ""}}},
+		"PUT /v1/me/locale": {Summary: "Define o idioma preferido", Tags: account, Auth: true, Request: model.LocalePreference{},
+			Responses: map[int]interface{}{200: openapi.Object{"locale": "", "message": ""}}},
+
+		"GET /v1/conversations": {Summary: "Lista as conversas com a última mensagem de cada uma", Tags: conversations, Auth: true,
+			Responses: map[int]interface{}{200: openapi.Object{"conversations": []model.UserMessage{}}}},
+		"GET /v1/conversations/:id": {Summary: "Outro participante da conversa (id é o username dele)", Tags: conversations, Auth: true,
+			Responses: map[int]interface{}{200: partnerResponse}},
+		"GET /v1/conversations/:id/messages": {Summary: "Mensagens da conversa", Tags: conversations, Auth: true,
+			Responses: map[int]interface{}{200: openapi.Object{"messages": []model.UserMessage{}}}},
+		"POST /v1/conversations/:id/messages": {Summary: "Envia uma mensagem", Tags: conversations, Auth: true,
+			Request: model.NewMessage{}, Headers: []openapi.Parameter{idempotencyKey},
+			Responses: map[int]interface{}{200: openapi.Object{"messageID": int64(0), "message": "", "clientMessageId": openapi.Optional{Sample: ""}}}},
+
+		"POST /v1/ws/tickets": {Summary: "Emite um ticket de uso único para o handshake WebSocket", Tags: realtime, Auth: true,
+			Responses: map[int]interface{}{200: openapi.Object{"ticket": "", "expiresAt": time.Time{}}}},
+		"GET /v1/ws/conversations": {Summary: "WebSocket com as atualizações da lista de conversas", Tags: realtime, Auth: true,
+			WebSocket: true, Headers: []openapi.Parameter{websocketProtocol}},
+		"GET /v1/ws/conversations/:id": {Summary: "WebSocket de uma conversa", Tags: realtime, Auth: true,
+			WebSocket: true, Headers: []openapi.Parameter{websocketProtocol}},
+
+		"GET /v1/events": {Summary: "Eventos do WebSocket versionado por Server-Sent Events (text/event-stream)", Tags: realtime, Auth: true,
+			Headers: []openapi.Parameter{lastEventID, eventCursor}, Responses: map[int]interface{}{200: nil}},
+		"GET /v1/events/poll": {Summary: "Long-poll dos eventos do WebSocket versionado", Tags: realtime, Auth: true,
+			Headers:   []openapi.Parameter{eventCursor, pollTimeout},
+			Responses: map[int]interface{}{200: openapi.Object{"events": []websockets.Envelope{}, "cursor": ""}}},
+
+		"POST /v1/2fa/setup": {Summary: "Inicia a configuração do 2FA", Tags: twoFactor, Auth: true,
+			Responses: map[int]interface{}{200: services.TwoFactorSetup{}}},
+		"POST /v1/2fa/confirm": {Summary: "Ativa o 2FA e devolve os códigos de recuperação", Tags: twoFactor, Auth: true, Request: model.TwoFactorCode{},
+			Responses: map[int]interface{}{200: openapi.Object{"recoveryCodes": []string{}, "message": ""}}},
+		"POST /v1/2fa/disable": {Summary: "Desativa o 2FA", Tags: twoFactor, Auth: true, Request: model.TwoFactorDisable{},
+			Responses: map[int]interface{}{200: messageResponse}},
+
+		"GET /v1/sessions": {Summary: "Lista as sessões ativas", Tags: sessions, Auth: true,
+			Responses: map[int]interface{}{200: openapi.Object{"sessions": []model.Session{}}}},
+		"DELETE /v1/sessions": {Summary: "Revoga todas as sessões, exceto a atual", Tags: sessions, Auth: true,
+			Responses: map[int]interface{}{200: openapi.Object{"revoked": 0, "message": ""}}},
+		"DELETE /v1/sessions/:id": {Summary: "Revoga uma sessão", Tags: sessions, Auth: true,
+			Responses: map[int]interface{}{200: messageResponse}},
+
+		"GET /v1/admin/connections": {Summary: "Conexões em tempo real abertas nesta instância, por usuário; exige um usuário de ADMIN_USER_IDS", Tags: admin, Auth: true,
+			Headers:   []openapi.Parameter{connectionsUser},
+			Responses: map[int]interface{}{200: openapi.Object{"users": []controllers.UserConnections{}, "total": 0}}},
+		"GET /v1/admin/diagnostics": {Summary: "Versão, tempo no ar, filas dos pools de workers e conexões; exige um usuário de ADMIN_USER_IDS", Tags: admin, Auth: true,
+			Responses: map[int]interface{}{200: openapi.Object{
+				"build":         controllers.BuildInfo{},
+				"startedAt":     time.Time{},
+				"uptimeSeconds": int64(0),
+				"goroutines":    0,
+				"workerPools":   []websockets.PoolStats{},
+				"connections":   openapi.Object{"total": 0, "users": 0},
+			}}},
+
+		// Rotas legadas com formato próprio
+		"POST /chat/:username": {Summary: "Mensagens da conversa, com o usuário atual e o outro participante", Tags: conversations, Auth: true,
+			Responses: map[int]interface{}{200: openapi.Object{
+				"currentUsername": currentUsername,
+				"messages":        []model.UserMessage{},
+				"userInfos":       openapi.Object{"name": "", "username": "", "iconBase64": ""},
+			}}},
+		"POST /messages": {Summary: "Lista as conversas, com o usuário atual", Tags: conversations, Auth: true,
+			Responses: map[int]interface{}{200: openapi.Object{"currentUsername": currentUsername, "chats": []model.UserMessage{}}}},
+	}
+
+	for route, successor := range legacyRoutes {
+		doc, ok := docs[route]
+		if !ok {
+			doc = docs[successor]
+		}
+		doc.Deprecated = successor
+		docs[route] = doc
+	}
+	return docs
+}
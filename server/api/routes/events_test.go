@@ -0,0 +1,88 @@
+package routes
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"messenger-pigeon-app/config/keys"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// O SSE retoma a partir do Last-Event-ID e o long-poll a partir do ?cursor=, entregando só os
+// eventos posteriores ao informado.
+func TestEventsResumeFromCursor(t *testing.T) {
+	t.Setenv("SESSION_SECRET", "events-secret")
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_JWKS_URL", "")
+	if err := keys.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	openContractStore(t)
+	websockets.Initialize()
+	server, _ := startContractServer(t)
+	token, _, err := services.IssueAccessToken(context.Background(), 7, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]string, 3)
+	for i := range ids {
+		websockets.UserConnections.Send(context.Background(), 7, model.UserMessage{MessageID: i + 1, MessageTo: 7})
+		ids[i] = websockets.UserConnections.Journal().Cursor()
+	}
+
+	get := func(ctx context.Context, path string, header http.Header) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		req.Header = header
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", path, resp.StatusCode)
+		}
+		return resp
+	}
+
+	t.Run("Last-Event-ID", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		// O cabeçalho tem precedência sobre o ?cursor=
+		resp := get(ctx, "/v1/events?cursor="+ids[0], http.Header{"Last-Event-ID": {ids[1]}})
+		defer resp.Body.Close()
+
+		var received []string
+		scanner := bufio.NewScanner(resp.Body)
+		for len(received) < 1 && scanner.Scan() {
+			if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+				received = append(received, id)
+			}
+		}
+		if len(received) != 1 || received[0] != ids[2] {
+			t.Fatalf("received ids %v, want [%s]", received, ids[2])
+		}
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		resp := get(context.Background(), "/v1/events/poll?timeout=0&cursor="+ids[0], http.Header{})
+		defer resp.Body.Close()
+		var body struct {
+			Events []websockets.Envelope `json:"events"`
+			Cursor string                `json:"cursor"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Events) != 2 || body.Events[0].ID != ids[1] || body.Events[1].ID != ids[2] || body.Cursor != ids[2] {
+			t.Fatalf("poll = %+v, want events %s and %s", body, ids[1], ids[2])
+		}
+	})
+}
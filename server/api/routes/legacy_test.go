@@ -0,0 +1,98 @@
+package routes
+
+import (
+	"context"
+	"database/sql/driver"
+	"messenger-pigeon-app/config/keys"
+	"messenger-pigeon-app/internal/testdb"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// As cinco rotas sem versão da API original seguem respondendo, com os cabeçalhos Deprecation
+// e Link para a rota /v1 que as substitui; as rotas que só existem em /v1 não têm alias.
+func TestLegacyRoutesAreDeprecatedAliases(t *testing.T) {
+	t.Setenv("SESSION_SECRET", "legacy-secret")
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_JWKS_URL", "")
+	if err := keys.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	store := openContractStore(t)
+	store.Handle("INSERT INTO user_message", func([]driver.Value) testdb.Result {
+		return testdb.Result{RowsAffected: 1, LastInsertID: 3}
+	})
+	websockets.Initialize()
+	server, _ := startContractServer(t)
+	token, _, err := services.IssueAccessToken(context.Background(), 7, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{"Authorization": {"Bearer " + token}}
+
+	checkDeprecated := func(t *testing.T, resp *http.Response, successor string) {
+		t.Helper()
+		if !strings.HasPrefix(resp.Header.Get("Deprecation"), "@") {
+			t.Errorf("Deprecation = %q, want @<unix time>", resp.Header.Get("Deprecation"))
+		}
+		if want := "<" + successor + `>; rel="successor-version"`; resp.Header.Get("Link") != want {
+			t.Errorf("Link = %q, want %q", resp.Header.Get("Link"), want)
+		}
+	}
+
+	aliases := []struct {
+		method, path, body, successor string
+	}{
+		{"POST", "/chat/bruno", "", "/v1/conversations/bruno/messages"},
+		{"POST", "/create-message/bruno", "content=oi", "/v1/conversations/bruno/messages"},
+		{"POST", "/messages", "", "/v1/conversations"},
+	}
+	for _, alias := range aliases {
+		t.Run(alias.method+" "+alias.path, func(t *testing.T) {
+			req, _ := http.NewRequest(alias.method, server.URL+alias.path, strings.NewReader(alias.body))
+			req.Header = header.Clone()
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+			checkDeprecated(t, resp, alias.successor)
+		})
+	}
+
+	// Os cabeçalhos acompanham a resposta do handshake dos WebSockets
+	sockets := []struct{ path, successor string }{
+		{"/websocket/chat/bruno", "/v1/ws/conversations/bruno"},
+		{"/websokcet/messages", "/v1/ws/conversations"},
+	}
+	for _, socket := range sockets {
+		t.Run("GET "+socket.path, func(t *testing.T) {
+			conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+socket.path, header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn.Close()
+			checkDeprecated(t, resp, socket.successor)
+		})
+	}
+
+	for _, path := range []string{"/signup", "/login", "/refresh-token", "/logout", "/websocket/ticket", "/settings/locale"} {
+		resp, err := http.Post(server.URL+path, "application/json", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("POST %s = %d, want 404", path, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"messenger-pigeon-app/config/env"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/metrics"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitMetrics publica em /metrics as métricas no formato do Prometheus. Com METRICS_TOKEN,
+// a coleta precisa enviar o cabeçalho "Authorization: Bearer <token>".
+func InitMetrics(r *gin.Engine) {
+	token := env.String("METRICS_TOKEN", "")
+	handler := gin.WrapH(metrics.Handler())
+
+	r.GET("/metrics", func(c *gin.Context) {
+		if token != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, i18n.Error(c, err.CodeInvalidToken, "error.invalid_token"))
+			return
+		}
+		handler(c)
+	})
+}
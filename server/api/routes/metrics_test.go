@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/config/keys"
+	"messenger-pigeon-app/config/middleware"
+	"messenger-pigeon-app/pkg/metrics"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scrape coleta o /metrics e retorna o valor de cada série, indexado pela linha sem o valor
+// (ex.: `pigeon_realtime_connections{endpoint="/v1/events"}`).
+func scrape(t *testing.T, server *httptest.Server) map[string]float64 {
+	t.Helper()
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /metrics status = %d", resp.StatusCode)
+	}
+
+	series := make(map[string]float64)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		value, parseErr := strconv.ParseFloat(line[i+1:], 64)
+		if parseErr != nil {
+			t.Fatalf("invalid sample %q: %v", line, parseErr)
+		}
+		series[line[:i]] = value
+	}
+	return series
+}
+
+// O /metrics expõe as métricas HTTP, dos pools (banco e workers) e das conexões em tempo
+// real, e elas mudam com as requisições.
+func TestMetricsCollectorsChangeAfterRequests(t *testing.T) {
+	t.Setenv("SESSION_SECRET", "metrics-secret")
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_JWKS_URL", "")
+	if err := keys.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	openContractStore(t)
+	// Sem conexões ociosas, cada consulta abre e fecha uma conexão do pool
+	database.GetDB().SetMaxIdleConns(0)
+	// Nome único, já que o coletor de uma execução anterior (-count) continua registrado
+	dbName := "pigeon_test_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	metrics.RegisterDB(dbName, database.GetDB())
+	websockets.Initialize()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Metrics(), middleware.Locale())
+	InitRoutes(r.Group("/"))
+	InitMetrics(r)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	token, _, err := services.IssueAccessToken(context.Background(), 7, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		requests = `pigeon_http_request_duration_seconds_count{method="GET",route="/v1/me",status="200"}`
+		queue    = `pigeon_worker_pool_queue_depth{channel="chat"}`
+		realtime = `pigeon_realtime_connections{endpoint="/v1/events"}`
+	)
+	dbPool := `go_sql_max_idle_closed_total{db_name="` + dbName + `"}`
+	openDBConn := `go_sql_open_connections{db_name="` + dbName + `"}`
+	before := scrape(t, server)
+	for _, name := range []string{dbPool, openDBConn, queue} {
+		if _, ok := before[name]; !ok {
+			t.Errorf("%s is not registered", name)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", server.URL+"/v1/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	// O long-poll, como o SSE, fica fora do histograma
+	req, _ = http.NewRequest("GET", server.URL+"/v1/events/poll?timeout=0", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /v1/events/poll status = %d", resp.StatusCode)
+	}
+
+	// Mantém um stream SSE aberto durante a coleta
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ = http.NewRequestWithContext(ctx, "GET", server.URL+"/v1/events", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Body.Close()
+	if line, _ := bufio.NewReader(stream.Body).ReadString('\n'); !strings.HasPrefix(line, "retry:") {
+		t.Fatalf("SSE stream started with %q", line)
+	}
+
+	after := scrape(t, server)
+	if after[requests] != before[requests]+1 {
+		t.Errorf("%s = %v, want %v", requests, after[requests], before[requests]+1)
+	}
+	if after[dbPool] <= before[dbPool] {
+		t.Errorf("%s = %v, want more than %v", dbPool, after[dbPool], before[dbPool])
+	}
+	if after[realtime] != before[realtime]+1 {
+		t.Errorf("%s = %v, want %v", realtime, after[realtime], before[realtime]+1)
+	}
+	for name := range after {
+		if strings.HasPrefix(name, "pigeon_http_request_duration_seconds") && strings.Contains(name, `route="/v1/events`) {
+			t.Errorf("%s is observed, want the event routes out of the histogram", name)
+		}
+	}
+}
@@ -3,15 +3,100 @@ package routes
 import (
 	"messenger-pigeon-app/config/middleware"
 	"messenger-pigeon-app/pkg/controllers"
+	"messenger-pigeon-app/pkg/openapi"
+	"messenger-pigeon-app/pkg/ratelimit"
+	"messenger-pigeon-app/pkg/websockets"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 func InitRoutes(r *gin.RouterGroup) {
-	r.Use(middleware.AuthMiddleware())
-	r.POST("/chat/:username", controllers.Chat)
-	r.POST("/create-message/:username", controllers.CreateNewMessage)
-	r.GET("/websocket/chat/:username", controllers.WebSocketChat)
-	r.POST("/messages", controllers.Messages)
-	r.GET("/websokcet/messages", controllers.WebSocketMessages)
+	r.GET("/.well-known/jwks.json", controllers.JWKS)
+
+	// Sondas do orquestrador, sem autenticação
+	r.GET("/healthz", controllers.Healthz)
+	r.GET("/readyz", controllers.Readyz)
+
+	initV1Routes(r.Group("/v1"))
+	initLegacyRoutes(r)
+}
+
+// initV1Routes monta a API versionada: GET para leituras e caminhos orientados a recursos.
+// Uma conversa é identificada pelo username do outro participante.
+func initV1Routes(v1 *gin.RouterGroup) {
+	// Autenticação, limitada por IP
+	auth := v1.Group("/auth")
+	auth.Use(middleware.RateLimit(ratelimit.ClassAuth))
+	auth.POST("/signup", controllers.Signup)
+	auth.POST("/login", controllers.Login)
+	auth.POST("/login/2fa", controllers.LoginTwoFactor)
+	auth.POST("/refresh", controllers.RefreshToken)
+	auth.POST("/verify-email", controllers.VerifyEmail)
+	auth.POST("/forgot-password", controllers.ForgotPassword)
+	auth.POST("/reset-password", controllers.ResetPassword)
+
+	// WebSocket: origem e usuário validados antes do upgrade (ticket, subprotocolo ou token)
+	ws := v1.Group("/ws")
+	ws.Use(websockets.OriginMiddleware(), middleware.WebSocketAuthMiddleware())
+	ws.GET("/conversations", controllers.WebSocketMessages)
+	ws.GET("/conversations/:id", controllers.WebSocketChat)
+
+	// Fallbacks do WebSocket para proxies que bloqueiam o upgrade, com as mesmas credenciais
+	events := v1.Group("/events")
+	events.Use(middleware.WebSocketAuthMiddleware())
+	events.GET("", controllers.EventStream)
+	events.GET("/poll", controllers.PollEvents)
+
+	api := v1.Group("/")
+	api.Use(middleware.AuthMiddleware())
+	api.GET("/me", controllers.Me)
+	api.PUT("/me/locale", controllers.UpdateLocale)
+	api.GET("/conversations", middleware.RateLimit(ratelimit.ClassHistory), controllers.ListConversations)
+	api.GET("/conversations/:id", middleware.RateLimit(ratelimit.ClassHistory), controllers.GetConversation)
+	api.GET("/conversations/:id/messages", middleware.RateLimit(ratelimit.ClassHistory), controllers.ListConversationMessages)
+	api.POST("/conversations/:id/messages", middleware.RateLimit(ratelimit.ClassSend), controllers.CreateNewMessage)
+	api.POST("/ws/tickets", controllers.WebSocketTicket)
+	api.POST("/auth/logout", controllers.Logout)
+	api.POST("/auth/verify-email/resend", middleware.RateLimit(ratelimit.ClassAuth), controllers.ResendEmailVerification)
+	api.POST("/2fa/setup", middleware.RateLimit(ratelimit.ClassAuth), controllers.TwoFactorSetup)
+	api.POST("/2fa/confirm", middleware.RateLimit(ratelimit.ClassAuth), controllers.TwoFactorConfirm)
+	api.POST("/2fa/disable", middleware.RateLimit(ratelimit.ClassAuth), controllers.TwoFactorDisable)
+	api.GET("/sessions", controllers.Sessions)
+	api.DELETE("/sessions", controllers.RevokeOtherSessions)
+	api.DELETE("/sessions/:id", controllers.RevokeSession)
+
+	// Diagnósticos, restritos aos usuários de ADMIN_USER_IDS
+	admin := v1.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	admin.GET("/connections", controllers.AdminConnections)
+	admin.GET("/diagnostics", controllers.AdminDiagnostics)
+}
+
+// initLegacyRoutes mantém as rotas sem versão como aliases obsoletos das rotas /v1. Elas
+// respondem com os mesmos formatos de antes e enviam os cabeçalhos Deprecation e Link.
+func initLegacyRoutes(r *gin.RouterGroup) {
+	historyLimit := middleware.RateLimit(ratelimit.ClassHistory)
+	sendLimit := middleware.RateLimit(ratelimit.ClassSend)
+	authenticated := middleware.AuthMiddleware()
+	websocketAuth := []gin.HandlerFunc{websockets.OriginMiddleware(), middleware.WebSocketAuthMiddleware()}
+
+	legacy(r, "GET", "/websocket/chat/:username", "GET /v1/ws/conversations/:id", append(websocketAuth, controllers.WebSocketChat)...)
+	// Com o erro de digitação do caminho original, mantido para os clientes existentes
+	legacy(r, "GET", "/websokcet/messages", "GET /v1/ws/conversations", append(websocketAuth, controllers.WebSocketMessages)...)
+
+	legacy(r, "POST", "/chat/:username", "GET /v1/conversations/:id/messages", authenticated, historyLimit, controllers.Chat)
+	legacy(r, "POST", "/create-message/:username", "POST /v1/conversations/:id/messages", authenticated, sendLimit, controllers.CreateNewMessage)
+	legacy(r, "POST", "/messages", "GET /v1/conversations", authenticated, historyLimit, controllers.Messages)
+}
+
+// Rotas legadas registradas, com a rota /v1 ("MÉTODO caminho") que substitui cada uma
+var legacyRoutes = make(map[string]string)
+
+// legacy registra uma rota obsoleta. Os cabeçalhos de obsolescência vêm antes dos demais
+// middlewares, para acompanhar também as respostas de erro (401, 429...).
+func legacy(r *gin.RouterGroup, method, path, successor string, handlers ...gin.HandlerFunc) {
+	legacyRoutes[openapi.Key(method, path)] = successor
+	successorPath := successor[strings.Index(successor, " ")+1:]
+	r.Handle(method, path, append([]gin.HandlerFunc{middleware.Deprecated(successorPath)}, handlers...)...)
 }
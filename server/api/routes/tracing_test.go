@@ -0,0 +1,197 @@
+package routes
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"messenger-pigeon-app/config/keys"
+	"messenger-pigeon-app/config/middleware"
+	"messenger-pigeon-app/internal/testdb"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	spanExporterOnce sync.Once
+	spanExporter     *tracetest.InMemoryExporter
+)
+
+// recordSpans instala um TracerProvider que exporta para a memória. O tracer global dos
+// pacotes delega ao primeiro provider instalado, então ele é o mesmo em todas as execuções.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	spanExporterOnce.Do(func() {
+		spanExporter = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanExporter)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	spanExporter.Reset()
+	return spanExporter
+}
+
+// waitSpan espera o span terminar, já que as entregas acontecem fora da requisição.
+func waitSpan(t *testing.T, exporter *tracetest.InMemoryExporter, name string) tracetest.SpanStub {
+	t.Helper()
+	return waitSpanFunc(t, exporter, name, func(span tracetest.SpanStub) bool { return span.Name == name })
+}
+
+func waitSpanFunc(t *testing.T, exporter *tracetest.InMemoryExporter, description string, match func(tracetest.SpanStub) bool) tracetest.SpanStub {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		for _, span := range exporter.GetSpans() {
+			if match(span) {
+				return span
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("span %s was not exported", description)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func assertChild(t *testing.T, child, parent tracetest.SpanStub) {
+	t.Helper()
+	if child.Parent.SpanID() != parent.SpanContext.SpanID() || child.SpanContext.TraceID() != parent.SpanContext.TraceID() {
+		t.Errorf("span %q is not a child of %q", child.Name, parent.Name)
+	}
+}
+
+// Cada requisição gera o span HTTP, com os spans dos services e do repositório como filhos,
+// e a entrega da mensagem pelo WebSocket fica ligada ao trace de quem a enviou.
+func TestRequestTraces(t *testing.T) {
+	t.Setenv("SESSION_SECRET", "tracing-secret")
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_JWKS_URL", "")
+	t.Setenv("WS_DISPATCH_MODE", "batched")
+	t.Setenv("WS_BATCH_INTERVAL", "10ms")
+	if err := keys.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	exporter := recordSpans(t)
+	store := openContractStore(t)
+	store.Handle("INSERT INTO user_message", func([]driver.Value) testdb.Result {
+		return testdb.Result{RowsAffected: 1, LastInsertID: 3}
+	})
+	websockets.Initialize()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(otelgin.Middleware("messenger-pigeon-test"), middleware.Locale())
+	InitRoutes(r.Group("/"))
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	ana, _, err := services.IssueAccessToken(context.Background(), 7, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bruno, _, err := services.IssueAccessToken(context.Background(), 8, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("service and repository", func(t *testing.T) {
+		exporter.Reset()
+		req, _ := http.NewRequest("GET", server.URL+"/v1/conversations/bruno/messages", nil)
+		req.Header.Set("Authorization", "Bearer "+ana)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		request := waitSpan(t, exporter, "/v1/conversations/:id/messages")
+		if request.SpanKind != trace.SpanKindServer {
+			t.Errorf("request span kind = %v, want server", request.SpanKind)
+		}
+		service := waitSpan(t, exporter, "services.GetChatMessages")
+		assertChild(t, service, request)
+
+		var statements int
+		for _, span := range exporter.GetSpans() {
+			if span.SpanKind == trace.SpanKindClient && span.Parent.SpanID() == service.SpanContext.SpanID() {
+				statements++
+			}
+		}
+		if statements == 0 {
+			t.Error("no repository span is a child of services.GetChatMessages")
+		}
+	})
+
+	t.Run("websocket delivery", func(t *testing.T) {
+		header := http.Header{"Authorization": {"Bearer " + bruno}}
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/v1/ws/conversations/ana", header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		// O registro da conexão acontece depois do handshake
+		for deadline := time.Now().Add(2 * time.Second); !websockets.UserConnections.IsOnline(8); {
+			if time.Now().After(deadline) {
+				t.Fatal("websocket was not registered")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		exporter.Reset()
+
+		req, _ := http.NewRequest("POST", server.URL+"/v1/conversations/bruno/messages", strings.NewReader(`{"content":"oi"}`))
+		req.Header.Set("Authorization", "Bearer "+ana)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST status = %d", resp.StatusCode)
+		}
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if _, _, err := conn.ReadMessage(); err != nil {
+			t.Fatal(err)
+		}
+
+		request := waitSpan(t, exporter, "/v1/conversations/:id/messages")
+		write := waitSpan(t, exporter, "websocket.write")
+		// Há também o lote do eco para a remetente, sem conexões abertas
+		batch := waitSpanFunc(t, exporter, "parent of websocket.write", func(span tracetest.SpanStub) bool {
+			return span.SpanContext.SpanID() == write.Parent.SpanID()
+		})
+		if batch.Name != "worker_pool.dispatch_batch" {
+			t.Fatalf("websocket.write parent = %q, want worker_pool.dispatch_batch", batch.Name)
+		}
+
+		var enqueue *tracetest.SpanStub
+		for _, span := range exporter.GetSpans() {
+			if span.Name == "worker_pool.enqueue" {
+				for _, link := range batch.Links {
+					if link.SpanContext.SpanID() == span.SpanContext.SpanID() {
+						enqueue = &span
+					}
+				}
+			}
+		}
+		if enqueue == nil {
+			t.Fatal("worker_pool.dispatch_batch has no link to a worker_pool.enqueue span")
+		}
+		assertChild(t, *enqueue, request)
+	})
+}
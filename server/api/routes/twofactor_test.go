@@ -0,0 +1,99 @@
+package routes
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"messenger-pigeon-app/config/keys"
+	"messenger-pigeon-app/config/middleware"
+	"messenger-pigeon-app/internal/totp"
+	"messenger-pigeon-app/pkg/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Com o 2FA ativo, o login devolve só um token parcial, que não autentica a API e é trocado
+// pelos tokens da sessão em /v1/auth/login/2fa junto com o código do aplicativo.
+func TestLoginTwoFactorExchange(t *testing.T) {
+	t.Setenv("SESSION_SECRET", "two-factor-secret")
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_JWKS_URL", "")
+	if err := keys.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	hash, err := services.HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := openContractStore(t)
+	store.Rows("SELECT id, password FROM user", []string{"id", "password"}, []driver.Value{int64(7), hash})
+	store.Rows("SELECT totp_secret, totp_enabled FROM user", []string{"totp_secret", "totp_enabled"}, []driver.Value{secret, true})
+	store.Exec("UPDATE user SET totp_last_step", 1)
+	store.Exec("INSERT INTO user_session", 1)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Locale())
+	InitRoutes(r.Group("/"))
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	do := func(method, path, token, body string) (*http.Response, map[string]interface{}) {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var decoded map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&decoded)
+		return resp, decoded
+	}
+
+	resp, login := do("POST", "/v1/auth/login", "", `{"login":"ana","password":"correct horse"}`)
+	if resp.StatusCode != http.StatusOK || login["mfaRequired"] != true {
+		t.Fatalf("login = %d %v, want 200 with mfaRequired", resp.StatusCode, login)
+	}
+	if _, ok := login["token"]; ok || len(resp.Cookies()) > 0 {
+		t.Fatal("login with two-factor returned session tokens")
+	}
+	mfaToken, _ := login["mfaToken"].(string)
+
+	if resp, _ := do("GET", "/v1/conversations", mfaToken, ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("API with the mfa token = %d, want 401", resp.StatusCode)
+	}
+	if resp, _ := do("POST", "/v1/auth/login/2fa", "", `{"mfaToken":"`+mfaToken+`","code":"000000"}`); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("2fa with an invalid code = %d, want 401", resp.StatusCode)
+	}
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, tokens := do("POST", "/v1/auth/login/2fa", "", `{"mfaToken":"`+mfaToken+`","code":"`+code+`"}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("2fa = %d %v, want 200", resp.StatusCode, tokens)
+	}
+	accessToken, _ := tokens["token"].(string)
+	if accessToken == "" || tokens["refreshToken"] == "" {
+		t.Fatalf("2fa response %v has no session tokens", tokens)
+	}
+	if resp, _ := do("GET", "/v1/conversations", accessToken, ""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("API with the access token = %d, want 200", resp.StatusCode)
+	}
+
+	// O token de acesso não serve como token parcial
+	if resp, _ := do("POST", "/v1/auth/login/2fa", "", `{"mfaToken":"`+accessToken+`","code":"`+code+`"}`); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("2fa with an access token = %d, want 401", resp.StatusCode)
+	}
+}
@@ -0,0 +1,125 @@
+package routes
+
+import (
+	"context"
+	"messenger-pigeon-app/config/keys"
+	"messenger-pigeon-app/config/middleware"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// startWebSocketServer sobe as rotas da API com os canais WebSocket inicializados e devolve
+// a URL ws:// da conversa da ana com o bruno.
+func startWebSocketServer(t *testing.T) string {
+	t.Helper()
+	t.Setenv("SESSION_SECRET", "websocket-secret")
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_JWKS_URL", "")
+	if err := keys.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	openContractStore(t)
+	websockets.Initialize()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Locale())
+	InitRoutes(r.Group("/"))
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/v1/ws/conversations/bruno"
+}
+
+// dialStatus abre o WebSocket e devolve o status HTTP do handshake.
+func dialStatus(t *testing.T, url string, header http.Header, subprotocols ...string) (int, *websocket.Conn) {
+	t.Helper()
+	dialer := websocket.Dialer{Subprotocols: subprotocols, HandshakeTimeout: 2 * time.Second}
+	conn, resp, err := dialer.Dial(url, header)
+	if resp == nil {
+		t.Fatalf("dial %s: %v", url, err)
+	}
+	if conn != nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+	return resp.StatusCode, conn
+}
+
+func issueTicket(t *testing.T) string {
+	t.Helper()
+	ticket, _, err := services.IssueWebSocketTicket(7, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ticket
+}
+
+func TestWebSocketTicketIsSingleUse(t *testing.T) {
+	url := startWebSocketServer(t)
+	ticket := issueTicket(t)
+
+	if status, _ := dialStatus(t, url+"?ticket="+ticket, nil); status != http.StatusSwitchingProtocols {
+		t.Fatalf("first handshake status = %d, want 101", status)
+	}
+	if status, _ := dialStatus(t, url+"?ticket="+ticket, nil); status != http.StatusUnauthorized {
+		t.Fatalf("second handshake status = %d, want 401", status)
+	}
+}
+
+func TestWebSocketTicketExpires(t *testing.T) {
+	t.Setenv("WS_TICKET_TTL", "20ms")
+	url := startWebSocketServer(t)
+	ticket := issueTicket(t)
+
+	time.Sleep(40 * time.Millisecond)
+	if status, _ := dialStatus(t, url+"?ticket="+ticket, nil); status != http.StatusUnauthorized {
+		t.Fatalf("handshake status = %d, want 401", status)
+	}
+}
+
+// A origem é conferida antes da autenticação: o ticket apresentado por uma página de outra
+// origem não é consumido.
+func TestWebSocketOriginRejectedBeforeUpgrade(t *testing.T) {
+	t.Setenv("WS_ALLOWED_ORIGINS", "https://app.example.com")
+	url := startWebSocketServer(t)
+	ticket := issueTicket(t)
+
+	status, _ := dialStatus(t, url+"?ticket="+ticket, http.Header{"Origin": {"https://evil.example.com"}})
+	if status != http.StatusForbidden {
+		t.Fatalf("foreign origin status = %d, want 403", status)
+	}
+	status, _ = dialStatus(t, url+"?ticket="+ticket, http.Header{"Origin": {"https://app.example.com"}})
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("allowed origin status = %d, want 101", status)
+	}
+}
+
+// O token enviado como subprotocolo autentica o handshake, e o servidor responde só com o
+// subprotocolo de dados, sem ecoar o token.
+func TestWebSocketTokenSubprotocol(t *testing.T) {
+	url := startWebSocketServer(t)
+	token, _, err := services.IssueAccessToken(context.Background(), 7, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, conn := dialStatus(t, url, nil, websockets.SubprotocolV1JSON, middleware.TokenSubprotocolPrefix+token)
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status = %d, want 101", status)
+	}
+	if got := conn.Subprotocol(); got != websockets.SubprotocolV1JSON {
+		t.Errorf("negotiated subprotocol = %q, want %q", got, websockets.SubprotocolV1JSON)
+	}
+
+	status, _ = dialStatus(t, url, nil, websockets.SubprotocolV1JSON, middleware.TokenSubprotocolPrefix+"not-a-jwt")
+	if status != http.StatusUnauthorized {
+		t.Fatalf("invalid token status = %d, want 401", status)
+	}
+}
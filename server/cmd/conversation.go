@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/logging"
+	"messenger-pigeon-app/pkg/services"
+	"os"
+	"strconv"
+)
+
+const conversationUsage = `usage: conversation export [--format json|csv] [--output FILE] <username|email> <username|email>`
+
+// runConversationCommand executa "conversation export": grava as mensagens trocadas entre
+// dois usuários, da mais antiga para a mais recente, na saída padrão ou em --output.
+func runConversationCommand(args []string) {
+	if len(args) == 0 || args[0] != "export" {
+		fmt.Fprintln(os.Stderr, conversationUsage)
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("conversation export", flag.ExitOnError)
+	format := flags.String("format", "json", "output format (json or csv)")
+	output := flags.String("output", "", "output file (default standard output)")
+	flags.Parse(args[1:])
+	if flags.NArg() != 2 || (*format != "json" && *format != "csv") {
+		fmt.Fprintln(os.Stderr, conversationUsage)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	database.InitializeDB()
+	user1ID := findUser(ctx, flags.Arg(0))
+	user2ID := findUser(ctx, flags.Arg(1))
+
+	messages, err := services.ExportConversation(ctx, user1ID, user2ID)
+	if err != nil {
+		logging.Fatal("Failed to export conversation", "error", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			logging.Fatal("Failed to create output file", "error", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if *format == "csv" {
+		err = writeMessagesCSV(w, messages)
+	} else {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if messages == nil {
+			messages = []model.ExportedMessage{}
+		}
+		err = encoder.Encode(messages)
+	}
+	if err != nil {
+		logging.Fatal("Failed to write export", "error", err)
+	}
+
+	if *output != "" {
+		fmt.Printf("exported %d messages to %s\n", len(messages), *output)
+	}
+}
+
+func writeMessagesCSV(w io.Writer, messages []model.ExportedMessage) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"messageId", "from", "to", "content", "createdAt", "clientMessageId"})
+	for _, message := range messages {
+		writer.Write([]string{
+			strconv.FormatInt(message.MessageID, 10),
+			message.From,
+			message.To,
+			message.Content,
+			message.CreatedAt,
+			message.ClientMessageID,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"messenger-pigeon-app/config/env"
+	"messenger-pigeon-app/config/keys"
+	"messenger-pigeon-app/pkg/logging"
+	"os"
+	"time"
+)
+
+// runKeysCommand executa "keys rotate": gera uma nova chave de assinatura em JWT_KEYS_DIR
+// e remove as chaves antigas além das --keep mais recentes cujos tokens já expiraram. Os
+// servidores recarregam o diretório e passam a assinar com a nova chave após
+// JWT_KEY_ACTIVATION_DELAY; as anteriores seguem válidas para os tokens já emitidos.
+func runKeysCommand(args []string) {
+	if len(args) == 0 || args[0] != "rotate" {
+		fmt.Fprintln(os.Stderr, "usage: keys rotate [--dir DIR] [--alg EdDSA|RS256] [--keep N]")
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("keys rotate", flag.ExitOnError)
+	dir := flags.String("dir", env.String("JWT_KEYS_DIR", ""), "directory holding the <kid>.pem keys")
+	alg := flags.String("alg", keys.AlgEdDSA, "algorithm of the new key (EdDSA or RS256)")
+	keep := flags.Int("keep", 3, "number of private keys to keep, including the new one")
+	flags.Parse(args[1:])
+
+	if *dir == "" {
+		logging.Fatal("Keys directory not set: use --dir or JWT_KEYS_DIR")
+	}
+
+	retention := keys.ActivationDelay() + env.Duration("ACCESS_TOKEN_TTL", 15*time.Minute)
+	key, removed, err := keys.Rotate(*dir, *alg, *keep, retention)
+	if err != nil {
+		logging.Fatal("Failed to rotate keys", "error", err)
+	}
+
+	fmt.Printf("new signing key: %s (%s)\n", key.ID, key.Algorithm)
+	for _, kid := range removed {
+		fmt.Printf("removed key: %s\n", kid)
+	}
+}
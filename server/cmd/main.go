@@ -1,38 +1,54 @@
 package main
 
 import (
-	"log"
-	"messenger-pigeon-app/api/routes"
-	"messenger-pigeon-app/config/database"
-	"net/http"
-
-	"github.com/gin-contrib/cors"
-	"github.com/gin-contrib/gzip"
-	"github.com/gin-gonic/gin"
+	"fmt"
+	"messenger-pigeon-app/pkg/logging"
+	"os"
+	"strings"
+
 	"github.com/joho/godotenv"
 )
 
-func main() {
-
-	godotenv.Load()
-	database.InitializeDB()
+const usage = `usage: server [command] [arguments]
 
-	r := gin.Default()
-	r.Use(gzip.Gzip(gzip.DefaultCompression))
+commands:
+  serve                                       start the HTTP and gRPC servers (default)
+  migrate [--status]                          apply pending database migrations
+  keys rotate                                 generate a new signing key
+  user create|disable|reset-password          manage user accounts
+  conversation export <user> <other>          export the messages between two users
+  messages purge --older-than AGE             delete old messages
 
-	// Configuração do CORS
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"*"}                             // Permitir todas as origens
-	config.AllowMethods = []string{"GET", "POST", "OPTIONS"}        // Métodos permitidos
-	config.AllowHeaders = []string{"Authorization", "Content-Type"} // Cabeçalhos permitidos
+Run "server <command> --help" for the arguments of each command.`
 
-	r.Use(cors.New(config))
+// Todos os comandos leem a mesma configuração (.env e variáveis de ambiente) e usam o mesmo
+// banco e camada de serviços do servidor.
+func main() {
+	godotenv.Load()
+	logging.Initialize()
 
-	// Inicializar rotas
-	routes.InitRoutes(r.Group("/"))
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
 
-	err := http.ListenAndServe(":8081", r)
-	if err != nil {
-		log.Fatal("Failed to start server: ", err)
+	switch command {
+	case "serve":
+		runServeCommand(args)
+	case "migrate":
+		runMigrateCommand(args)
+	case "keys":
+		runKeysCommand(args)
+	case "user":
+		runUserCommand(args)
+	case "conversation":
+		runConversationCommand(args)
+	case "messages":
+		runMessagesCommand(args)
+	case "help":
+		fmt.Println(usage)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
 	}
 }
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	valid := map[string]time.Duration{
+		"90d": 90 * 24 * time.Hour,
+		"1d":  24 * time.Hour,
+		"36h": 36 * time.Hour,
+		"90m": 90 * time.Minute,
+	}
+	for value, want := range valid {
+		if age, err := parseAge(value); err != nil || age != want {
+			t.Errorf("parseAge(%q) = %v, %v; want %v", value, age, err, want)
+		}
+	}
+
+	for _, value := range []string{"", "0d", "-1d", "0s", "-2h", "d", "1.5d", "90", "ninety days"} {
+		if age, err := parseAge(value); err == nil {
+			t.Errorf("parseAge(%q) = %v, want an error", value, age)
+		}
+	}
+}
+
+// Variável que faz o binário de teste executar o main com os argumentos em PIGEON_CLI_ARGS.
+const cliArgsEnv = "PIGEON_CLI_ARGS"
+
+func TestMain(m *testing.M) {
+	if args, ok := os.LookupEnv(cliArgsEnv); ok {
+		os.Args = append([]string{"server"}, strings.Split(args, "\n")...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCLI executa o comando num subprocesso, já que os comandos encerram o processo nos erros.
+func runCLI(t *testing.T, args ...string) (exitCode int, stderr string) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0])
+	// Banco inacessível: um comando que passar da validação falha com outro código de saída
+	cmd.Env = append(os.Environ(), cliArgsEnv+"="+strings.Join(args, "\n"), "DB_DSN=", "DB_HOST=127.0.0.1:1")
+	var output strings.Builder
+	cmd.Stderr = &output
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), output.String()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return 0, output.String()
+}
+
+// Argumentos inválidos dos comandos destrutivos encerram com o código 2 e o uso, antes de
+// abrir o banco.
+func TestInvalidArgumentsExitBeforeTouchingTheDatabase(t *testing.T) {
+	cases := []struct {
+		args  []string
+		usage string
+	}{
+		{[]string{"unknown"}, "usage: server"},
+		{[]string{"messages"}, "usage: messages purge"},
+		{[]string{"messages", "delete", "--older-than", "90d"}, "usage: messages purge"},
+		{[]string{"messages", "purge"}, "usage: messages purge"},
+		{[]string{"messages", "purge", "--older-than", "0d"}, "usage: messages purge"},
+		{[]string{"messages", "purge", "--older-than", "90"}, "usage: messages purge"},
+		{[]string{"messages", "purge", "--older-than", "90d", "--batch", "0"}, "usage: messages purge"},
+		{[]string{"user"}, "user disable"},
+		{[]string{"user", "delete", "ana"}, "user disable"},
+		{[]string{"user", "disable"}, "user disable"},
+		{[]string{"user", "disable", "ana", "bruno"}, "user disable"},
+		{[]string{"user", "reset-password", "--password", "short", "ana"}, "--password"},
+		{[]string{"user", "reset-password"}, "user reset-password"},
+		{[]string{"conversation", "export", "ana"}, "usage: conversation export"},
+		{[]string{"conversation", "export", "--format", "xml", "ana", "bruno"}, "usage: conversation export"},
+	}
+	for _, tc := range cases {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			code, stderr := runCLI(t, tc.args...)
+			if code != 2 {
+				t.Fatalf("exit code = %d, want 2; stderr:\n%s", code, stderr)
+			}
+			if !strings.Contains(stderr, tc.usage) {
+				t.Errorf("stderr does not contain %q:\n%s", tc.usage, stderr)
+			}
+		})
+	}
+}
+
+// Com argumentos válidos o comando segue até o banco, que no teste está inacessível.
+func TestValidPurgeArgumentsReachTheDatabase(t *testing.T) {
+	code, stderr := runCLI(t, "messages", "purge", "--older-than", "90d", "--batch", "10", "--dry-run")
+	if code != 1 || !strings.Contains(stderr, "Error pinging the database") {
+		t.Fatalf("exit code = %d, want 1 from the database connection; stderr:\n%s", code, stderr)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/pkg/logging"
+	"messenger-pigeon-app/pkg/services"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const messagesUsage = `usage: messages purge --older-than AGE [--dry-run] [--batch N]
+AGE is a number of days ("90d") or a Go duration ("36h").`
+
+// runMessagesCommand executa "messages purge": apaga as mensagens com mais que a idade
+// informada, em lotes. Ctrl+C interrompe entre um lote e outro.
+func runMessagesCommand(args []string) {
+	if len(args) == 0 || args[0] != "purge" {
+		fmt.Fprintln(os.Stderr, messagesUsage)
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("messages purge", flag.ExitOnError)
+	olderThan := flags.String("older-than", "", `minimum age of the messages to delete, e.g. "90d"`)
+	dryRun := flags.Bool("dry-run", false, "only count the messages that would be deleted")
+	batch := flags.Int("batch", 1000, "messages deleted per statement")
+	flags.Parse(args[1:])
+
+	age, err := parseAge(*olderThan)
+	if err != nil || *batch < 1 {
+		fmt.Fprintln(os.Stderr, messagesUsage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	database.InitializeDB()
+
+	if *dryRun {
+		count, err := services.CountMessagesOlderThan(ctx, age)
+		if err != nil {
+			logging.Fatal("Failed to count messages", "error", err)
+		}
+		fmt.Printf("%d messages older than %s would be deleted\n", count, *olderThan)
+		return
+	}
+
+	deleted, err := services.PurgeMessages(ctx, age, *batch)
+	fmt.Printf("deleted %d messages older than %s\n", deleted, *olderThan)
+	if err != nil {
+		logging.Fatal("Failed to purge messages", "error", err)
+	}
+}
+
+// parseAge aceita um número de dias ("90d") ou uma duração do Go ("36h"). A idade precisa ser positiva.
+func parseAge(value string) (time.Duration, error) {
+	var age time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		age = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if age, err = time.ParseDuration(value); err != nil {
+			return 0, err
+		}
+	}
+	if age <= 0 {
+		return 0, fmt.Errorf("age must be positive: %q", value)
+	}
+	return age, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/pkg/logging"
+)
+
+// runMigrateCommand executa "migrate": aplica as migrações pendentes, como o "serve" faz ao
+// subir. Com --status, só lista as pendentes.
+func runMigrateCommand(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	status := flags.Bool("status", false, "list pending migrations without applying them")
+	flags.Parse(args)
+
+	database.InitializeDB()
+	pending, err := database.PendingMigrations(context.Background())
+	if err != nil {
+		logging.Fatal("Failed to list migrations", "error", err)
+	}
+
+	if *status {
+		if len(pending) == 0 {
+			fmt.Println("database is up to date")
+		}
+		for _, version := range pending {
+			fmt.Printf("pending: %s\n", version)
+		}
+		return
+	}
+
+	if err := database.Migrate(); err != nil {
+		logging.Fatal("Failed to apply migrations", "error", err)
+	}
+	for _, version := range pending {
+		fmt.Printf("applied: %s\n", version)
+	}
+	fmt.Println("database is up to date")
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"messenger-pigeon-app/api/routes"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/config/env"
+	"messenger-pigeon-app/config/keys"
+	"messenger-pigeon-app/config/middleware"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/controllers"
+	"messenger-pigeon-app/pkg/logging"
+	"messenger-pigeon-app/pkg/mailer"
+	"messenger-pigeon-app/pkg/ratelimit"
+	"messenger-pigeon-app/pkg/rpc"
+	"messenger-pigeon-app/pkg/tracing"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+	"strings"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-contrib/gzip"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// runServeCommand executa "serve": aplica as migrações pendentes e sobe a API HTTP e, com
+// GRPC_ADDR, a API gRPC. É o comando padrão.
+func runServeCommand(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", env.String("HTTP_ADDR", ":8081"), "HTTP listen address")
+	flags.Parse(args)
+
+	if err := keys.Initialize(); err != nil {
+		logging.Fatal("Failed to load signing keys", "error", err)
+	}
+
+	// Precisa vir antes da criação dos middlewares, que guardam o TracerProvider global
+	shutdownTracing, err := tracing.Initialize()
+	if err != nil {
+		logging.Fatal("Failed to initialize tracing", "error", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	database.InitializeDB()
+	if err := database.Migrate(); err != nil {
+		// Bancos antigos com usernames ou emails repetidos continuam funcionando como antes,
+		// sem as chaves únicas, até que as linhas sejam corrigidas e o "migrate" rodado
+		var duplicates *database.DuplicateRowsError
+		if !errors.As(err, &duplicates) {
+			logging.Fatal("Failed to apply migrations", "error", err)
+		}
+		slog.Error("Migration blocked by duplicate rows, serving without it", "version", duplicates.Version, "error", err)
+	}
+	websockets.Initialize()
+	mailer.Initialize()
+	i18n.Initialize()
+	ratelimit.Initialize(database.GetDB())
+
+	r := gin.New()
+	// O Logger fica depois do otelgin para registrar o trace de cada requisição
+	r.Use(middleware.RequestID(), otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(traceRequest)))
+	r.Use(middleware.Logger(), gin.CustomRecovery(controllers.Recovery))
+	r.Use(middleware.Metrics())
+	r.NoRoute(controllers.NotFound)
+
+	// Proxies confiáveis para o X-Forwarded-For (usado no limite por IP); por padrão nenhum
+	if err := r.SetTrustedProxies(env.List("TRUSTED_PROXIES", nil)); err != nil {
+		logging.Fatal("Invalid TRUSTED_PROXIES", "error", err)
+	}
+	r.Use(gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedPaths([]string{"/v1/events"}))) // O SSE precisa de flush a cada evento
+	r.Use(middleware.Locale())
+
+	// Configuração do CORS
+	config := cors.DefaultConfig()
+	config.AllowOrigins = []string{"*"}                                                                                    // Permitir todas as origens
+	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}                                              // Métodos permitidos
+	config.AllowHeaders = []string{"Authorization", "Content-Type", "Idempotency-Key", "Last-Event-ID", "X-Request-ID"}    // Cabeçalhos permitidos
+	config.ExposeHeaders = []string{"Retry-After", "Idempotent-Replayed", "Deprecation", "Link", "Sunset", "X-Request-ID"} // Cabeçalhos expostos ao cliente
+
+	// Contexto de trace W3C enviado pelos clientes instrumentados
+	config.AllowHeaders = append(config.AllowHeaders, "traceparent", "tracestate")
+
+	r.Use(cors.New(config))
+
+	// Inicializar rotas
+	routes.InitRoutes(r.Group("/"))
+	routes.InitMetrics(r)
+	routes.InitAsyncAPI(r)
+	routes.InitOpenAPI(r)
+	routes.InitDebug(r)
+
+	// API gRPC para serviços internos; desativada sem GRPC_ADDR (ex.: "127.0.0.1:9090"). Fora
+	// do loopback, GRPC_TLS_CERT e GRPC_TLS_KEY ativam o TLS
+	if addr := env.String("GRPC_ADDR", ""); addr != "" {
+		go func() {
+			if err := rpc.ListenAndServe(addr); err != nil {
+				logging.Fatal("Failed to start gRPC server", "error", err)
+			}
+		}()
+	}
+
+	err = http.ListenAndServe(*addr, r)
+	if err != nil {
+		logging.Fatal("Failed to start server", "error", err)
+	}
+}
+
+// traceRequest deixa de fora dos traces as conexões de longa duração (WebSocket, SSE e
+// long-poll), cujo span duraria a conexão inteira, os perfis do pprof, as coletas do
+// Prometheus e as sondas do orquestrador.
+func traceRequest(r *http.Request) bool {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || strings.HasPrefix(r.URL.Path, "/debug/pprof/") {
+		return false
+	}
+	switch r.URL.Path {
+	case "/v1/events", "/metrics", "/healthz", "/readyz":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/logging"
+	"messenger-pigeon-app/pkg/repository"
+	"messenger-pigeon-app/pkg/services"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+const userUsage = `usage:
+  user create --username NAME --name NAME --email EMAIL --bio BIO [--password P] [--locale L] [--verified]
+  user disable <username|email>
+  user reset-password [--password P] <username|email>`
+
+// runUserCommand executa "user create|disable|reset-password".
+func runUserCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, userUsage)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "create":
+		runUserCreate(args[1:])
+	case "disable":
+		runUserDisable(args[1:])
+	case "reset-password":
+		runUserResetPassword(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, userUsage)
+		os.Exit(2)
+	}
+}
+
+// runUserCreate cadastra um usuário com as mesmas regras do cadastro pela API. Sem --password,
+// uma senha aleatória é gerada e exibida.
+func runUserCreate(args []string) {
+	flags := flag.NewFlagSet("user create", flag.ExitOnError)
+	username := flags.String("username", "", "username (4 to 32 characters)")
+	name := flags.String("name", "", "display name")
+	email := flags.String("email", "", "email address")
+	bio := flags.String("bio", "", "profile bio")
+	password := flags.String("password", "", "password (8 to 16 characters); generated if empty")
+	locale := flags.String("locale", "", "preferred language (default DEFAULT_LOCALE)")
+	verified := flags.Bool("verified", false, "mark the email as verified")
+	flags.Parse(args)
+
+	generated := *password == ""
+	if generated {
+		var err error
+		if *password, err = services.GeneratePassword(); err != nil {
+			logging.Fatal("Failed to generate password", "error", err)
+		}
+	}
+
+	i18n.Initialize()
+	user := model.User{
+		Username:        strings.TrimSpace(*username),
+		Name:            *name,
+		Bio:             *bio,
+		Email:           *email,
+		Password:        *password,
+		ConfirmPassword: *password,
+		Locale:          i18n.Default(),
+	}
+	if *locale != "" {
+		matched, ok := i18n.Match(*locale)
+		if !ok {
+			exitInvalid("--locale: " + i18n.T(i18n.English, "error.unsupported_locale"))
+		}
+		user.Locale = matched
+	}
+	if err := binding.Validator.ValidateStruct(&user); err != nil {
+		exitInvalid(validationMessage(err))
+	}
+
+	ctx := context.Background()
+	database.InitializeDB()
+
+	fields, err := services.CheckUserAvailability(ctx, user)
+	if err != nil {
+		logging.Fatal("Failed to check user availability", "error", err)
+	}
+	if len(fields) > 0 {
+		var problems []string
+		for field, key := range fields {
+			problems = append(problems, "--"+field+": "+i18n.T(i18n.English, key))
+		}
+		sort.Strings(problems)
+		exitInvalid(strings.Join(problems, "\n"))
+	}
+
+	userID, err := services.RegisterUser(ctx, user)
+	if err != nil {
+		logging.Fatal("Failed to create user", "error", err)
+	}
+	if *verified {
+		if err := services.MarkEmailVerified(ctx, int(userID)); err != nil {
+			logging.Fatal("Failed to mark email as verified", "user_id", userID, "error", err)
+		}
+	}
+
+	fmt.Printf("created user %s (id %d)\n", user.Username, userID)
+	if generated {
+		fmt.Printf("password: %s\n", *password)
+	}
+}
+
+// runUserDisable desativa o usuário e revoga suas sessões. Conexões em tempo real já abertas
+// só são encerradas quando o cliente reconectar ou renovar o token.
+func runUserDisable(args []string) {
+	flags := flag.NewFlagSet("user disable", flag.ExitOnError)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, userUsage)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	database.InitializeDB()
+	userID := findUser(ctx, flags.Arg(0))
+
+	disabled, revoked, err := services.DisableUser(ctx, userID)
+	if err != nil {
+		logging.Fatal("Failed to disable user", "user_id", userID, "error", err)
+	}
+	if !disabled {
+		fmt.Printf("user %d was already disabled\n", userID)
+	} else {
+		fmt.Printf("disabled user %d\n", userID)
+	}
+	fmt.Printf("revoked sessions: %d\n", len(revoked))
+}
+
+// runUserResetPassword define uma nova senha e revoga as sessões do usuário. Sem --password,
+// uma senha aleatória é gerada e exibida.
+func runUserResetPassword(args []string) {
+	flags := flag.NewFlagSet("user reset-password", flag.ExitOnError)
+	password := flags.String("password", "", "new password (8 to 16 characters); generated if empty")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, userUsage)
+		os.Exit(2)
+	}
+
+	generated := *password == ""
+	if generated {
+		var err error
+		if *password, err = services.GeneratePassword(); err != nil {
+			logging.Fatal("Failed to generate password", "error", err)
+		}
+	}
+	// Mesmas regras da redefinição pela API
+	if err := binding.Validator.ValidateStruct(&model.ResetPassword{Token: "-", Password: *password, ConfirmPassword: *password}); err != nil {
+		exitInvalid(validationMessage(err))
+	}
+
+	ctx := context.Background()
+	database.InitializeDB()
+	userID := findUser(ctx, flags.Arg(0))
+
+	revoked, err := services.SetPassword(ctx, userID, *password)
+	if err != nil {
+		logging.Fatal("Failed to reset password", "user_id", userID, "error", err)
+	}
+
+	fmt.Printf("password reset for user %d\n", userID)
+	if generated {
+		fmt.Printf("password: %s\n", *password)
+	}
+	fmt.Printf("revoked sessions: %d\n", len(revoked))
+}
+
+// findUser obtém o ID do usuário pelo username ou email, encerrando o comando se ele não existir.
+func findUser(ctx context.Context, login string) int {
+	userID, err := services.FindUserID(ctx, login)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		exitInvalid(fmt.Sprintf("user %q not found", login))
+	}
+	if err != nil {
+		logging.Fatal("Failed to find user", "login", login, "error", err)
+	}
+	return userID
+}
+
+// validationMessage descreve os erros de validação por flag, com a regra que falhou.
+func validationMessage(err error) string {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err.Error()
+	}
+
+	var problems []string
+	for _, fieldErr := range validationErrs {
+		problems = append(problems, "--"+strings.ToLower(fieldErr.Field())+": "+i18n.T(i18n.English, "validation.rule", fieldErr.Tag()))
+	}
+	return strings.Join(problems, "\n")
+}
+
+// exitInvalid encerra o comando por erro nos argumentos.
+func exitInvalid(message string) {
+	fmt.Fprintln(os.Stderr, message)
+	os.Exit(2)
+}
@@ -2,24 +2,27 @@ package database
 
 import (
 	"database/sql"
-	"log"
+	"messenger-pigeon-app/config/env"
+	"messenger-pigeon-app/pkg/logging"
+	"messenger-pigeon-app/pkg/metrics"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
 var db *sql.DB
 
-// InitializeDB inicializa o pool de conexões com o banco de dados.
+// InitializeDB inicializa o pool de conexões com o banco de dados, usado pelo servidor e
+// pelos comandos administrativos.
 func InitializeDB() {
-	user := "root"
-	password := "2009"
-	_db := "mydb"
-	dbSource := user + ":" + password + "@tcp(localhost:3306)/" + _db
+	config, err := configFromEnv()
+	if err != nil {
+		logging.Fatal("Invalid database configuration", "error", err)
+	}
 
 	// Abre uma conexão com o banco de dados MySQL.
-	conn, err := sql.Open("mysql", dbSource)
+	conn, err := sql.Open("mysql", config.FormatDSN())
 	if err != nil {
-		log.Fatal("Error connecting to the database:", err)
+		logging.Fatal("Error connecting to the database", "error", err)
 	}
 
 	// Define o número máximo de conexões abertas.
@@ -28,10 +31,27 @@ func InitializeDB() {
 	// Testa a conexão com o banco de dados para garantir que a conexão foi bem-sucedida.
 	err = conn.Ping()
 	if err != nil {
-		log.Fatal("Error pinging the database:", err)
+		logging.Fatal("Error pinging the database", "error", err)
 	}
 
 	db = conn
+	metrics.RegisterDB(config.DBName, conn)
+}
+
+// configFromEnv lê a conexão de DB_DSN (no formato do driver, ex.:
+// "user:senha@tcp(host:3306)/banco") ou, sem ele, de DB_USER, DB_PASSWORD, DB_HOST e DB_NAME.
+func configFromEnv() (*mysql.Config, error) {
+	if dsn := env.String("DB_DSN", ""); dsn != "" {
+		return mysql.ParseDSN(dsn)
+	}
+
+	config := mysql.NewConfig()
+	config.User = env.String("DB_USER", "root")
+	config.Passwd = env.String("DB_PASSWORD", "2009")
+	config.Net = "tcp"
+	config.Addr = env.String("DB_HOST", "localhost:3306")
+	config.DBName = env.String("DB_NAME", "mydb")
+	return config, nil
 }
 
 // GetDB retorna a conexão com o banco de dados MySQL.
@@ -39,3 +59,8 @@ func GetDB() *sql.DB {
 	// Retorna a conexão existente com o banco de dados.
 	return db
 }
+
+// SetDB substitui a conexão com o banco de dados, para os testes usarem um banco em memória.
+func SetDB(conn *sql.DB) {
+	db = conn
+}
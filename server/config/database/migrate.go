@@ -0,0 +1,241 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrate aplica, em ordem, as migrações de migrations/ que ainda não foram registradas
+// na tabela schema_migrations. Um comando que cria um índice já existente (com o mesmo nome)
+// é ignorado, para que migrações possam acrescentar chaves que só faltam em bancos antigos.
+// Se o banco tiver valores repetidos nas colunas que uma migração torna únicas, ela e as
+// seguintes ficam pendentes e o erro é um *DuplicateRowsError.
+func Migrate() error {
+	return migrate(GetDB())
+}
+
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version VARCHAR(255) NOT NULL PRIMARY KEY,
+		applied_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	applied, err := appliedMigrations(context.Background(), db)
+	if err != nil {
+		return err
+	}
+
+	versions, err := migrationVersions()
+	if err != nil {
+		return err
+	}
+
+	for _, version := range versions {
+		if applied[version] {
+			continue
+		}
+		if err := checkUniqueKeys(db, version); err != nil {
+			return err
+		}
+
+		content, err := migrationFiles.ReadFile("migrations/" + version)
+		if err != nil {
+			return fmt.Errorf("failed to read migration %s: %w", version, err)
+		}
+
+		// O driver não aceita vários comandos por Exec, então cada comando é executado separadamente.
+		for _, statement := range strings.Split(string(content), ";") {
+			if strings.TrimSpace(statement) == "" {
+				continue
+			}
+			if _, err := db.Exec(statement); err != nil {
+				if isMySQLError(err, mysqlDuplicateKeyName) {
+					slog.Info("Skipping existing index", "version", version, "error", err)
+					continue
+				}
+				if isMySQLError(err, mysqlDuplicateEntry) {
+					// Linhas repetidas gravadas depois da verificação
+					blockedVersion.Store(version)
+					return &DuplicateRowsError{Version: version}
+				}
+				return fmt.Errorf("failed to apply migration %s: %w", version, err)
+			}
+		}
+
+		if _, err := db.Exec("INSERT INTO schema_migrations(version, applied_at) VALUES (?, NOW())", version); err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", version, err)
+		}
+		slog.Info("Applied migration", "version", version)
+	}
+
+	blockedVersion.Store("")
+	return nil
+}
+
+// uniqueColumn é uma coluna que passa a ter chave única numa migração.
+type uniqueColumn struct {
+	table, column string
+}
+
+// uniqueKeys lista, por migração, as colunas que ganham chave única em bancos antigos. Valores
+// repetidos são verificados antes, já que o ALTER falharia com o erro 1062.
+var uniqueKeys = map[string][]uniqueColumn{
+	"0010_initial_keys.sql": {{"user", "username"}, {"user", "email"}},
+}
+
+// Máximo de valores repetidos listados por coluna no erro
+const maxReportedDuplicates = 20
+
+// Duplicate é um valor repetido numa coluna que precisa ser única.
+type Duplicate struct {
+	Table, Column, Value string
+	Rows                 int64
+}
+
+// DuplicateRowsError indica que uma migração não foi aplicada porque o banco tem valores
+// repetidos nas colunas que ela torna únicas. As linhas precisam ser corrigidas à mão (ex.:
+// renomear o username ou apagar a conta repetida) antes de rodar "server migrate" de novo.
+type DuplicateRowsError struct {
+	Version    string
+	Duplicates []Duplicate // Vazio se a repetição só apareceu no ALTER
+}
+
+func (e *DuplicateRowsError) Error() string {
+	found := "duplicate values"
+	if len(e.Duplicates) > 0 {
+		values := make([]string, len(e.Duplicates))
+		for i, d := range e.Duplicates {
+			values[i] = fmt.Sprintf("%s.%s %q (%d rows)", d.Table, d.Column, d.Value, d.Rows)
+		}
+		found = strings.Join(values, ", ")
+	}
+	return fmt.Sprintf("migration %s adds unique keys, but the database has %s; fix the duplicate rows and run \"server migrate\"", e.Version, found)
+}
+
+// Migração interrompida por linhas repetidas na última execução do Migrate ("" se nenhuma)
+var blockedVersion atomic.Value
+
+// BlockedMigration retorna a migração que o Migrate deixou de aplicar por causa de linhas
+// repetidas, ou "" se nenhuma. As migrações seguintes também ficam pendentes.
+func BlockedMigration() string {
+	version, _ := blockedVersion.Load().(string)
+	return version
+}
+
+// checkUniqueKeys procura valores repetidos nas colunas que a migração torna únicas.
+func checkUniqueKeys(db *sql.DB, version string) error {
+	var duplicates []Duplicate
+	for _, key := range uniqueKeys[version] {
+		rows, err := db.Query(fmt.Sprintf("SELECT %[2]s, COUNT(*) FROM %[1]s GROUP BY %[2]s HAVING COUNT(*) > 1 LIMIT %[3]d",
+			key.table, key.column, maxReportedDuplicates))
+		if err != nil {
+			return fmt.Errorf("failed to check duplicate %s.%s before migration %s: %w", key.table, key.column, version, err)
+		}
+		for rows.Next() {
+			d := Duplicate{Table: key.table, Column: key.column}
+			if err := rows.Scan(&d.Value, &d.Rows); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan duplicate %s.%s: %w", key.table, key.column, err)
+			}
+			duplicates = append(duplicates, d)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("failed to check duplicate %s.%s before migration %s: %w", key.table, key.column, version, err)
+		}
+	}
+
+	if len(duplicates) > 0 {
+		blockedVersion.Store(version)
+		return &DuplicateRowsError{Version: version, Duplicates: duplicates}
+	}
+	return nil
+}
+
+// Códigos de erro do MySQL
+const (
+	mysqlDuplicateKeyName = 1061 // Já existe um índice com esse nome
+	mysqlDuplicateEntry   = 1062 // Valor repetido numa chave única
+	mysqlNoSuchTable      = 1146
+)
+
+func isMySQLError(err error, number uint16) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == number
+}
+
+// PendingMigrations retorna as migrações embutidas no binário que ainda não foram aplicadas.
+// Num banco sem a tabela schema_migrations, todas estão pendentes.
+func PendingMigrations(ctx context.Context) ([]string, error) {
+	applied, err := appliedMigrations(ctx, GetDB())
+	if isMySQLError(err, mysqlNoSuchTable) {
+		applied, err = map[string]bool{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := migrationVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []string
+	for _, version := range versions {
+		if !applied[version] {
+			pending = append(pending, version)
+		}
+	}
+	return pending, nil
+}
+
+func appliedMigrations(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+func migrationVersions() ([]string, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".sql") {
+			versions = append(versions, entry.Name())
+		}
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
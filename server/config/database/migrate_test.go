@@ -0,0 +1,117 @@
+package database
+
+import (
+	"database/sql/driver"
+	"errors"
+	"messenger-pigeon-app/internal/testdb"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// openMigrated simula um banco com todas as migrações aplicadas, menos as informadas.
+func openMigrated(t *testing.T, pending ...string) *testdb.Store {
+	t.Helper()
+	versions, err := migrationVersions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applied [][]driver.Value
+	for _, version := range versions {
+		if !contains(pending, version) {
+			applied = append(applied, []driver.Value{version})
+		}
+	}
+
+	db, store := testdb.Open()
+	SetDB(db)
+	t.Cleanup(func() { SetDB(nil) })
+	store.Rows("SELECT version FROM schema_migrations", []string{"version"}, applied...)
+	return store
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Num banco criado pela 0001 as chaves já existem: a 0010 é registrada mesmo assim.
+func TestMigrateSkipsExistingKeys(t *testing.T) {
+	store := openMigrated(t, "0010_initial_keys.sql")
+	store.Handle("ALTER TABLE user ADD UNIQUE KEY uq_user_username", func([]driver.Value) testdb.Result {
+		return testdb.Result{Err: &mysql.MySQLError{Number: mysqlDuplicateKeyName, Message: "Duplicate key name 'uq_user_username'"}}
+	})
+	store.Exec("", 0)
+
+	if err := Migrate(); err != nil {
+		t.Fatalf("Migrate(): %v", err)
+	}
+	if blocked := BlockedMigration(); blocked != "" {
+		t.Fatalf("BlockedMigration() = %q after a successful migration", blocked)
+	}
+	if alters := store.Calls("ALTER TABLE"); len(alters) != 4 {
+		t.Fatalf("%d ALTER TABLE statements, want 4", len(alters))
+	}
+	recorded := store.Calls("INSERT INTO schema_migrations")
+	if len(recorded) != 1 || recorded[0].Args[0] != "0010_initial_keys.sql" {
+		t.Fatalf("recorded migrations = %v, want 0010_initial_keys.sql", recorded)
+	}
+}
+
+// Valores repetidos nas colunas únicas são reportados antes do ALTER, e a migração fica pendente.
+func TestMigrateReportsDuplicateRows(t *testing.T) {
+	store := openMigrated(t, "0010_initial_keys.sql")
+	store.Rows("GROUP BY email HAVING COUNT(*) > 1", []string{"email", "count"},
+		[]driver.Value{"ana@example.com", int64(2)},
+		[]driver.Value{"bruno@example.com", int64(3)},
+	)
+	store.Rows("GROUP BY username HAVING COUNT(*) > 1", []string{"username", "count"})
+	store.Exec("", 0)
+
+	err := Migrate()
+	var duplicates *DuplicateRowsError
+	if !errors.As(err, &duplicates) {
+		t.Fatalf("Migrate() error = %v, want a DuplicateRowsError", err)
+	}
+	want := []Duplicate{{"user", "email", "ana@example.com", 2}, {"user", "email", "bruno@example.com", 3}}
+	if duplicates.Version != "0010_initial_keys.sql" || !reflect.DeepEqual(duplicates.Duplicates, want) {
+		t.Fatalf("duplicates = %+v, want %+v in 0010_initial_keys.sql", duplicates, want)
+	}
+	for _, part := range []string{`user.email "ana@example.com" (2 rows)`, "server migrate"} {
+		if !strings.Contains(err.Error(), part) {
+			t.Errorf("error %q does not mention %q", err, part)
+		}
+	}
+	if alters := store.Calls("ALTER TABLE"); len(alters) != 0 {
+		t.Fatalf("keys altered despite the duplicates: %v", alters)
+	}
+	if recorded := store.Calls("INSERT INTO schema_migrations"); len(recorded) != 0 {
+		t.Fatalf("migration recorded despite the duplicates: %v", recorded)
+	}
+	if blocked := BlockedMigration(); blocked != "0010_initial_keys.sql" {
+		t.Fatalf("BlockedMigration() = %q, want 0010_initial_keys.sql", blocked)
+	}
+}
+
+// Linhas repetidas gravadas entre a verificação e o ALTER dão o mesmo erro.
+func TestMigrateDuplicateEntryDuringAlter(t *testing.T) {
+	store := openMigrated(t, "0010_initial_keys.sql")
+	store.Handle("ADD UNIQUE KEY uq_user_email", func([]driver.Value) testdb.Result {
+		return testdb.Result{Err: &mysql.MySQLError{Number: mysqlDuplicateEntry, Message: "Duplicate entry 'a@b.c' for key 'uq_user_email'"}}
+	})
+	store.Exec("", 0)
+
+	var duplicates *DuplicateRowsError
+	if err := Migrate(); !errors.As(err, &duplicates) || duplicates.Version != "0010_initial_keys.sql" {
+		t.Fatalf("Migrate() error = %v, want a DuplicateRowsError in 0010_initial_keys.sql", err)
+	}
+	if recorded := store.Calls("INSERT INTO schema_migrations"); len(recorded) != 0 {
+		t.Fatalf("migration recorded despite the failure: %v", recorded)
+	}
+}
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS user (
+    id INT NOT NULL AUTO_INCREMENT,
+    username VARCHAR(32) NOT NULL,
+    name VARCHAR(70) NOT NULL,
+    icon LONGBLOB NULL,
+    bio VARCHAR(70) NOT NULL DEFAULT '',
+    email VARCHAR(255) NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_user_username (username),
+    UNIQUE KEY uq_user_email (email)
+);
+
+CREATE TABLE IF NOT EXISTS user_message (
+    message_id INT NOT NULL AUTO_INCREMENT,
+    content TEXT NOT NULL,
+    messageBy INT NOT NULL,
+    messageTo INT NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (message_id),
+    KEY idx_user_message_by_to (messageBy, messageTo, created_at),
+    KEY idx_user_message_to_by (messageTo, messageBy, created_at)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE user_session (
+    id CHAR(32) NOT NULL,
+    user_id INT NOT NULL,
+    refresh_token_hash CHAR(64) NOT NULL,
+    device VARCHAR(255) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    last_used_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME NULL,
+    PRIMARY KEY (id),
+    KEY idx_user_session_user (user_id, revoked_at, expires_at)
+);
@@ -0,0 +1,15 @@
+ALTER TABLE user
+    ADD COLUMN email_verified_at DATETIME NULL;
+
+CREATE TABLE user_token (
+    id INT NOT NULL AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    purpose VARCHAR(32) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_user_token_hash (token_hash),
+    KEY idx_user_token_user (user_id, purpose)
+);
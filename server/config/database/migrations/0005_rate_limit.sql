@@ -0,0 +1,7 @@
+CREATE TABLE rate_limit_bucket (
+    bucket_key VARCHAR(191) NOT NULL,
+    tokens DOUBLE NOT NULL,
+    updated_at BIGINT NOT NULL,
+    PRIMARY KEY (bucket_key),
+    KEY idx_rate_limit_bucket_updated (updated_at)
+);
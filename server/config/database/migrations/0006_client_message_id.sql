@@ -0,0 +1,3 @@
+ALTER TABLE user_message
+    ADD COLUMN client_message_id VARCHAR(64) NULL,
+    ADD UNIQUE KEY uq_user_message_client_id (messageBy, client_message_id);
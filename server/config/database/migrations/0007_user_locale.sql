@@ -0,0 +1,2 @@
+ALTER TABLE user
+    ADD COLUMN locale VARCHAR(16) NULL;
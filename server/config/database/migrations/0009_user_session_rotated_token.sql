@@ -0,0 +1,6 @@
+CREATE TABLE user_session_rotated_token (
+    session_id CHAR(32) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    rotated_at DATETIME NOT NULL,
+    PRIMARY KEY (session_id, token_hash)
+);
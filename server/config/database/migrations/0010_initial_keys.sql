@@ -0,0 +1,7 @@
+-- Bancos anteriores à 0001 já tinham estas tabelas, e o CREATE TABLE IF NOT EXISTS não
+-- acrescentou as chaves. Em bancos criados pela 0001 elas já existem e o erro de chave
+-- duplicada é ignorado pelo migrador, por isso cada chave fica num comando próprio.
+ALTER TABLE user ADD UNIQUE KEY uq_user_username (username);
+ALTER TABLE user ADD UNIQUE KEY uq_user_email (email);
+ALTER TABLE user_message ADD KEY idx_user_message_by_to (messageBy, messageTo, created_at);
+ALTER TABLE user_message ADD KEY idx_user_message_to_by (messageTo, messageBy, created_at);
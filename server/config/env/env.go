@@ -0,0 +1,59 @@
+package env
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// String retorna o valor da variável de ambiente ou o valor padrão quando ela não está definida.
+func String(key, def string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return def
+}
+
+// Int retorna a variável de ambiente convertida para int, ou o valor padrão se ausente ou inválida.
+func Int(key string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// Bool retorna a variável de ambiente convertida para bool, ou o valor padrão se ausente ou inválida.
+func Bool(key string, def bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// Duration retorna a variável de ambiente no formato de time.ParseDuration (ex.: "30s"), ou o valor padrão.
+func Duration(key string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// List retorna a variável de ambiente separada por vírgulas, ignorando itens vazios.
+func List(key string, def []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
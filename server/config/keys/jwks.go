@@ -0,0 +1,161 @@
+package keys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// Intervalo mínimo entre buscas do JWKS disparadas por kids desconhecidos.
+const jwksMinRefreshInterval = 30 * time.Second
+
+// JWK é a representação JSON (RFC 7517) de uma chave pública.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKS é um conjunto de chaves públicas.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS retorna as chaves públicas locais no formato JWKS.
+func (s *KeySet) PublicJWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range s.PublicKeys() {
+		jwk, err := key.JWK()
+		if err != nil {
+			slog.Error("Error encoding JWK", "kid", key.ID, "error", err)
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+// JWK converte a parte pública da chave para JWK.
+func (k *Key) JWK() (JWK, error) {
+	switch public := k.Public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType:   "RSA",
+			KeyID:     k.ID,
+			Use:       "sig",
+			Algorithm: AlgRS256,
+			N:         base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			KeyType:   "OKP",
+			KeyID:     k.ID,
+			Use:       "sig",
+			Algorithm: AlgEdDSA,
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(public),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("unsupported key type %T", k.Public)
+	}
+}
+
+// Key converte o JWK em uma chave de verificação.
+func (j JWK) Key() (*Key, error) {
+	switch j.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		return &Key{ID: j.KeyID, Algorithm: AlgRS256, Public: public}, nil
+	case "OKP":
+		if j.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", j.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key")
+		}
+		return &Key{ID: j.KeyID, Algorithm: AlgEdDSA, Public: ed25519.PublicKey(x)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", j.KeyType)
+	}
+}
+
+// RefreshJWKS busca o JWKS configurado e substitui as chaves remotas.
+func (s *KeySet) RefreshJWKS() error {
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(s.jwksURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var jwks JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	remote := make(map[string]*Key, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.Key()
+		if err != nil {
+			slog.Warn("Ignoring JWK", "kid", jwk.KeyID, "error", err)
+			continue
+		}
+		remote[key.ID] = key
+	}
+
+	s.mu.Lock()
+	s.remote = remote
+	s.lastJWKSFetch = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *KeySet) refreshJWKSThrottled() error {
+	s.mu.RLock()
+	recent := time.Since(s.lastJWKSFetch) < jwksMinRefreshInterval
+	s.mu.RUnlock()
+	if recent {
+		return nil
+	}
+	return s.RefreshJWKS()
+}
+
+func (s *KeySet) refreshJWKSLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.RefreshJWKS(); err != nil {
+			slog.Error("Error refreshing JWKS", "url", s.jwksURL, "error", err)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"messenger-pigeon-app/config/env"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Algoritmos assimétricos aceitos.
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Key é uma chave de assinatura identificada por kid. Private é nil para chaves que
+// só servem para verificação (ex.: obtidas de um JWKS).
+type Key struct {
+	ID        string
+	Algorithm string
+	Public    crypto.PublicKey
+	Private   crypto.Signer
+	CreatedAt time.Time
+}
+
+// KeySet guarda as chaves conhecidas. Durante uma rotação, a chave anterior continua
+// no conjunto para verificar os tokens já emitidos, enquanto a mais nova assina os novos.
+type KeySet struct {
+	mu         sync.RWMutex
+	local      map[string]*Key // Chaves carregadas de arquivos
+	remote     map[string]*Key // Chaves obtidas do JWKS
+	hmacSecret []byte          // Segredo HS256 legado (SESSION_SECRET)
+
+	// Uma chave nova só assina depois de activationDelay, quando todas as instâncias já
+	// recarregaram o diretório e conseguem verificar os tokens dela.
+	keysDir         string
+	activationDelay time.Duration
+	lastDirLoad     time.Time
+
+	jwksURL       string
+	lastJWKSFetch time.Time
+}
+
+var defaultSet = &KeySet{local: map[string]*Key{}, remote: map[string]*Key{}}
+
+// Default retorna o conjunto de chaves usado pela aplicação.
+func Default() *KeySet {
+	return defaultSet
+}
+
+// Initialize carrega as chaves configuradas:
+//   - JWT_KEYS_DIR: diretório com arquivos <kid>.pem (chaves privadas PKCS#8/PKCS#1 ou públicas PKIX),
+//     relido a cada JWT_KEYS_RELOAD e quando chega um token com kid desconhecido;
+//   - JWT_JWKS_URL: JWKS com chaves públicas de outros emissores, atualizado a cada JWT_JWKS_REFRESH;
+//   - SESSION_SECRET: segredo HS256, aceito enquanto JWT_ALLOW_HMAC for verdadeiro.
+func Initialize() error {
+	set := &KeySet{
+		local:           map[string]*Key{},
+		remote:          map[string]*Key{},
+		keysDir:         env.String("JWT_KEYS_DIR", ""),
+		activationDelay: ActivationDelay(),
+		jwksURL:         env.String("JWT_JWKS_URL", ""),
+	}
+	if env.Bool("JWT_ALLOW_HMAC", true) {
+		set.hmacSecret = []byte(os.Getenv("SESSION_SECRET"))
+	}
+
+	if set.keysDir != "" {
+		if err := set.ReloadDir(); err != nil {
+			return err
+		}
+		go set.reloadDirLoop(env.Duration("JWT_KEYS_RELOAD", time.Minute))
+	}
+
+	if set.jwksURL != "" {
+		if err := set.RefreshJWKS(); err != nil {
+			return err
+		}
+		go set.refreshJWKSLoop(env.Duration("JWT_JWKS_REFRESH", 5*time.Minute))
+	}
+
+	defaultSet = set
+	return nil
+}
+
+// ActivationDelay é quanto tempo uma chave nova só verifica tokens antes de passar a assinar
+// (JWT_KEY_ACTIVATION_DELAY, padrão 2min, acima do intervalo de recarga do diretório).
+func ActivationDelay() time.Duration {
+	return env.Duration("JWT_KEY_ACTIVATION_DELAY", 2*time.Minute)
+}
+
+// Formato da data de criação no início do kid das chaves geradas por Generate.
+const kidTimeLayout = "20060102T150405Z"
+
+// keyCreatedAt extrai a data de criação do kid ("<data>-<sufixo>"). A data fica no nome do
+// arquivo, e não na data de modificação, para sobreviver a cópias e restaurações do diretório.
+// Chaves com outro nome são tratadas como criadas há muito tempo, já ativas.
+func keyCreatedAt(kid string) time.Time {
+	prefix, _, _ := strings.Cut(kid, "-")
+	created, err := time.Parse(kidTimeLayout, prefix)
+	if err != nil {
+		return time.Time{}
+	}
+	return created
+}
+
+// LoadDir lê todas as chaves *.pem do diretório, da mais antiga para a mais nova. O nome do
+// arquivo, sem extensão, é o kid.
+func LoadDir(dir string) ([]*Key, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	var loaded []*Key
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key %s: %w", path, err)
+		}
+
+		key, err := ParsePEM(strings.TrimSuffix(filepath.Base(path), ".pem"), data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key %s: %w", path, err)
+		}
+		key.CreatedAt = keyCreatedAt(key.ID)
+		loaded = append(loaded, key)
+	}
+
+	sort.Slice(loaded, func(i, j int) bool {
+		if !loaded[i].CreatedAt.Equal(loaded[j].CreatedAt) {
+			return loaded[i].CreatedAt.Before(loaded[j].CreatedAt)
+		}
+		return loaded[i].ID < loaded[j].ID
+	})
+	return loaded, nil
+}
+
+// ParsePEM interpreta uma chave RSA ou Ed25519, privada ou pública, em formato PEM.
+func ParsePEM(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newKey(kid, parsed)
+}
+
+func newKey(kid string, parsed interface{}) (*Key, error) {
+	key := &Key{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm, key.Public, key.Private = AlgRS256, &k.PublicKey, k
+	case *rsa.PublicKey:
+		key.Algorithm, key.Public = AlgRS256, k
+	case ed25519.PrivateKey:
+		key.Algorithm, key.Public, key.Private = AlgEdDSA, k.Public(), k
+	case ed25519.PublicKey:
+		key.Algorithm, key.Public = AlgEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
+
+// SigningKey retorna a chave privada local mais recente já ativa, usada para assinar novos
+// tokens. Se nenhuma passou do tempo de ativação (ex.: primeira chave), usa a mais antiga.
+func (s *KeySet) SigningKey() (*Key, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	var newest, oldest *Key
+	for _, key := range s.local {
+		if key.Private == nil {
+			continue
+		}
+		if oldest == nil || key.CreatedAt.Before(oldest.CreatedAt) {
+			oldest = key
+		}
+		if now.Sub(key.CreatedAt) >= s.activationDelay && (newest == nil || key.CreatedAt.After(newest.CreatedAt)) {
+			newest = key
+		}
+	}
+	if newest == nil {
+		newest = oldest
+	}
+	return newest, newest != nil
+}
+
+// Sign assina as reivindicações com a chave assimétrica mais recente ou, sem chaves locais,
+// com o segredo HS256 legado.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if key, ok := s.SigningKey(); ok {
+		token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+		token.Header["kid"] = key.ID
+		return token.SignedString(key.Private)
+	}
+
+	if len(s.hmacSecret) == 0 {
+		return "", errors.New("no signing key configured: set JWT_KEYS_DIR or SESSION_SECRET")
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.hmacSecret)
+}
+
+// Keyfunc escolhe a chave de verificação pelo kid do token, exigindo que o algoritmo do
+// cabeçalho seja o mesmo da chave.
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(s.hmacSecret) == 0 {
+			return nil, errors.New("HMAC tokens are not accepted")
+		}
+		return s.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no kid")
+	}
+
+	key, ok := s.lookup(kid)
+	if !ok && s.keysDir != "" {
+		// Chave desconhecida: outra instância pode ter rotacionado as chaves do diretório
+		if err := s.reloadDirThrottled(); err != nil {
+			slog.Error("Error reloading keys", "dir", s.keysDir, "error", err)
+		}
+		key, ok = s.lookup(kid)
+	}
+	if !ok && s.jwksURL != "" {
+		// Chave desconhecida: o emissor pode ter rotacionado as chaves
+		if err := s.refreshJWKSThrottled(); err != nil {
+			slog.Error("Error refreshing JWKS", "url", s.jwksURL, "error", err)
+		}
+		key, ok = s.lookup(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %s for kid %q", token.Method.Alg(), kid)
+	}
+	return key.Public, nil
+}
+
+func (s *KeySet) lookup(kid string) (*Key, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if key, ok := s.local[kid]; ok {
+		return key, true
+	}
+	key, ok := s.remote[kid]
+	return key, ok
+}
+
+// PublicKeys retorna as chaves locais, em ordem de criação, para publicação no JWKS.
+func (s *KeySet) PublicKeys() []*Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(s.local))
+	for _, key := range s.local {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys
+}
@@ -0,0 +1,226 @@
+package keys
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func newTestKey(t *testing.T) (*Key, []byte) {
+	t.Helper()
+	key, pemData, err := Generate(AlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, pemData
+}
+
+func signWith(t *testing.T, key *Key) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), jwt.MapClaims{"id": 1, "exp": time.Now().Add(time.Minute).Unix()})
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// writeKey grava a chave no diretório com a data de criação informada, que vai no kid. Se a
+// chave já estava no diretório com outra data, o arquivo anterior é removido.
+func writeKey(t *testing.T, dir string, key *Key, pemData []byte, createdAt time.Time) {
+	t.Helper()
+	os.Remove(filepath.Join(dir, key.ID+".pem"))
+	_, suffix, _ := strings.Cut(key.ID, "-")
+	key.ID = createdAt.UTC().Format(kidTimeLayout) + "-" + suffix
+	key.CreatedAt = keyCreatedAt(key.ID)
+	if err := os.WriteFile(filepath.Join(dir, key.ID+".pem"), pemData, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Um token com kid desconhecido busca o JWKS de novo, respeitando o intervalo mínimo.
+func TestKeyfuncRefreshesJWKS(t *testing.T) {
+	first, _ := newTestKey(t)
+	second, _ := newTestKey(t)
+
+	var published atomic.Pointer[JWKS]
+	var fetches atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		json.NewEncoder(w).Encode(published.Load())
+	}))
+	defer server.Close()
+
+	publish := func(keys ...*Key) {
+		jwks := JWKS{}
+		for _, key := range keys {
+			jwk, err := key.JWK()
+			if err != nil {
+				t.Fatal(err)
+			}
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+		// Chaves que não são de assinatura são ignoradas
+		jwks.Keys = append(jwks.Keys, JWK{KeyType: "OKP", KeyID: "enc", Use: "enc", Curve: "Ed25519"})
+		published.Store(&jwks)
+	}
+
+	publish(first)
+	set := &KeySet{local: map[string]*Key{}, remote: map[string]*Key{}, jwksURL: server.URL}
+	if err := set.RefreshJWKS(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := set.lookup("enc"); ok {
+		t.Fatal("encryption key was loaded from the JWKS")
+	}
+	if _, err := jwt.Parse(signWith(t, first), set.Keyfunc); err != nil {
+		t.Fatalf("token signed with a published key: %v", err)
+	}
+
+	// O emissor rotaciona as chaves logo depois da última busca: ainda dentro do intervalo mínimo
+	publish(first, second)
+	if _, err := jwt.Parse(signWith(t, second), set.Keyfunc); err == nil {
+		t.Fatal("JWKS was refetched before the minimum refresh interval")
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", got)
+	}
+
+	set.mu.Lock()
+	set.lastJWKSFetch = time.Now().Add(-jwksMinRefreshInterval)
+	set.mu.Unlock()
+	if _, err := jwt.Parse(signWith(t, second), set.Keyfunc); err != nil {
+		t.Fatalf("token signed with a rotated key: %v", err)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", got)
+	}
+}
+
+// Uma chave gravada no diretório por outra instância é carregada quando chega um token dela.
+func TestKeyfuncReloadsDir(t *testing.T) {
+	dir := t.TempDir()
+	first, firstPEM := newTestKey(t)
+	writeKey(t, dir, first, firstPEM, time.Now().Add(-time.Hour))
+
+	set := &KeySet{remote: map[string]*Key{}, keysDir: dir, activationDelay: time.Minute}
+	if err := set.ReloadDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	second, secondPEM := newTestKey(t)
+	writeKey(t, dir, second, secondPEM, time.Now())
+	set.mu.Lock()
+	set.lastDirLoad = time.Now().Add(-dirMinReloadInterval)
+	set.mu.Unlock()
+
+	if _, err := jwt.Parse(signWith(t, second), set.Keyfunc); err != nil {
+		t.Fatalf("token signed with a new key: %v", err)
+	}
+}
+
+// Uma chave nova só verifica tokens até passar o tempo de ativação; a anterior segue assinando.
+func TestSigningKeyActivation(t *testing.T) {
+	dir := t.TempDir()
+	old, oldPEM := newTestKey(t)
+	writeKey(t, dir, old, oldPEM, time.Now().Add(-time.Hour))
+	set := &KeySet{remote: map[string]*Key{}, keysDir: dir, activationDelay: 2 * time.Minute}
+	if err := set.ReloadDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh, freshPEM := newTestKey(t)
+	writeKey(t, dir, fresh, freshPEM, time.Now().Add(-time.Minute))
+	if err := set.ReloadDir(); err != nil {
+		t.Fatal(err)
+	}
+	if key, _ := set.SigningKey(); key.ID != old.ID {
+		t.Fatalf("SigningKey() = %s before activation, want %s", key.ID, old.ID)
+	}
+	if _, err := jwt.Parse(signWith(t, fresh), set.Keyfunc); err != nil {
+		t.Fatalf("token signed with a verify-only key: %v", err)
+	}
+
+	writeKey(t, dir, fresh, freshPEM, time.Now().Add(-3*time.Minute))
+	if err := set.ReloadDir(); err != nil {
+		t.Fatal(err)
+	}
+	if key, _ := set.SigningKey(); key.ID != fresh.ID {
+		t.Fatalf("SigningKey() = %s after activation, want %s", key.ID, fresh.ID)
+	}
+
+	// Sem nenhuma chave ativa, a única disponível assina
+	only := &KeySet{local: map[string]*Key{fresh.ID: fresh}, activationDelay: time.Hour}
+	if key, ok := only.SigningKey(); !ok || key.ID != fresh.ID {
+		t.Fatalf("SigningKey() with a single new key = %v, %v", key, ok)
+	}
+}
+
+// A ativação segue a data do kid: copiar ou restaurar o diretório, o que muda a data de
+// modificação dos arquivos, não volta a chave para o período só de verificação.
+func TestSigningKeyActivationIgnoresModTime(t *testing.T) {
+	dir := t.TempDir()
+	old, oldPEM := newTestKey(t)
+	writeKey(t, dir, old, oldPEM, time.Now().Add(-time.Hour))
+	current, currentPEM := newTestKey(t)
+	writeKey(t, dir, current, currentPEM, time.Now().Add(-10*time.Minute))
+
+	// Arquivos copiados agora, com a mais antiga por último
+	for _, key := range []*Key{current, old} {
+		path := filepath.Join(dir, key.ID+".pem")
+		if err := os.Chtimes(path, time.Now(), time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	set := &KeySet{remote: map[string]*Key{}, keysDir: dir, activationDelay: 2 * time.Minute}
+	if err := set.ReloadDir(); err != nil {
+		t.Fatal(err)
+	}
+	if key, _ := set.SigningKey(); key.ID != current.ID {
+		t.Fatalf("SigningKey() = %s after copying the directory, want %s", key.ID, current.ID)
+	}
+
+	// Uma chave com outro nome é tratada como já ativa
+	if created := keyCreatedAt("manual"); !created.IsZero() {
+		t.Fatalf("keyCreatedAt(manual) = %v, want zero", created)
+	}
+}
+
+// Rotate só remove chaves substituídas há mais do que a retenção.
+func TestRotateRetention(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	var created []*Key
+	for _, age := range []time.Duration{5 * time.Hour, 4 * time.Hour, 10 * time.Minute} {
+		key, pemData := newTestKey(t)
+		writeKey(t, dir, key, pemData, now.Add(-age))
+		created = append(created, key)
+	}
+
+	// A segunda chave substituiu a primeira há 4h; a terceira substituiu a segunda há 10min
+	_, removed, err := Rotate(dir, AlgEdDSA, 2, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != created[0].ID {
+		t.Fatalf("removed = %v, want [%s]", removed, created[0].ID)
+	}
+
+	loaded, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 3 {
+		t.Fatalf("%d keys left, want 3 (the second key still verifies recent tokens)", len(loaded))
+	}
+}
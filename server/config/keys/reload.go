@@ -0,0 +1,50 @@
+package keys
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Intervalo mínimo entre recargas do diretório disparadas por kids desconhecidos.
+const dirMinReloadInterval = 10 * time.Second
+
+// ReloadDir relê o diretório de chaves e substitui as chaves locais. Chaves novas entram
+// primeiro só para verificação (ver SigningKey); as removidas do diretório deixam de valer.
+func (s *KeySet) ReloadDir() error {
+	loaded, err := LoadDir(s.keysDir)
+	if err != nil {
+		return err
+	}
+
+	local := make(map[string]*Key, len(loaded))
+	for _, key := range loaded {
+		local[key.ID] = key
+	}
+
+	s.mu.Lock()
+	s.local = local
+	s.lastDirLoad = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *KeySet) reloadDirThrottled() error {
+	s.mu.RLock()
+	recent := time.Since(s.lastDirLoad) < dirMinReloadInterval
+	s.mu.RUnlock()
+	if recent {
+		return nil
+	}
+	return s.ReloadDir()
+}
+
+func (s *KeySet) reloadDirLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.ReloadDir(); err != nil {
+			slog.Error("Error reloading keys", "dir", s.keysDir, "error", err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package keys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Generate cria uma nova chave privada com o algoritmo informado (RS256 ou EdDSA).
+// O kid começa com a data de criação, que define quando a chave passa a assinar (ver keyCreatedAt).
+func Generate(algorithm string) (*Key, []byte, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, nil, err
+	}
+	kid := time.Now().UTC().Format(kidTimeLayout) + "-" + hex.EncodeToString(suffix)
+
+	var private interface{}
+	switch algorithm {
+	case AlgEdDSA:
+		_, k, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		private = k
+	case AlgRS256:
+		k, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, nil, err
+		}
+		private = k
+	default:
+		return nil, nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := newKey(kid, private)
+	if err != nil {
+		return nil, nil, err
+	}
+	key.CreatedAt = keyCreatedAt(kid)
+
+	return key, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// Rotate grava uma nova chave no diretório, que passa a assinar depois do tempo de ativação,
+// e remove as chaves privadas mais antigas além das keep mais recentes. Uma chave só é
+// removida depois de ter sido substituída há mais de retention (tempo de ativação mais a
+// validade dos tokens de acesso), para que os tokens que ela assinou já tenham expirado.
+func Rotate(dir, algorithm string, keep int, retention time.Duration) (*Key, []string, error) {
+	if keep < 2 {
+		keep = 2
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, nil, fmt.Errorf("failed to create keys directory: %w", err)
+	}
+
+	key, pemData, err := Generate(algorithm)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, key.ID+".pem"), pemData, 0o600); err != nil {
+		return nil, nil, fmt.Errorf("failed to write key: %w", err)
+	}
+
+	existing, err := LoadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var private []*Key
+	for _, k := range existing {
+		if k.Private != nil {
+			private = append(private, k)
+		}
+	}
+
+	var removed []string
+	for i := 0; i < len(private)-keep; i++ {
+		// A chave seguinte é a que a substituiu na assinatura
+		if time.Since(private[i+1].CreatedAt) < retention {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, private[i].ID+".pem")); err != nil {
+			return nil, nil, fmt.Errorf("failed to remove key %s: %w", private[i].ID, err)
+		}
+		removed = append(removed, private[i].ID)
+	}
+
+	return key, removed, nil
+}
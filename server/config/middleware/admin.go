@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"log/slog"
+	"messenger-pigeon-app/config/env"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware restringe a rota aos usuários listados em ADMIN_USER_IDS (ids separados por
+// vírgula). Deve vir depois do AuthMiddleware. Sem a variável, nenhum usuário é administrador.
+func AdminMiddleware() gin.HandlerFunc {
+	admins := make(map[int]bool)
+	for _, item := range env.List("ADMIN_USER_IDS", nil) {
+		id, convErr := strconv.Atoi(item)
+		if convErr != nil || id <= 0 {
+			slog.Warn("Ignoring invalid id in ADMIN_USER_IDS", "value", item)
+			continue
+		}
+		admins[id] = true
+	}
+
+	return func(c *gin.Context) {
+		if !admins[c.GetInt("id")] {
+			c.AbortWithStatusJSON(http.StatusForbidden, i18n.Error(c, err.CodeForbidden, "error.admin_required"))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminStatus envia uma requisição do usuário pela rota protegida pelo AdminMiddleware.
+func adminStatus(t *testing.T, userID int) int {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin", func(c *gin.Context) { c.Set("id", userID) }, AdminMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	return rec.Code
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	cases := []struct {
+		name   string
+		admins string
+		userID int
+		status int
+	}{
+		{"listed user", "1, 2", 2, http.StatusNoContent},
+		{"user not in the list", "1,2", 3, http.StatusForbidden},
+		{"empty ADMIN_USER_IDS", "", 1, http.StatusForbidden},
+		{"invalid ids ignored", "abc,-1", 1, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("ADMIN_USER_IDS", tc.admins)
+			if status := adminStatus(t, tc.userID); status != tc.status {
+				t.Fatalf("status = %d, want %d", status, tc.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"messenger-pigeon-app/config/env"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Data em que as rotas sem versão passaram a ser obsoletas, em favor de /v1.
+var legacyDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// Deprecated marca uma rota legada como obsoleta: envia o cabeçalho Deprecation (RFC 9745) e o
+// Link para a rota que a substitui. Os parâmetros do sucessor (ex.: ":id") são preenchidos, na
+// ordem, com os da requisição. Com LEGACY_API_SUNSET (data RFC 3339), envia também o Sunset.
+func Deprecated(successor string) gin.HandlerFunc {
+	var sunset string
+	if value := env.String("LEGACY_API_SUNSET", ""); value != "" {
+		at, parseErr := time.Parse(time.RFC3339, value)
+		if parseErr != nil {
+			slog.Warn("Invalid LEGACY_API_SUNSET", "error", parseErr)
+		} else {
+			sunset = at.UTC().Format(http.TimeFormat)
+		}
+	}
+
+	return func(c *gin.Context) {
+		segments := strings.Split(successor, "/")
+		next := 0
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") && next < len(c.Params) {
+				segments[i] = c.Params[next].Value
+				next++
+			}
+		}
+		link := strings.Join(segments, "/")
+
+		c.Header("Deprecation", fmt.Sprintf("@%d", legacyDeprecatedAt.Unix()))
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", link))
+		if sunset != "" {
+			c.Header("Sunset", sunset)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"messenger-pigeon-app/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale negocia o idioma da requisição pelo cabeçalho Accept-Language. Em rotas autenticadas,
+// a preferência salva pelo usuário (claim "locale" do token) tem prioridade.
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		c.Set(i18n.ContextKey, locale)
+		c.Header("Content-Language", locale)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
+
+// setUserLocale aplica o idioma preferido do usuário autenticado, se houver um válido.
+func setUserLocale(c *gin.Context, preferred string) {
+	if locale, ok := i18n.Match(preferred); ok {
+		c.Set(i18n.ContextKey, locale)
+		c.Header("Content-Language", locale)
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"log/slog"
+	"messenger-pigeon-app/pkg/logging"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cabeçalho com o id de correlação da requisição, aceito do cliente ou do proxy e devolvido na resposta.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID garante um id de correlação para a requisição, guardado no contexto da requisição
+// para que os logs feitos com ele o incluam. Ids recebidos inválidos são substituídos.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !logging.ValidID(id) {
+			id = logging.NewID()
+		}
+
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// Rotas chamadas a cada poucos segundos pelo orquestrador, que encheriam o log.
+var probeRoutes = map[string]bool{"/healthz": true, "/readyz": true}
+
+// Logger registra cada requisição ao terminar. Só o caminho é registrado, sem a query string,
+// que pode trazer credenciais (ex.: o ?ticket= do WebSocket). Erros do servidor são registrados
+// como error e os do cliente como warn; as sondas do orquestrador bem-sucedidas, como debug.
+func Logger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case probeRoutes[c.FullPath()]:
+			level = slog.LevelDebug
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if userID := c.GetInt("id"); userID > 0 {
+			attrs = append(attrs, slog.Int("user_id", userID))
+		}
+		slog.LogAttrs(c.Request.Context(), level, "HTTP request", attrs...)
+	}
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"messenger-pigeon-app/pkg/logging"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// logBuffer guarda os logs em memória; a conexão WebSocket registra da própria goroutine.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// record retorna o primeiro registro com a mensagem informada, ou nil.
+func (b *logBuffer) record(t *testing.T, msg string) map[string]interface{} {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		if record["msg"] == msg {
+			return record
+		}
+	}
+	return nil
+}
+
+// Os logs da requisição e da conexão WebSocket aberta por ela levam o mesmo request_id, e os
+// da conexão, o conn_id.
+func TestLogsCarryRequestAndConnectionIDs(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("LOG_FORMAT", "json")
+	logs := &logBuffer{}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(logging.NewHandler(logs)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	gin.SetMode(gin.TestMode)
+	websockets.Initialize()
+	router := gin.New()
+	router.Use(RequestID(), Logger())
+	router.GET("/ws", func(c *gin.Context) {
+		conn, err := websockets.Upgrade(c.Writer, c.Request)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		client := websockets.NewClient(c.Request.Context(), conn, 7, "s1", "en")
+		client.ReadMessages(nil)
+	})
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	const requestID = "req-logging-test"
+	header := http.Header{RequestIDHeader: {requestID}}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	conn.Close()
+
+	// O log da requisição só é gravado quando o handler termina, com a conexão fechada
+	var request map[string]interface{}
+	for deadline := time.Now().Add(2 * time.Second); request == nil; request = logs.record(t, "HTTP request") {
+		if time.Now().After(deadline) {
+			t.Fatal("request was not logged")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if request["request_id"] != requestID {
+		t.Errorf("request log request_id = %v, want %s", request["request_id"], requestID)
+	}
+
+	opened := logs.record(t, "Connection opened")
+	if opened == nil {
+		t.Fatal("connection was not logged")
+	}
+	if opened["request_id"] != requestID {
+		t.Errorf("connection log request_id = %v, want %s", opened["request_id"], requestID)
+	}
+	if id, _ := opened["conn_id"].(string); id == "" {
+		t.Errorf("connection log has no conn_id: %v", opened)
+	}
+	if _, ok := opened["connection_id"]; ok {
+		t.Error("connection log still uses connection_id")
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"messenger-pigeon-app/pkg/metrics"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rotas de longa duração (SSE e long-poll), cuja latência é a espera pelos eventos e
+// distorceria o histograma; como no trace, ficam de fora.
+var longLivedRoutes = map[string]bool{
+	"/v1/events":      true,
+	"/v1/events/poll": true,
+}
+
+// Metrics registra a latência e o status de cada requisição pelo padrão da rota. Conexões
+// WebSocket, streams SSE e long-polls ficam de fora, pois duram a conexão ou a espera toda;
+// elas são contadas pelos próprios controllers.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.IsWebsocket() || longLivedRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+		metrics.ObserveRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
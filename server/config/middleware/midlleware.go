@@ -1,10 +1,15 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"messenger-pigeon-app/config/keys"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/services"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 
@@ -12,90 +17,160 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+var (
+	errInvalidToken   = errors.New("Invalid token")
+	errInvalidClaims  = errors.New("Invalid token claims")
+	errInvalidUserID  = errors.New("Invalid user ID")
+	errSessionRevoked = errors.New("Session expired or revoked")
+)
+
+// TokenClaims são as reivindicações do token de acesso usadas pela aplicação.
+type TokenClaims struct {
+	UserID    int
+	SessionID string
+	Locale    string // Idioma preferido do usuário, vazio se ele não escolheu
+}
+
 // AuthMiddleware é um middleware para verificar se o token JWT é válido e relacionado a um usuário autenticado.
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var tokenString string
-
-		// Verifica se o token estar no cabeçalho Authorization
-		authHeader := c.GetHeader("Authorization")
-		if authHeader != "" {
-			// O token deve estar no formato "Bearer {token}"
-			tokenParts := strings.Split(authHeader, " ")
-			if len(tokenParts) == 2 && tokenParts[0] == "Bearer" {
-				tokenString = tokenParts[1]
-			}
-		}
-
-		// Se o token não foi encontrado no cabeçalho, tente obter do cookie
-		if tokenString == "" {
-			cookieToken, err := c.Cookie("token")
-			if err == nil {
-				tokenString = cookieToken
-			}
-		}
+		tokenString := tokenFromRequest(c)
 
 		// Se nenhum token foi encontrado, retorne erro
 		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token não fornecido"})
-			c.Abort()
+			c.AbortWithStatusJSON(http.StatusUnauthorized, i18n.Error(c, err.CodeTokenMissing, "error.token_missing"))
 			return
 		}
 
-		// Parse e verifique o token JWT
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Verifique o método de assinatura
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("Token inválido")
-			}
-			return []byte(os.Getenv("SESSION_SECRET")), nil
-		})
-		if err != nil {
-			log.Println("Erro ao analisar o token JWT:", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token inválido"})
-			c.Abort()
+		claims, authErr := Authenticate(c.Request.Context(), tokenString)
+		if authErr != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, authError(c, authErr))
 			return
 		}
 
-		// Verifique se o token é válido
-		if !token.Valid {
-			log.Println("Token Inválido")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token inválido"})
-			c.Abort()
-			return
-		}
+		// Definir o ID do usuário e da sessão no contexto da requisição
+		c.Set("id", claims.UserID)
+		c.Set("sid", claims.SessionID)
+		setUserLocale(c, claims.Locale)
 
-		// Obtenha o ID do usuário das reivindicações do token
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			log.Println("Reivindicação inválida")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Reivindicações inválidas"})
-			c.Abort()
-			return
-		}
+		// Continuar com a solicitação
+		c.Next()
+	}
+}
 
-		// Extrair o ID do usuário como um valor genérico
-		userID, ok := claims["id"]
-		if !ok {
-			log.Println("Erro ao converter ID do usuário para int")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
-			c.Abort()
-			return
-		}
+// authError converte o erro da autenticação no envelope de erro da API.
+func authError(c *gin.Context, authErr error) err.ErrorResponse {
+	code, key := AuthErrorCode(authErr)
+	return i18n.Error(c, code, key)
+}
 
-		// Converter o userID para int
-		idInt, err := strconv.Atoi(fmt.Sprintf("%v", userID))
-		if err != nil {
-			log.Println("Erro ao converter ID do usuário para int:", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
-			c.Abort()
-			return
+// AuthErrorCode retorna o código de erro e a chave da mensagem para um erro de Authenticate.
+func AuthErrorCode(authErr error) (code, key string) {
+	switch {
+	case errors.Is(authErr, errSessionRevoked):
+		return err.CodeSessionRevoked, "error.session_revoked"
+	case errors.Is(authErr, errInvalidClaims):
+		return err.CodeInvalidToken, "error.invalid_token_claims"
+	case errors.Is(authErr, errInvalidUserID):
+		return err.CodeInvalidToken, "error.invalid_user_id"
+	default:
+		return err.CodeInvalidToken, "error.invalid_token"
+	}
+}
+
+// tokenFromRequest obtém o token do cabeçalho Authorization ou, na falta dele, do cookie "token".
+func tokenFromRequest(c *gin.Context) string {
+	// Verifica se o token estar no cabeçalho Authorization
+	authHeader := c.GetHeader("Authorization")
+	if authHeader != "" {
+		// O token deve estar no formato "Bearer {token}"
+		tokenParts := strings.Split(authHeader, " ")
+		if len(tokenParts) == 2 && tokenParts[0] == "Bearer" {
+			return tokenParts[1]
 		}
+	}
 
-		// Definir o ID do usuário no contexto da requisição
-		c.Set("id", idInt)
+	// Se o token não foi encontrado no cabeçalho, tente obter do cookie
+	cookieToken, cookieErr := c.Cookie("token")
+	if cookieErr == nil {
+		return cookieToken
+	}
+	return ""
+}
 
-		// Continuar com a solicitação
-		c.Next()
+// Authenticate verifica o token e se a sessão a que ele pertence ainda está ativa.
+func Authenticate(ctx context.Context, tokenString string) (TokenClaims, error) {
+	claims, parseErr := ParseUserToken(tokenString)
+	if parseErr != nil {
+		return TokenClaims{}, parseErr
+	}
+
+	active, sessionErr := services.IsSessionActive(ctx, claims.SessionID, claims.UserID)
+	if sessionErr != nil {
+		slog.ErrorContext(ctx, "Error checking session", "user_id", claims.UserID, "error", sessionErr)
+		return TokenClaims{}, errSessionRevoked
+	}
+	if !active {
+		return TokenClaims{}, errSessionRevoked
+	}
+	return claims, nil
+}
+
+// ParseUserToken verifica o token JWT, que precisa ter expiração e sessão, e retorna suas reivindicações.
+func ParseUserToken(tokenString string) (TokenClaims, error) {
+	// Parse e verifique o token JWT
+	// A chave é escolhida pelo algoritmo e pelo kid do token (HS256 legado, RS256 ou EdDSA)
+	token, parseErr := jwt.Parse(tokenString, keys.Default().Keyfunc)
+	if parseErr != nil {
+		slog.Debug("Error parsing JWT", "error", parseErr)
+		return TokenClaims{}, errInvalidToken
+	}
+
+	// Verifique se o token é válido
+	if !token.Valid {
+		slog.Debug("Invalid JWT")
+		return TokenClaims{}, errInvalidToken
+	}
+
+	// Obtenha o ID do usuário das reivindicações do token
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		slog.Debug("Invalid JWT claims")
+		return TokenClaims{}, errInvalidClaims
+	}
+
+	// Tokens parciais (ex.: aguardando o segundo fator) não dão acesso à API
+	if typ, _ := claims["typ"].(string); typ != "" {
+		slog.Debug("JWT type not accepted", "type", typ)
+		return TokenClaims{}, errInvalidToken
+	}
+
+	// Tokens sem expiração ou sem sessão não podem ser revogados, então não são aceitos
+	if _, ok := claims["exp"].(float64); !ok {
+		slog.Debug("JWT without expiration")
+		return TokenClaims{}, errInvalidClaims
+	}
+	sessionID, ok := claims["sid"].(string)
+	if !ok || sessionID == "" {
+		slog.Debug("JWT without session")
+		return TokenClaims{}, errInvalidClaims
 	}
+
+	// Extrair o ID do usuário como um valor genérico
+	userID, ok := claims["id"]
+	if !ok {
+		slog.Debug("JWT without user ID")
+		return TokenClaims{}, errInvalidUserID
+	}
+
+	// Converter o userID para int
+	idInt, convErr := strconv.Atoi(fmt.Sprintf("%v", userID))
+	if convErr != nil {
+		slog.Debug("Invalid user ID in JWT", "error", convErr)
+		return TokenClaims{}, errInvalidUserID
+	}
+
+	locale, _ := claims["locale"].(string)
+
+	return TokenClaims{UserID: idInt, SessionID: sessionID, Locale: locale}, nil
 }
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/ratelimit"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit aplica os limites da classe de rota ao IP e, em rotas autenticadas, ao usuário.
+// Requisições acima do limite recebem 429 com o cabeçalho Retry-After.
+func RateLimit(class string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, retryAfter := ratelimit.Default().Allow(class, c.GetInt("id"), c.ClientIP())
+		if !allowed {
+			c.Header("Retry-After", ratelimit.RetryAfterSeconds(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, i18n.Error(c, err.CodeTooManyRequests, "error.too_many_requests"))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"log/slog"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Prefixo do subprotocolo usado para enviar o token de acesso no handshake do WebSocket.
+const TokenSubprotocolPrefix = "pigeon.token."
+
+// WebSocketAuthMiddleware autentica o handshake do WebSocket antes do upgrade. Aceita, em ordem,
+// um ticket de uso único (?ticket=), um token enviado como subprotocolo ("pigeon.token.<jwt>")
+// ou as mesmas credenciais do AuthMiddleware (cabeçalho Authorization ou cookie).
+func WebSocketAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ticket := c.Query("ticket"); ticket != "" {
+			userID, sessionID, ok := services.RedeemWebSocketTicket(c.Request.Context(), ticket)
+			if !ok {
+				slog.WarnContext(c.Request.Context(), "Invalid or expired websocket ticket")
+				c.AbortWithStatusJSON(http.StatusUnauthorized, i18n.Error(c, err.CodeInvalidToken, "error.invalid_ticket"))
+				return
+			}
+			c.Set("id", userID)
+			c.Set("sid", sessionID)
+			// O ticket não carrega o claim "locale" do token, então a preferência vem do banco
+			setUserLocale(c, services.PreferredLocale(c.Request.Context(), userID))
+			c.Next()
+			return
+		}
+
+		tokenString := tokenFromSubprotocols(c.Request)
+		if tokenString == "" {
+			tokenString = tokenFromRequest(c)
+		}
+		if tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, i18n.Error(c, err.CodeTokenMissing, "error.token_missing"))
+			return
+		}
+
+		claims, authErr := Authenticate(c.Request.Context(), tokenString)
+		if authErr != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, authError(c, authErr))
+			return
+		}
+
+		c.Set("id", claims.UserID)
+		c.Set("sid", claims.SessionID)
+		setUserLocale(c, claims.Locale)
+		c.Next()
+	}
+}
+
+func tokenFromSubprotocols(r *http.Request) string {
+	for _, protocol := range websocket.Subprotocols(r) {
+		if strings.HasPrefix(protocol, TokenSubprotocolPrefix) {
+			return strings.TrimPrefix(protocol, TokenSubprotocolPrefix)
+		}
+	}
+	return ""
+}
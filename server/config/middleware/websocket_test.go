@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"database/sql/driver"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/internal/testdb"
+	"messenger-pigeon-app/pkg/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// O handshake autenticado por ticket usa o idioma salvo pelo usuário, como o caminho do token.
+func TestWebSocketAuthMiddlewareTicketLocale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, store := testdb.Open()
+	database.SetDB(db)
+	store.Rows("FROM user_session", []string{"active"}, []driver.Value{true})
+	store.Rows("SELECT locale FROM user", []string{"locale"}, []driver.Value{"pt-BR"})
+
+	ticket, _, err := services.IssueWebSocketTicket(7, "session-7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var locale string
+	router := gin.New()
+	router.Use(Locale())
+	router.GET("/ws", WebSocketAuthMiddleware(), func(c *gin.Context) {
+		locale = i18n.FromContext(c)
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ws?ticket="+ticket, nil)
+	req.Header.Set("Accept-Language", "en")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if locale != "pt-BR" {
+		t.Fatalf("locale = %q, want pt-BR", locale)
+	}
+	if got := rec.Header().Get("Content-Language"); got != "pt-BR" {
+		t.Fatalf("Content-Language = %q, want pt-BR", got)
+	}
+}
@@ -1,5 +1,58 @@
 package err
 
+// Códigos de erro estáveis, para que os clientes não dependam do texto da mensagem.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeValidation           = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeTokenMissing         = "token_missing"
+	CodeInvalidToken         = "invalid_token"
+	CodeSessionRevoked       = "session_revoked"
+	CodeInvalidCredentials   = "invalid_credentials"
+	CodeInvalidRefreshToken  = "invalid_refresh_token"
+	CodeInvalidMFAToken      = "invalid_mfa_token"
+	CodeInvalidTwoFactorCode = "invalid_two_factor_code"
+	CodeInvalidAccountToken  = "invalid_account_token"
+	CodeForbidden            = "forbidden"
+	CodeOriginNotAllowed     = "origin_not_allowed"
+	CodeNotFound             = "not_found"
+	CodeUserNotFound         = "user_not_found"
+	CodeSessionNotFound      = "session_not_found"
+	CodeConflict             = "conflict"
+	CodeAlreadyExists        = "already_exists"
+	CodeTwoFactorEnabled     = "two_factor_already_enabled"
+	CodeTwoFactorNotEnabled  = "two_factor_not_enabled"
+	CodeTwoFactorNotPending  = "two_factor_not_pending"
+	CodeClientMessageReused  = "client_message_id_reused"
+	CodeTooManyRequests      = "too_many_requests"
+	CodeUnavailable          = "unavailable"
+	CodeInternal             = "internal_error"
+)
+
+// ErrorResponse é o envelope de erro de todos os endpoints:
+// {"error": {"code": "...", "message": "...", "fields": {"campo": "..."}}}
 type ErrorResponse struct {
-	Error map[string]string `json:"error"`
+	Error Error `json:"error"`
+}
+
+// Error descreve o erro: código estável, mensagem legível e, quando houver, os erros por campo.
+type Error struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// New cria o envelope de erro com código e mensagem.
+func New(code, message string) ErrorResponse {
+	return ErrorResponse{Error: Error{Code: code, Message: message}}
+}
+
+// Validation cria o envelope para erros de validação por campo.
+func Validation(fields map[string]string) ErrorResponse {
+	return WithFields(CodeValidation, "Invalid request", fields)
+}
+
+// WithFields cria o envelope com código, mensagem e erros por campo.
+func WithFields(code, message string, fields map[string]string) ErrorResponse {
+	return ErrorResponse{Error: Error{Code: code, Message: message, Fields: fields}}
 }
@@ -0,0 +1,127 @@
+package i18n
+
+import (
+	"fmt"
+	"log/slog"
+	"messenger-pigeon-app/config/env"
+	"messenger-pigeon-app/internal/err"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// Idiomas disponíveis no catálogo.
+const (
+	English    = "en"
+	Portuguese = "pt-BR"
+)
+
+// ContextKey é a chave do idioma da requisição no contexto do gin.
+const ContextKey = "locale"
+
+// Catálogo de mensagens por idioma, indexado por chaves estáveis (ex.: "error.invalid_token").
+var catalogue = map[string]map[string]string{
+	English:    messagesEN,
+	Portuguese: messagesPTBR,
+}
+
+// A ordem define a preferência em caso de empate; o primeiro é o idioma padrão do matcher.
+var (
+	supported = []language.Tag{language.English, language.BrazilianPortuguese}
+	matcher   = language.NewMatcher(supported)
+)
+
+var defaultLocale = English
+
+// Initialize define o idioma padrão a partir de DEFAULT_LOCALE (en ou pt-BR).
+func Initialize() {
+	locale, ok := Match(env.String("DEFAULT_LOCALE", English))
+	if !ok {
+		slog.Warn("Unsupported DEFAULT_LOCALE, using default", "value", env.String("DEFAULT_LOCALE", ""), "default", English)
+		locale = English
+	}
+	defaultLocale = locale
+}
+
+// Default retorna o idioma padrão.
+func Default() string {
+	return defaultLocale
+}
+
+// Supported retorna os idiomas disponíveis.
+func Supported() []string {
+	return []string{English, Portuguese}
+}
+
+// Match normaliza um idioma informado pelo usuário ("pt", "pt-br", "en-US"...) para um
+// dos idiomas do catálogo. Retorna false se nenhum corresponder.
+func Match(locale string) (string, bool) {
+	tag, parseErr := language.Parse(strings.TrimSpace(locale))
+	if parseErr != nil {
+		return "", false
+	}
+	_, index, confidence := matcher.Match(tag)
+	if confidence == language.No {
+		return "", false
+	}
+	return supported[index].String(), true
+}
+
+// Negotiate escolhe o idioma a partir do cabeçalho Accept-Language, respeitando os pesos (q).
+// Sem cabeçalho ou sem correspondência, retorna o idioma padrão.
+func Negotiate(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return defaultLocale
+	}
+	tags, _, parseErr := language.ParseAcceptLanguage(acceptLanguage)
+	if parseErr != nil || len(tags) == 0 {
+		return defaultLocale
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return defaultLocale
+	}
+	return supported[index].String()
+}
+
+// T traduz a chave para o idioma informado, formatando os argumentos. Chaves ausentes caem
+// no idioma padrão e, por último, na própria chave.
+func T(locale, key string, args ...interface{}) string {
+	message, ok := catalogue[locale][key]
+	if !ok {
+		message, ok = catalogue[defaultLocale][key]
+	}
+	if !ok {
+		slog.Warn("Missing translation", "key", key, "locale", locale)
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// FromContext retorna o idioma negociado para a requisição.
+func FromContext(c *gin.Context) string {
+	if locale := c.GetString(ContextKey); locale != "" {
+		return locale
+	}
+	return defaultLocale
+}
+
+// Message traduz a chave para o idioma da requisição.
+func Message(c *gin.Context, key string, args ...interface{}) string {
+	return T(FromContext(c), key, args...)
+}
+
+// Error cria o envelope de erro com a mensagem traduzida para o idioma da requisição.
+func Error(c *gin.Context, code, key string, args ...interface{}) err.ErrorResponse {
+	return err.New(code, Message(c, key, args...))
+}
+
+// ErrorWithFields cria o envelope de erro com a mensagem traduzida para o idioma da requisição
+// e os erros por campo, já traduzidos (ex.: com Message).
+func ErrorWithFields(c *gin.Context, code, key string, fields map[string]string) err.ErrorResponse {
+	return err.WithFields(code, Message(c, key), fields)
+}
@@ -0,0 +1,65 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+)
+
+// Os dois idiomas têm as mesmas chaves, com os mesmos argumentos de formatação.
+func TestCatalogueParity(t *testing.T) {
+	for key, en := range messagesEN {
+		pt, ok := messagesPTBR[key]
+		if !ok {
+			t.Errorf("key %q is missing in %s", key, Portuguese)
+			continue
+		}
+		if strings.Count(en, "%") != strings.Count(pt, "%") {
+			t.Errorf("key %q: %q and %q have different format verbs", key, en, pt)
+		}
+	}
+	for key := range messagesPTBR {
+		if _, ok := messagesEN[key]; !ok {
+			t.Errorf("key %q is missing in %s", key, English)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		defaultLocale  string
+		acceptLanguage string
+		want           string
+	}{
+		{"", "pt-BR,pt;q=0.9,en;q=0.8", Portuguese},
+		{"", "pt", Portuguese},
+		{"", "en-US,en;q=0.9", English},
+		{"", "en;q=0.5, pt-BR;q=0.8", Portuguese},
+		{"", "fr-FR, de;q=0.5", English},
+		{"", "", English},
+		{"", ";;;", English},
+		// Sem cabeçalho ou sem correspondência, vale DEFAULT_LOCALE
+		{"pt-BR", "", Portuguese},
+		{"pt-BR", "fr-FR", Portuguese},
+		{"pt-BR", "en", English},
+		// Um DEFAULT_LOCALE sem catálogo cai no inglês
+		{"fr", "", English},
+	}
+	t.Cleanup(func() { defaultLocale = English })
+	for _, tt := range tests {
+		t.Setenv("DEFAULT_LOCALE", tt.defaultLocale)
+		Initialize()
+		if got := Negotiate(tt.acceptLanguage); got != tt.want {
+			t.Errorf("DEFAULT_LOCALE=%q, Negotiate(%q) = %q, want %q", tt.defaultLocale, tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+// Uma chave sem tradução cai no idioma padrão e, por último, na própria chave.
+func TestTFallback(t *testing.T) {
+	if got := T("fr", "error.validation"); got != messagesEN["error.validation"] {
+		t.Errorf("T(fr) = %q, want the English message", got)
+	}
+	if got := T(Portuguese, "error.does_not_exist"); got != "error.does_not_exist" {
+		t.Errorf("T(missing key) = %q, want the key", got)
+	}
+}
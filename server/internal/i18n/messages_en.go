@@ -0,0 +1,95 @@
+package i18n
+
+var messagesEN = map[string]string{
+	// Erros da API
+	"error.validation":                 "Invalid request",
+	"error.invalid_body":               "Invalid request body",
+	"error.invalid_event":              "Invalid websocket event",
+	"error.unknown_event":              "Unknown event type: %s",
+	"error.stream_closed":              "Event stream closed; reconnect with the last event id",
+	"error.internal":                   "Internal server error",
+	"error.route_not_found":            "Route not found",
+	"error.too_many_requests":          "Too many requests",
+	"error.origin_not_allowed":         "Origin not allowed",
+	"error.admin_required":             "Administrator access required",
+	"error.token_missing":              "Token not provided",
+	"error.invalid_token":              "Invalid token",
+	"error.invalid_token_claims":       "Invalid token claims",
+	"error.invalid_ticket":             "Invalid or expired ticket",
+	"error.session_revoked":            "Session expired or revoked",
+	"error.user_not_in_session":        "User ID not found in session",
+	"error.invalid_user_id":            "Invalid user ID",
+	"error.invalid_credentials":        "Invalid username or password",
+	"error.invalid_password":           "Invalid password",
+	"error.invalid_refresh_token":      "Invalid refresh token",
+	"error.invalid_mfa_token":          "Invalid or expired mfa token",
+	"error.invalid_code":               "Invalid code",
+	"error.invalid_account_token":      "Invalid or expired token",
+	"error.already_exists":             "Already in use",
+	"error.user_not_found":             "User not found",
+	"error.session_not_found":          "Session not found",
+	"error.client_message_id_reused":   "clientMessageId was already used for a different message",
+	"error.two_factor_already_enabled": "Two-factor authentication is already enabled",
+	"error.two_factor_not_enabled":     "Two-factor authentication is not enabled",
+	"error.two_factor_not_pending":     "Two-factor setup has not been started",
+	"error.unsupported_locale":         "Unsupported language",
+	"error.signup_failed":              "Failed to create user",
+	"error.login_failed":               "Failed to log in",
+	"error.refresh_failed":             "Failed to refresh token",
+	"error.logout_failed":              "Failed to log out",
+	"error.user_lookup_failed":         "Failed to get user ID",
+	"error.username_lookup_failed":     "Failed to get user Username",
+	"error.messages_failed":            "Failed to retrieve messages",
+	"error.chats_failed":               "Failed to retrieve chats",
+	"error.chat_partner_failed":        "Failed to retrieve chat partner info",
+	"error.send_message_failed":        "Failed to send message",
+	"error.ticket_failed":              "Failed to issue ticket",
+	"error.list_sessions_failed":       "Failed to list sessions",
+	"error.revoke_session_failed":      "Failed to revoke session",
+	"error.revoke_sessions_failed":     "Failed to revoke sessions",
+	"error.two_factor_setup_failed":    "Failed to start two-factor setup",
+	"error.two_factor_enable_failed":   "Failed to enable two-factor authentication",
+	"error.two_factor_disable_failed":  "Failed to disable two-factor authentication",
+	"error.verify_email_failed":        "Failed to verify email",
+	"error.send_verification_failed":   "Failed to send verification email",
+	"error.forgot_password_failed":     "Failed to request password reset",
+	"error.reset_password_failed":      "Failed to reset password",
+	"error.update_locale_failed":       "Failed to update language",
+
+	// Validação por campo
+	"validation.required":                 "Values are missing!",
+	"validation.email":                    "Invalid email address",
+	"validation.integer":                  "Must be an integer",
+	"validation.min":                      "Must have at least %s characters",
+	"validation.max":                      "Must have at most %s characters",
+	"validation.eqfield":                  "Passwords do not match",
+	"validation.rule":                     "Failed on the '%s' rule",
+	"validation.username_taken":           "Username is already in use",
+	"validation.email_taken":              "Email is already in use",
+	"validation.idempotency_key_mismatch": "Does not match the Idempotency-Key header",
+
+	// Mensagens de sucesso
+	"message.user_created":        "User created successfully",
+	"message.logged_out":          "Logged out successfully",
+	"message.message_sent":        "Message sent successfully",
+	"message.session_revoked":     "Session revoked successfully",
+	"message.sessions_revoked":    "Sessions revoked successfully",
+	"message.two_factor_enabled":  "Two-factor authentication enabled",
+	"message.two_factor_disabled": "Two-factor authentication disabled",
+	"message.email_verified":      "Email verified successfully",
+	"message.verification_sent":   "Verification email sent",
+	"message.password_reset_sent": "If the email is registered, a reset link has been sent",
+	"message.password_reset":      "Password reset successfully",
+	"message.locale_updated":      "Language updated",
+
+	// Emails
+	"email.verify.subject": "Confirm your email address",
+	"email.verify.body": "Welcome to Messenger Pigeon!\n\n" +
+		"Confirm your email address by opening the link below:\n" +
+		"%s\n",
+	"email.reset.subject": "Reset your password",
+	"email.reset.body": "We received a request to reset your Messenger Pigeon password.\n\n" +
+		"Choose a new password by opening the link below:\n" +
+		"%s\n\n" +
+		"If you did not ask for this, you can ignore this email.\n",
+}
@@ -0,0 +1,95 @@
+package i18n
+
+var messagesPTBR = map[string]string{
+	// Erros da API
+	"error.validation":                 "Requisição inválida",
+	"error.invalid_body":               "Corpo da requisição inválido",
+	"error.invalid_event":              "Evento de websocket inválido",
+	"error.unknown_event":              "Tipo de evento desconhecido: %s",
+	"error.stream_closed":              "Stream de eventos encerrado; reconecte com o id do último evento",
+	"error.internal":                   "Erro interno do servidor",
+	"error.route_not_found":            "Rota não encontrada",
+	"error.too_many_requests":          "Muitas requisições",
+	"error.origin_not_allowed":         "Origem não permitida",
+	"error.admin_required":             "Acesso restrito a administradores",
+	"error.token_missing":              "Token não fornecido",
+	"error.invalid_token":              "Token inválido",
+	"error.invalid_token_claims":       "Reivindicações do token inválidas",
+	"error.invalid_ticket":             "Ticket inválido ou expirado",
+	"error.session_revoked":            "Sessão expirada ou revogada",
+	"error.user_not_in_session":        "ID do usuário não encontrado na sessão",
+	"error.invalid_user_id":            "ID do usuário inválido",
+	"error.invalid_credentials":        "Usuário ou senha inválidos",
+	"error.invalid_password":           "Senha inválida",
+	"error.invalid_refresh_token":      "Refresh token inválido",
+	"error.invalid_mfa_token":          "Token de verificação inválido ou expirado",
+	"error.invalid_code":               "Código inválido",
+	"error.invalid_account_token":      "Token inválido ou expirado",
+	"error.already_exists":             "Já está em uso",
+	"error.user_not_found":             "Usuário não encontrado",
+	"error.session_not_found":          "Sessão não encontrada",
+	"error.client_message_id_reused":   "O clientMessageId já foi usado em outra mensagem",
+	"error.two_factor_already_enabled": "A autenticação em dois fatores já está ativada",
+	"error.two_factor_not_enabled":     "A autenticação em dois fatores não está ativada",
+	"error.two_factor_not_pending":     "A configuração da autenticação em dois fatores não foi iniciada",
+	"error.unsupported_locale":         "Idioma não suportado",
+	"error.signup_failed":              "Falha ao criar o usuário",
+	"error.login_failed":               "Falha ao entrar",
+	"error.refresh_failed":             "Falha ao renovar o token",
+	"error.logout_failed":              "Falha ao sair",
+	"error.user_lookup_failed":         "Falha ao obter o ID do usuário",
+	"error.username_lookup_failed":     "Falha ao obter o nome de usuário",
+	"error.messages_failed":            "Falha ao obter as mensagens",
+	"error.chats_failed":               "Falha ao obter as conversas",
+	"error.chat_partner_failed":        "Falha ao obter as informações do contato",
+	"error.send_message_failed":        "Falha ao enviar a mensagem",
+	"error.ticket_failed":              "Falha ao emitir o ticket",
+	"error.list_sessions_failed":       "Falha ao listar as sessões",
+	"error.revoke_session_failed":      "Falha ao revogar a sessão",
+	"error.revoke_sessions_failed":     "Falha ao revogar as sessões",
+	"error.two_factor_setup_failed":    "Falha ao iniciar a configuração da autenticação em dois fatores",
+	"error.two_factor_enable_failed":   "Falha ao ativar a autenticação em dois fatores",
+	"error.two_factor_disable_failed":  "Falha ao desativar a autenticação em dois fatores",
+	"error.verify_email_failed":        "Falha ao verificar o email",
+	"error.send_verification_failed":   "Falha ao enviar o email de verificação",
+	"error.forgot_password_failed":     "Falha ao solicitar a redefinição de senha",
+	"error.reset_password_failed":      "Falha ao redefinir a senha",
+	"error.update_locale_failed":       "Falha ao atualizar o idioma",
+
+	// Validação por campo
+	"validation.required":                 "Valores ausentes!",
+	"validation.email":                    "Endereço de email inválido",
+	"validation.integer":                  "Deve ser um número inteiro",
+	"validation.min":                      "Deve ter pelo menos %s caracteres",
+	"validation.max":                      "Deve ter no máximo %s caracteres",
+	"validation.eqfield":                  "As senhas não coincidem",
+	"validation.rule":                     "Falhou na regra '%s'",
+	"validation.username_taken":           "Nome de usuário já está em uso",
+	"validation.email_taken":              "Email já está em uso",
+	"validation.idempotency_key_mismatch": "Não corresponde ao cabeçalho Idempotency-Key",
+
+	// Mensagens de sucesso
+	"message.user_created":        "Usuário criado com sucesso",
+	"message.logged_out":          "Sessão encerrada com sucesso",
+	"message.message_sent":        "Mensagem enviada com sucesso",
+	"message.session_revoked":     "Sessão revogada com sucesso",
+	"message.sessions_revoked":    "Sessões revogadas com sucesso",
+	"message.two_factor_enabled":  "Autenticação em dois fatores ativada",
+	"message.two_factor_disabled": "Autenticação em dois fatores desativada",
+	"message.email_verified":      "Email verificado com sucesso",
+	"message.verification_sent":   "Email de verificação enviado",
+	"message.password_reset_sent": "Se o email estiver cadastrado, um link de redefinição foi enviado",
+	"message.password_reset":      "Senha redefinida com sucesso",
+	"message.locale_updated":      "Idioma atualizado",
+
+	// Emails
+	"email.verify.subject": "Confirme seu endereço de email",
+	"email.verify.body": "Boas-vindas ao Messenger Pigeon!\n\n" +
+		"Confirme seu endereço de email abrindo o link abaixo:\n" +
+		"%s\n",
+	"email.reset.subject": "Redefina sua senha",
+	"email.reset.body": "Recebemos um pedido para redefinir sua senha do Messenger Pigeon.\n\n" +
+		"Escolha uma nova senha abrindo o link abaixo:\n" +
+		"%s\n\n" +
+		"Se você não fez esse pedido, pode ignorar este email.\n",
+}
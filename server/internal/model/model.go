@@ -2,13 +2,77 @@ package model
 
 type User struct {
 	ID              int    `json:"id"`
-	Username        string `json:"username" binding:"required, min=4,max=32"`
-	Name            string `json:"name" binding:"required, min=1,max=70"`
+	Username        string `json:"username" form:"username" binding:"required,min=4,max=32"`
+	Name            string `json:"name" form:"name" binding:"required,min=1,max=70"`
 	Icon            []byte `json:"icon"`
-	Bio             string `json:"bio" binding:"required, max=70"`
-	Email           string `json:"email" binding:"required, email"`
-	Password        string `json:"password" binding:"required, min=8, max=16"`
-	ConfirmPassword string `json:"cpassword" binding:"required"`
+	Bio             string `json:"bio" form:"bio" binding:"required,max=70"`
+	Email           string `json:"email" form:"email" binding:"required,email"`
+	Password        string `json:"password" form:"password" binding:"required,min=8,max=16"`
+	ConfirmPassword string `json:"cpassword" form:"cpassword" binding:"required,eqfield=Password"`
+	Locale          string `json:"locale" form:"locale" binding:"max=16"` // Idioma preferido (opcional, padrão: o da requisição)
+}
+
+// LocalePreference é o corpo da troca do idioma preferido.
+type LocalePreference struct {
+	Locale string `json:"locale" form:"locale" binding:"required,max=16"`
+}
+
+type UserLogin struct {
+	Login    string `json:"login" form:"login" binding:"required"` // Username ou email
+	Password string `json:"password" form:"password" binding:"required"`
+	Device   string `json:"device" form:"device" binding:"max=255"` // Nome do dispositivo (opcional)
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" form:"refreshToken" binding:"required"`
+}
+
+type TwoFactorCode struct {
+	Code string `json:"code" form:"code" binding:"required"`
+}
+
+type TwoFactorLogin struct {
+	MFAToken     string `json:"mfaToken" form:"mfaToken" binding:"required"`
+	Code         string `json:"code" form:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recoveryCode" form:"recoveryCode"`
+	Device       string `json:"device" form:"device" binding:"max=255"`
+}
+
+type TwoFactorDisable struct {
+	Password     string `json:"password" form:"password" binding:"required"`
+	Code         string `json:"code" form:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recoveryCode" form:"recoveryCode"`
+}
+
+type AccountToken struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+type ForgotPassword struct {
+	Email string `json:"email" form:"email" binding:"required,email"`
+}
+
+type ResetPassword struct {
+	Token           string `json:"token" form:"token" binding:"required"`
+	Password        string `json:"password" form:"password" binding:"required,min=8,max=16"`
+	ConfirmPassword string `json:"cpassword" form:"cpassword" binding:"required,eqfield=Password"`
+}
+
+type Session struct {
+	ID         string `json:"id"`
+	UserID     int    `json:"-"`
+	Device     string `json:"device"`
+	IP         string `json:"ip"`
+	CreatedAt  string `json:"createdAt"`
+	LastUsedAt string `json:"lastUsedAt"`
+	ExpiresAt  string `json:"expiresAt"`
+	Current    bool   `json:"current"`
+}
+
+// NewMessage é o corpo do envio de mensagem (JSON ou formulário).
+type NewMessage struct {
+	Content         string `json:"content" form:"content" binding:"required"`
+	ClientMessageID string `json:"clientMessageId" form:"clientMessageId" binding:"max=64"` // Opcional, para reenvios idempotentes
 }
 
 type UserMessage struct {
@@ -24,4 +88,16 @@ type UserMessage struct {
 	MessageBy      int    `json:"message-by"`
 	MessageTo      int    `json:"message-to"`
 	CreatedAt      string `json:"hourminute"`
+	// Id gerado pelo cliente no envio, devolvido para conciliar a mensagem otimista
+	ClientMessageID string `json:"clientMessageId,omitempty"`
+}
+
+// ExportedMessage é uma mensagem na exportação de uma conversa pelo comando administrativo.
+type ExportedMessage struct {
+	MessageID       int64  `json:"messageId"`
+	From            string `json:"from"` // Username do remetente
+	To              string `json:"to"`   // Username do destinatário
+	Content         string `json:"content"`
+	CreatedAt       string `json:"createdAt"`
+	ClientMessageID string `json:"clientMessageId,omitempty"`
 }
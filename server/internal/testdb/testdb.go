@@ -0,0 +1,234 @@
+// Package testdb é um banco SQL em memória para os testes: cada consulta é respondida pelo
+// primeiro handler registrado cujo padrão ela contém, sem precisar de um MySQL.
+package testdb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Result é a resposta de um handler: as linhas de uma consulta ou o efeito de um comando.
+type Result struct {
+	Columns      []string
+	Rows         [][]driver.Value
+	RowsAffected int64
+	LastInsertID int64
+	Err          error
+}
+
+// Handler responde a uma consulta com os argumentos recebidos.
+type Handler func(args []driver.Value) Result
+
+// Call é uma consulta executada, com a query normalizada (espaços colapsados).
+type Call struct {
+	Query string
+	Args  []driver.Value
+}
+
+// Store guarda os handlers e as consultas executadas de um banco.
+type Store struct {
+	mu       sync.Mutex
+	handlers []route
+	calls    []Call
+	pingErr  error
+}
+
+type route struct {
+	pattern string
+	handler Handler
+}
+
+var (
+	registerOnce sync.Once
+	stores       sync.Map // nome do DSN -> *Store
+	nextID       atomic.Int64
+)
+
+// Open cria um banco vazio. Consultas sem handler falham com um erro que cita a query.
+func Open() (*sql.DB, *Store) {
+	registerOnce.Do(func() { sql.Register("testdb", testDriver{}) })
+
+	store := &Store{}
+	name := strconv.FormatInt(nextID.Add(1), 10)
+	stores.Store(name, store)
+	db, err := sql.Open("testdb", name)
+	if err != nil {
+		panic(err)
+	}
+	return db, store
+}
+
+// Handle registra o handler das consultas que contêm pattern (comparado com a query normalizada).
+func (s *Store) Handle(pattern string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, route{pattern: normalize(pattern), handler: handler})
+}
+
+// Rows registra uma resposta fixa para as consultas que contêm pattern.
+func (s *Store) Rows(pattern string, columns []string, rows ...[]driver.Value) {
+	s.Handle(pattern, func([]driver.Value) Result { return Result{Columns: columns, Rows: rows} })
+}
+
+// Exec registra uma resposta fixa para os comandos que contêm pattern.
+func (s *Store) Exec(pattern string, rowsAffected int64) {
+	s.Handle(pattern, func([]driver.Value) Result { return Result{RowsAffected: rowsAffected} })
+}
+
+// FailPing faz o Ping do banco falhar com err.
+func (s *Store) FailPing(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pingErr = err
+}
+
+// Calls retorna as consultas executadas que contêm pattern, na ordem.
+func (s *Store) Calls(pattern string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pattern = normalize(pattern)
+	var calls []Call
+	for _, call := range s.calls {
+		if strings.Contains(call.Query, pattern) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (s *Store) run(query string, args []driver.Value) (Result, error) {
+	query = normalize(query)
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Query: query, Args: args})
+	var handler Handler
+	for _, r := range s.handlers {
+		if strings.Contains(query, r.pattern) {
+			handler = r.handler
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if handler == nil {
+		return Result{}, fmt.Errorf("testdb: unexpected query %q", query)
+	}
+	result := handler(args)
+	return result, result.Err
+}
+
+func normalize(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+type testDriver struct{}
+
+func (testDriver) Open(name string) (driver.Conn, error) {
+	store, ok := stores.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("testdb: unknown database %q", name)
+	}
+	return &conn{store: store.(*Store)}, nil
+}
+
+type conn struct {
+	store *Store
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error              { return nil }
+func (c *conn) Begin() (driver.Tx, error) { return tx{}, nil }
+
+func (c *conn) Ping(ctx context.Context) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	return c.store.pingErr
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result, err := c.store.run(query, namedValues(args))
+	if err != nil {
+		return nil, err
+	}
+	return execResult{lastInsertID: result.LastInsertID, rowsAffected: result.RowsAffected}, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result, err := c.store.run(query, namedValues(args))
+	if err != nil {
+		return nil, err
+	}
+	return &rows{columns: result.Columns, values: result.Rows}, nil
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error  { return nil }
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	result, err := s.conn.store.run(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return execResult{lastInsertID: result.LastInsertID, rowsAffected: result.RowsAffected}, nil
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	result, err := s.conn.store.run(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{columns: result.Columns, values: result.Rows}, nil
+}
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
+
+type execResult struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r execResult) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+func (r execResult) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+func (r *rows) Columns() []string { return r.columns }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
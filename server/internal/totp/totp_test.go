@@ -0,0 +1,79 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// Segredo ASCII "12345678901234567890" dos vetores de teste do RFC 6238 (SHA-1), em base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// Vetores do apêndice B do RFC 6238, com os 6 últimos dígitos dos códigos de 8.
+func TestCodeRFC6238Vectors(t *testing.T) {
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, v := range vectors {
+		code, err := Code(rfcSecret, Step(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != v.code {
+			t.Errorf("Code at %d = %s, want %s", v.unix, code, v.code)
+		}
+	}
+
+	// Segredos digitados em minúsculas ou com espaços em volta também valem
+	if code, _ := Code(" gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", 1); code != "287082" {
+		t.Errorf("Code with a lowercase secret = %s, want 287082", code)
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code accepted an invalid secret")
+	}
+}
+
+// Validate aceita os códigos de até skew intervalos antes ou depois e devolve o intervalo
+// do código, que é o que impede o reuso.
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Step(now)
+	codeAt := func(step int64) string {
+		code, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	for _, delta := range []int64{-1, 0, 1} {
+		step, ok := Validate(rfcSecret, codeAt(current+delta), now, 1)
+		if !ok || step != current+delta {
+			t.Errorf("code %+d steps away: Validate = %d, %v, want %d, true", delta, step, ok, current+delta)
+		}
+	}
+	for _, delta := range []int64{-2, 2} {
+		if _, ok := Validate(rfcSecret, codeAt(current+delta), now, 1); ok {
+			t.Errorf("code %+d steps away accepted with skew 1", delta)
+		}
+	}
+	if _, ok := Validate(rfcSecret, codeAt(current+1), now, 0); ok {
+		t.Error("code from the next step accepted with skew 0")
+	}
+
+	// Espaços digitados pelo usuário são ignorados; tamanhos diferentes, não
+	spaced := codeAt(current)[:3] + " " + codeAt(current)[3:]
+	if _, ok := Validate(rfcSecret, spaced, now, 0); !ok {
+		t.Errorf("code %q with a space rejected", spaced)
+	}
+	if _, ok := Validate(rfcSecret, codeAt(current)[:5], now, 0); ok {
+		t.Error("5-digit code accepted")
+	}
+}
@@ -0,0 +1,139 @@
+package asyncapi
+
+import (
+	"messenger-pigeon-app/pkg/openapi"
+	"messenger-pigeon-app/pkg/websockets"
+	"sort"
+)
+
+// Version é a versão da especificação AsyncAPI gerada.
+const Version = "2.6.0"
+
+// Document é o documento AsyncAPI do protocolo WebSocket versionado, servido em /asyncapi.json.
+type Document struct {
+	AsyncAPI           string              `json:"asyncapi"`
+	Info               openapi.Info        `json:"info"`
+	DefaultContentType string              `json:"defaultContentType"`
+	Channels           map[string]*Channel `json:"channels"`
+	Components         Components          `json:"components"`
+}
+
+// Channel é um endpoint WebSocket. No AsyncAPI 2, publish são os eventos que o cliente
+// envia e subscribe, os que ele recebe.
+type Channel struct {
+	Description string                `json:"description,omitempty"`
+	Parameters  map[string]*Parameter `json:"parameters,omitempty"`
+	Bindings    map[string]Binding    `json:"bindings,omitempty"`
+	Publish     *Operation            `json:"publish,omitempty"`
+	Subscribe   *Operation            `json:"subscribe,omitempty"`
+}
+
+type Parameter struct {
+	Description string          `json:"description,omitempty"`
+	Schema      *openapi.Schema `json:"schema"`
+}
+
+type Binding map[string]interface{}
+
+type Operation struct {
+	OperationID string      `json:"operationId"`
+	Summary     string      `json:"summary,omitempty"`
+	Message     MessageRefs `json:"message"`
+}
+
+type MessageRefs struct {
+	OneOf []Ref `json:"oneOf"`
+}
+
+type Ref struct {
+	Ref string `json:"$ref"`
+}
+
+type Message struct {
+	Name        string          `json:"name"`
+	Summary     string          `json:"summary,omitempty"`
+	ContentType string          `json:"contentType"`
+	Payload     *openapi.Schema `json:"payload"`
+}
+
+type Components struct {
+	Messages map[string]*Message        `json:"messages"`
+	Schemas  map[string]*openapi.Schema `json:"schemas"`
+}
+
+// Endpoint descreve uma rota WebSocket (caminho no formato do gin) que fala o protocolo.
+type Endpoint struct {
+	Path    string
+	Summary string
+}
+
+// Build gera o documento com os eventos de websockets.Events, todos disponíveis em cada endpoint.
+func Build(info openapi.Info, endpoints []Endpoint) *Document {
+	doc := &Document{
+		AsyncAPI:           Version,
+		Info:               info,
+		DefaultContentType: "application/json",
+		Channels:           make(map[string]*Channel),
+		Components: Components{
+			Messages: make(map[string]*Message),
+			Schemas:  make(map[string]*openapi.Schema),
+		},
+	}
+
+	var toServer, toClient []Ref
+	for _, event := range websockets.Events {
+		name := string(event.Type)
+		doc.Components.Messages[name] = &Message{
+			Name:        name,
+			Summary:     event.Summary,
+			ContentType: doc.DefaultContentType,
+			Payload:     envelopeSchema(doc.Components.Schemas, event),
+		}
+		ref := Ref{Ref: "#/components/messages/" + name}
+		if event.ToServer {
+			toServer = append(toServer, ref)
+		} else {
+			toClient = append(toClient, ref)
+		}
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].Path < endpoints[j].Path })
+	for _, endpoint := range endpoints {
+		path, params := openapi.ConvertPath(endpoint.Path)
+		channel := &Channel{
+			Description: endpoint.Summary,
+			Bindings:    map[string]Binding{"ws": {"method": "GET", "bindingVersion": "0.1.0"}},
+			Publish:     &Operation{OperationID: openapi.OperationID("send", endpoint.Path), Message: MessageRefs{OneOf: toServer}},
+			Subscribe:   &Operation{OperationID: openapi.OperationID("receive", endpoint.Path), Message: MessageRefs{OneOf: toClient}},
+		}
+		if len(params) > 0 {
+			channel.Parameters = make(map[string]*Parameter, len(params))
+			for _, param := range params {
+				channel.Parameters[param.Name] = &Parameter{Description: param.Description, Schema: param.Schema}
+			}
+		}
+		doc.Channels[path] = channel
+	}
+	return doc
+}
+
+// envelopeSchema descreve o Envelope com o tipo fixo do evento e o schema do seu payload.
+func envelopeSchema(components map[string]*openapi.Schema, event websockets.Event) *openapi.Schema {
+	schema := openapi.SchemaFor(components, openapi.Object{
+		"type":      "",
+		"id":        "",
+		"replyTo":   openapi.Optional{Sample: ""},
+		"timestamp": websockets.Envelope{}.Timestamp,
+		"payload":   event.Payload,
+		"meta":      openapi.Optional{Sample: websockets.Envelope{}.Meta},
+	})
+	schema.Properties["type"].Enum = []string{string(event.Type)}
+	schema.Properties["replyTo"].Description = "Id do evento do cliente respondido"
+	schema.Properties["meta"].Description = "Contexto de trace W3C (traceparent, tracestate) do evento"
+	if event.ToServer {
+		// Nos eventos do cliente só o tipo e o payload são obrigatórios; o id volta no replyTo
+		schema.Required = []string{"payload", "type"}
+		delete(schema.Properties, "replyTo")
+	}
+	return schema
+}
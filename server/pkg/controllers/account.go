@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyEmail confirma o email com o token enviado no cadastro.
+func VerifyEmail(c *gin.Context) {
+	var request model.AccountToken
+	if bindErr := c.ShouldBind(&request); bindErr != nil {
+		c.JSON(http.StatusBadRequest, bindingError(c, bindErr))
+		return
+	}
+
+	if verifyErr := services.VerifyEmail(c.Request.Context(), request.Token); verifyErr != nil {
+		if errors.Is(verifyErr, services.ErrInvalidAccountToken) {
+			c.JSON(http.StatusBadRequest, i18n.ErrorWithFields(c, err.CodeInvalidAccountToken, "error.invalid_account_token", map[string]string{"token": i18n.Message(c, "error.invalid_account_token")}))
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Error verifying email", "error", verifyErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.verify_email_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Message(c, "message.email_verified")})
+}
+
+// ResendEmailVerification envia novamente o link de verificação ao usuário autenticado.
+func ResendEmailVerification(c *gin.Context) {
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
+		return
+	}
+
+	if sendErr := services.SendEmailVerification(c.Request.Context(), userID, i18n.FromContext(c)); sendErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error sending email verification", "error", sendErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.send_verification_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Message(c, "message.verification_sent")})
+}
+
+// ForgotPassword envia o link de redefinição de senha. A resposta é a mesma para emails
+// cadastrados ou não.
+func ForgotPassword(c *gin.Context) {
+	var request model.ForgotPassword
+	if bindErr := c.ShouldBind(&request); bindErr != nil {
+		c.JSON(http.StatusBadRequest, bindingError(c, bindErr))
+		return
+	}
+
+	if resetErr := services.RequestPasswordReset(c.Request.Context(), request.Email, i18n.FromContext(c)); resetErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error requesting password reset", "error", resetErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.forgot_password_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Message(c, "message.password_reset_sent")})
+}
+
+// ResetPassword define uma nova senha com o token recebido por email e encerra todas as sessões.
+func ResetPassword(c *gin.Context) {
+	var request model.ResetPassword
+	if bindErr := c.ShouldBind(&request); bindErr != nil {
+		c.JSON(http.StatusBadRequest, bindingError(c, bindErr))
+		return
+	}
+
+	userID, revoked, resetErr := services.ResetPassword(c.Request.Context(), request.Token, request.Password)
+	if resetErr != nil {
+		if errors.Is(resetErr, services.ErrInvalidAccountToken) {
+			c.JSON(http.StatusBadRequest, i18n.ErrorWithFields(c, err.CodeInvalidAccountToken, "error.invalid_account_token", map[string]string{"token": i18n.Message(c, "error.invalid_account_token")}))
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Error resetting password", "error", resetErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.reset_password_failed"))
+		return
+	}
+	websockets.DisconnectSessions(userID, revoked...)
+
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Message(c, "message.password_reset")})
+}
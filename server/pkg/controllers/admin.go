@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Início do processo, para o tempo no ar dos diagnósticos.
+var startedAt = time.Now()
+
+// BuildInfo identifica o binário em execução. Revision e BuildTime vêm do controle de versão
+// e só existem em binários compilados a partir de um checkout do git.
+type BuildInfo struct {
+	Module    string `json:"module"`
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	Revision  string `json:"revision,omitempty"`
+	BuildTime string `json:"buildTime,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // Compilado com alterações não commitadas
+}
+
+// UserConnections são as conexões abertas de um usuário.
+type UserConnections struct {
+	UserID      int64                       `json:"userId"`
+	Connections []websockets.ConnectionInfo `json:"connections"`
+}
+
+var readBuildInfo = sync.OnceValue(func() BuildInfo {
+	info := BuildInfo{GoVersion: runtime.Version()}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.Module = build.Main.Path
+	info.Version = build.Main.Version
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.BuildTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+})
+
+// AdminConnections lista as conexões em tempo real abertas nesta instância, por usuário.
+// O parâmetro userId restringe a lista a um usuário.
+func AdminConnections(c *gin.Context) {
+	var filter int64
+	if value := c.Query("userId"); value != "" {
+		id, convErr := strconv.ParseInt(value, 10, 64)
+		if convErr != nil {
+			c.JSON(http.StatusBadRequest, i18n.ErrorWithFields(c, err.CodeValidation, "error.validation", map[string]string{"userId": i18n.Message(c, "validation.integer")}))
+			return
+		}
+		filter = id
+	}
+
+	users := []UserConnections{}
+	total := 0
+	for userID, connections := range websockets.LiveConnections() {
+		if filter != 0 && userID != filter {
+			continue
+		}
+		sort.Slice(connections, func(i, j int) bool { return connections[i].OpenedAt.Before(connections[j].OpenedAt) })
+		users = append(users, UserConnections{UserID: userID, Connections: connections})
+		total += len(connections)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].UserID < users[j].UserID })
+
+	c.JSON(http.StatusOK, gin.H{"users": users, "total": total})
+}
+
+// AdminDiagnostics mostra a versão em execução, o tempo no ar, a fila de cada pool de
+// workers e o número de conexões abertas nesta instância.
+func AdminDiagnostics(c *gin.Context) {
+	connections := websockets.LiveConnections()
+	total := 0
+	for _, userConnections := range connections {
+		total += len(userConnections)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"build":         readBuildInfo(),
+		"startedAt":     startedAt,
+		"uptimeSeconds": int64(time.Since(startedAt).Seconds()),
+		"goroutines":    runtime.NumGoroutine(),
+		"workerPools":   websockets.WorkerPools(),
+		"connections":   gin.H{"total": total, "users": len(connections)},
+	})
+}
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"messenger-pigeon-app/config/env"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Signup cadastra um novo usuário.
+func Signup(c *gin.Context) {
+	var user model.User
+	if bindErr := c.ShouldBind(&user); bindErr != nil {
+		c.JSON(http.StatusBadRequest, bindingError(c, bindErr))
+		return
+	}
+
+	// Sem idioma no cadastro, salva o negociado pela requisição como preferência
+	if user.Locale == "" {
+		user.Locale = i18n.FromContext(c)
+	} else if locale, ok := i18n.Match(user.Locale); ok {
+		user.Locale = locale
+	} else {
+		c.JSON(http.StatusBadRequest, i18n.ErrorWithFields(c, err.CodeValidation, "error.validation", map[string]string{"locale": i18n.Message(c, "error.unsupported_locale")}))
+		return
+	}
+
+	fields, availabilityErr := services.CheckUserAvailability(c.Request.Context(), user)
+	if availabilityErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error checking user availability", "error", availabilityErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.signup_failed"))
+		return
+	}
+	if len(fields) > 0 {
+		// O service devolve as chaves do catálogo de cada campo
+		for field, key := range fields {
+			fields[field] = i18n.Message(c, key)
+		}
+		c.JSON(http.StatusConflict, i18n.ErrorWithFields(c, err.CodeAlreadyExists, "error.already_exists", fields))
+		return
+	}
+
+	userID, registerErr := services.RegisterUser(c.Request.Context(), user)
+	if registerErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error registering user", "error", registerErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.signup_failed"))
+		return
+	}
+
+	// O cadastro não depende do envio do email; o usuário pode pedir o reenvio depois
+	if sendErr := services.SendEmailVerification(c.Request.Context(), int(userID), i18n.FromContext(c)); sendErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error sending email verification", "error", sendErr)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      userID,
+		"message": i18n.Message(c, "message.user_created"),
+	})
+}
+
+// Login autentica o usuário, abre uma sessão e emite o token de acesso e o refresh token.
+// Com 2FA ativo, responde apenas com um token parcial para a segunda etapa.
+func Login(c *gin.Context) {
+	var credentials model.UserLogin
+	if bindErr := c.ShouldBind(&credentials); bindErr != nil {
+		c.JSON(http.StatusBadRequest, bindingError(c, bindErr))
+		return
+	}
+
+	userID, authErr := services.AuthenticateUser(c.Request.Context(), credentials.Login, credentials.Password)
+	if authErr != nil {
+		if errors.Is(authErr, services.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, i18n.Error(c, err.CodeInvalidCredentials, "error.invalid_credentials"))
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Error authenticating user", "error", authErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.login_failed"))
+		return
+	}
+
+	enabled, mfaErr := services.IsTwoFactorEnabled(c.Request.Context(), userID)
+	if mfaErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error checking two-factor settings", "error", mfaErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.login_failed"))
+		return
+	}
+	if enabled {
+		// O login só é concluído em /login/2fa, trocando o token parcial e o código pelos tokens da sessão
+		mfaToken, expiresAt, tokenErr := services.IssueMFAToken(userID)
+		if tokenErr != nil {
+			slog.ErrorContext(c.Request.Context(), "Error issuing mfa token", "error", tokenErr)
+			c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.login_failed"))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"mfaRequired": true,
+			"mfaToken":    mfaToken,
+			"expiresAt":   expiresAt,
+		})
+		return
+	}
+
+	startSession(c, userID, credentials.Device)
+}
+
+// startSession abre a sessão do usuário autenticado e responde com os tokens.
+func startSession(c *gin.Context, userID int, device string) {
+	if device == "" {
+		device = c.Request.UserAgent()
+	}
+
+	tokens, sessionErr := services.StartSession(c.Request.Context(), userID, device, c.ClientIP())
+	if sessionErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error starting session", "error", sessionErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.login_failed"))
+		return
+	}
+
+	setTokenCookie(c, tokens)
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RefreshToken troca um refresh token por um novo par de tokens (o refresh token usado deixa de valer).
+func RefreshToken(c *gin.Context) {
+	var request model.RefreshTokenRequest
+	if bindErr := c.ShouldBind(&request); bindErr != nil {
+		c.JSON(http.StatusBadRequest, bindingError(c, bindErr))
+		return
+	}
+
+	tokens, refreshErr := services.RefreshSession(c.Request.Context(), request.RefreshToken)
+	if refreshErr != nil {
+		var reused *services.RefreshTokenReusedError
+		switch {
+		case errors.As(refreshErr, &reused):
+			slog.WarnContext(c.Request.Context(), "Revoking session after refresh token reuse", "user_id", reused.UserID, "session_id", reused.SessionID)
+			websockets.DisconnectSessions(reused.UserID, reused.SessionID)
+			c.JSON(http.StatusUnauthorized, i18n.Error(c, err.CodeInvalidRefreshToken, "error.invalid_refresh_token"))
+		case errors.Is(refreshErr, services.ErrInvalidRefreshToken):
+			c.JSON(http.StatusUnauthorized, i18n.Error(c, err.CodeInvalidRefreshToken, "error.invalid_refresh_token"))
+		default:
+			slog.ErrorContext(c.Request.Context(), "Error refreshing session", "error", refreshErr)
+			c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.refresh_failed"))
+		}
+		return
+	}
+
+	setTokenCookie(c, tokens)
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revoga a sessão atual e fecha suas conexões WebSocket.
+func Logout(c *gin.Context) {
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
+		return
+	}
+	sessionID := websockets.GetSessionIDFromContext(c)
+
+	if _, revokeErr := services.RevokeSession(c.Request.Context(), userID, sessionID); revokeErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error revoking session", "error", revokeErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.logout_failed"))
+		return
+	}
+	websockets.DisconnectSessions(userID, sessionID)
+
+	c.SetCookie("token", "", -1, "/", "", secureCookie(c), true)
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Message(c, "message.logged_out")})
+}
+
+// Também disponibiliza o token de acesso como cookie, lido pelo AuthMiddleware
+func setTokenCookie(c *gin.Context, tokens services.AuthTokens) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie("token", tokens.AccessToken, int(time.Until(tokens.ExpiresAt).Seconds()), "/", "", secureCookie(c), true)
+}
+
+// secureCookie indica se o cookie do token leva o atributo Secure. Atrás de um proxy que
+// termina o TLS, a requisição chega sem TLS, então COOKIE_SECURE=true força o atributo.
+func secureCookie(c *gin.Context) bool {
+	return c.Request.TLS != nil || env.Bool("COOKIE_SECURE", false)
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"crypto/tls"
+	"messenger-pigeon-app/pkg/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// O cookie do token leva Secure em conexões TLS e, atrás de um proxy que termina o TLS,
+// quando COOKIE_SECURE=true.
+func TestTokenCookieSecure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name   string
+		env    string
+		tls    bool
+		secure bool
+	}{
+		{"plain http", "", false, false},
+		{"tls", "", true, true},
+		{"behind tls proxy", "true", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("COOKIE_SECURE", tt.env)
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/auth/login", nil)
+			if tt.tls {
+				c.Request.TLS = &tls.ConnectionState{}
+			}
+
+			setTokenCookie(c, services.AuthTokens{AccessToken: "token", ExpiresAt: time.Now().Add(time.Minute)})
+
+			cookie := rec.Header().Get("Set-Cookie")
+			if got := strings.Contains(cookie, "; Secure"); got != tt.secure {
+				t.Errorf("Set-Cookie = %q, want Secure %v", cookie, tt.secure)
+			}
+		})
+	}
+}
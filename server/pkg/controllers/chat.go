@@ -1,9 +1,12 @@
 package controllers
 
 import (
-	"fmt"
-	"log"
+	"errors"
+	"log/slog"
 	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/metrics"
 	"messenger-pigeon-app/pkg/repository"
 	"messenger-pigeon-app/pkg/services"
 	"messenger-pigeon-app/pkg/websockets"
@@ -12,44 +15,36 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gorilla/websocket"
 )
 
 // Chat é um manipulador HTTP que lida com solicitações de chat.
 func Chat(c *gin.Context) {
-	userId, exists := c.Get("id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in session"})
+	id := websockets.GetUserIDFromContext(c)
+	if id == 0 {
 		return
 	}
 
-	id, err := strconv.Atoi(fmt.Sprintf("%v", userId))
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
-		return
-	}
-
-	username := c.Param("username")
-	partnerID, err := repository.MessageGetUserIDByUsername(username)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user ID"})
+	partnerID, ok := resolvePartner(c)
+	if !ok {
 		return
 	}
 
-	messages, err := services.GetChatMessages(id, partnerID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve messages"})
+	messages, messagesErr := services.GetChatMessages(c.Request.Context(), id, partnerID)
+	if messagesErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error retrieving messages", "error", messagesErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.messages_failed"))
 		return
 	}
-	currentUsername, err := repository.GetUsernameByID(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user Username"})
+	currentUsername, usernameErr := repository.GetUsernameByID(c.Request.Context(), id)
+	if usernameErr != nil {
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.username_lookup_failed"))
 		return
 	}
 
-	userInfosName, userInfosUsername, userInfosIcon, err := services.GetChatInfos(partnerID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve chat partner info"})
+	userInfosName, userInfosUsername, userInfosIcon, infoErr := services.GetChatInfos(c.Request.Context(), partnerID)
+	if infoErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error retrieving chat partner info", "error", infoErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.chat_partner_failed"))
 		return
 	}
 
@@ -62,73 +57,88 @@ func Chat(c *gin.Context) {
 
 // WebSocketChat é um manipulador HTTP para a rota websockets.
 func WebSocketChat(c *gin.Context) {
-	ws, err := websocket.Upgrade(c.Writer, c.Request, nil, 1024, 1024)
-	if err != nil {
-		log.Println("Error:", err)
+	// O usuário é validado pelo WebSocketAuthMiddleware antes do upgrade
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
 		return
 	}
-	defer ws.Close()
 
-	userID := websockets.GetUserIDFromContext(c)
-	if userID == 0 {
+	ws, err := websockets.Upgrade(c.Writer, c.Request)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error upgrading websocket connection", "error", err)
 		return
 	}
+	defer ws.Close()
 
 	// Registrar a conexão
-	websockets.UserConnections[int64(userID)] = ws
-
-	// Iniciar o controle de inatividade
-	go websockets.StartInactivityTimer(ws, userID)
+	client := websockets.NewClient(c.Request.Context(), ws, int64(userID), websockets.GetSessionIDFromContext(c), i18n.FromContext(c))
+	websockets.UserConnections.Register(client)
+	defer websockets.UserConnections.Unregister(client)
+	defer metrics.TrackConnection(c.FullPath())()
 
 	// Iniciar o manuseio de mensagens
-	websockets.HandleChatMessages(ws, userID)
+	websockets.HandleChatMessages(client)
 }
 
+// CreateNewMessage envia uma mensagem ao usuário da rota. Aceita JSON ou formulário; o
+// clientMessageId pode vir no corpo ou no cabeçalho Idempotency-Key.
 func CreateNewMessage(c *gin.Context) {
-	var errResp err.ErrorResponse
-
-	// Parse do corpo da requisição
-	if err := c.Request.ParseForm(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	id := websockets.GetUserIDFromContext(c)
+	if id == 0 {
 		return
 	}
 
-	username := c.Param("username")
-	content := strings.TrimSpace(c.PostForm("content"))
-	userId, exists := c.Get("id")
-	if !exists {
-		log.Println("User ID not found in session")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in session"})
+	var request model.NewMessage
+	if bindErr := c.ShouldBind(&request); bindErr != nil {
+		c.JSON(http.StatusBadRequest, bindingError(c, bindErr))
 		return
 	}
 
-	id, err := strconv.Atoi(fmt.Sprintf("%v", userId))
-	if err != nil {
-		log.Println("Error: ", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+	content := strings.TrimSpace(request.Content)
+	if content == "" {
+		c.JSON(http.StatusBadRequest, i18n.ErrorWithFields(c, err.CodeValidation, "error.validation", map[string]string{"content": i18n.Message(c, "validation.required")}))
 		return
 	}
 
-	// Validação básica
-	if content == "" {
-		errResp.Error["content"] = "Values are missing!"
+	// Id gerado pelo cliente: pelo cabeçalho Idempotency-Key ou pelo campo clientMessageId
+	clientMessageID := strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+	if field := strings.TrimSpace(request.ClientMessageID); field != "" {
+		if clientMessageID != "" && clientMessageID != field {
+			c.JSON(http.StatusBadRequest, i18n.ErrorWithFields(c, err.CodeValidation, "error.validation", map[string]string{"clientMessageId": i18n.Message(c, "validation.idempotency_key_mismatch")}))
+			return
+		}
+		clientMessageID = field
 	}
-	if len(errResp.Error) > 0 {
-		c.JSON(http.StatusBadRequest, errResp)
+	if len(clientMessageID) > websockets.MaxClientMessageIDLength {
+		c.JSON(http.StatusBadRequest, i18n.ErrorWithFields(c, err.CodeValidation, "error.validation", map[string]string{"clientMessageId": i18n.Message(c, "validation.max", strconv.Itoa(websockets.MaxClientMessageIDLength))}))
 		return
 	}
 
 	// Chama o service para enviar a mensagem
-	messageID, err := websockets.SendChatMessage(id, username, content)
-	if err != nil {
-		log.Println("Error sending message:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send message"})
+	messageID, replayed, sendErr := websockets.SendChatMessage(c.Request.Context(), id, conversationPartner(c), content, clientMessageID)
+	if sendErr != nil {
+		switch {
+		case errors.Is(sendErr, repository.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, i18n.Error(c, err.CodeUserNotFound, "error.user_not_found"))
+		case errors.Is(sendErr, websockets.ErrClientMessageConflict):
+			c.JSON(http.StatusConflict, i18n.Error(c, err.CodeClientMessageReused, "error.client_message_id_reused"))
+		default:
+			slog.ErrorContext(c.Request.Context(), "Error sending message", "error", sendErr)
+			c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.send_message_failed"))
+		}
 		return
 	}
 
 	resp := map[string]interface{}{
 		"messageID": messageID,
-		"message":   "Message sent successfully",
+		"message":   i18n.Message(c, "message.message_sent"),
+	}
+	if clientMessageID != "" {
+		resp["clientMessageId"] = clientMessageID
+	}
+	if replayed {
+		// Reenvio de uma mensagem já salva: mesma resposta, sem gravar nem entregar de novo
+		c.Header("Idempotent-Replayed", "true")
 	}
 
 	c.JSON(http.StatusOK, resp)
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/repository"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Nas rotas /v1 a conversa é identificada pelo username do outro participante (:id);
+// nas rotas legadas, pelo parâmetro :username.
+func conversationPartner(c *gin.Context) string {
+	if username := c.Param("username"); username != "" {
+		return username
+	}
+	return c.Param("id")
+}
+
+// resolvePartner obtém o ID do outro participante da conversa, respondendo 404 se ele não existir.
+func resolvePartner(c *gin.Context) (int, bool) {
+	partnerID, lookupErr := repository.MessageGetUserIDByUsername(c.Request.Context(), conversationPartner(c))
+	if lookupErr != nil {
+		if errors.Is(lookupErr, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, i18n.Error(c, err.CodeUserNotFound, "error.user_not_found"))
+			return 0, false
+		}
+		slog.ErrorContext(c.Request.Context(), "Error getting user ID", "error", lookupErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.user_lookup_failed"))
+		return 0, false
+	}
+	return partnerID, true
+}
+
+// ListConversations lista as conversas do usuário com a última mensagem de cada uma.
+// GET /v1/conversations
+func ListConversations(c *gin.Context) {
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
+		return
+	}
+
+	chats, chatsErr := services.GetUserChats(c.Request.Context(), int64(userID))
+	if chatsErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error in service layer", "error", chatsErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.chats_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"conversations": chats})
+}
+
+// GetConversation retorna o outro participante da conversa.
+// GET /v1/conversations/:id
+func GetConversation(c *gin.Context) {
+	if websockets.GetUserIDFromContext(c) == 0 {
+		return
+	}
+	partnerID, ok := resolvePartner(c)
+	if !ok {
+		return
+	}
+
+	name, username, iconBase64, infoErr := services.GetChatInfos(c.Request.Context(), partnerID)
+	if infoErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error retrieving chat partner info", "error", infoErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.chat_partner_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":         username,
+		"username":   username,
+		"name":       name,
+		"iconBase64": iconBase64,
+	})
+}
+
+// ListConversationMessages lista as mensagens trocadas com o outro participante.
+// GET /v1/conversations/:id/messages
+func ListConversationMessages(c *gin.Context) {
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
+		return
+	}
+	partnerID, ok := resolvePartner(c)
+	if !ok {
+		return
+	}
+
+	messages, messagesErr := services.GetChatMessages(c.Request.Context(), userID, partnerID)
+	if messagesErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error retrieving messages", "error", messagesErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.messages_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"messages": messages})
+}
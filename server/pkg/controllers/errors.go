@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"log/slog"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotFound responde às rotas inexistentes com o envelope de erro.
+func NotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, i18n.Error(c, err.CodeNotFound, "error.route_not_found"))
+}
+
+// Recovery responde com o envelope de erro quando um handler entra em pânico.
+func Recovery(c *gin.Context, recovered interface{}) {
+	slog.ErrorContext(c.Request.Context(), "Recovered from panic", "panic", recovered)
+	c.AbortWithStatusJSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.internal"))
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"messenger-pigeon-app/pkg/metrics"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Espera padrão e máxima do long-poll (?timeout= em segundos)
+const (
+	defaultPollTimeout = 25 * time.Second
+	maxPollTimeout     = 60 * time.Second
+)
+
+// EventStream entrega os eventos do usuário por Server-Sent Events, para clientes cujo proxy
+// bloqueia o upgrade do WebSocket. Retoma a partir do Last-Event-ID ou do ?cursor=.
+// GET /v1/events
+func EventStream(c *gin.Context) {
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
+		return
+	}
+
+	cursor := c.GetHeader("Last-Event-ID")
+	if cursor == "" {
+		cursor = c.Query("cursor")
+	}
+
+	stream := websockets.NewStreamClient(c.Request.Context(), int64(userID), websockets.GetSessionIDFromContext(c))
+	backlog, _ := websockets.Subscribe(websockets.UserConnections, stream, cursor)
+	defer websockets.UserConnections.Unregister(stream)
+	defer stream.Close()
+	defer metrics.TrackConnection(c.FullPath())()
+
+	websockets.ServeSSE(c.Writer, c.Request, stream, backlog)
+}
+
+// PollEvents é o long-poll dos mesmos eventos: responde assim que houver eventos depois do
+// ?cursor= ou, sem eventos, ao fim da espera. O cursor da resposta é usado na próxima chamada.
+// GET /v1/events/poll
+func PollEvents(c *gin.Context) {
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
+		return
+	}
+
+	timeout := defaultPollTimeout
+	if seconds, convErr := strconv.Atoi(c.Query("timeout")); convErr == nil && seconds >= 0 {
+		timeout = time.Duration(seconds) * time.Second
+		if timeout > maxPollTimeout {
+			timeout = maxPollTimeout
+		}
+	}
+
+	stream := websockets.NewStreamClient(c.Request.Context(), int64(userID), websockets.GetSessionIDFromContext(c))
+	backlog, start := websockets.Subscribe(websockets.UserConnections, stream, c.Query("cursor"))
+	defer websockets.UserConnections.Unregister(stream)
+	defer stream.Close()
+	defer metrics.TrackConnection(c.FullPath())()
+
+	events, cursor := websockets.Poll(c.Request.Context(), stream, backlog, start, timeout)
+	if events == nil {
+		events = []websockets.Envelope{}
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"cursor": cursor,
+	})
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tempo máximo das verificações do /readyz, abaixo do timeout usual das sondas.
+const readinessTimeout = 2 * time.Second
+
+// Healthz informa que o processo está no ar, sem verificar dependências.
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz informa se a instância pode receber tráfego: o banco responde, todas as migrações
+// foram aplicadas (menos a bloqueada por linhas repetidas, sem a qual o servidor subiu) e os
+// pools de entrega em tempo real estão rodando. Responde 503 com o
+// resultado de cada verificação se alguma falhar; os detalhes dos erros ficam só no log.
+func Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	ready := true
+	checks := make(map[string]string)
+	check := func(name string, checkErr error) {
+		if checkErr != nil {
+			slog.WarnContext(ctx, "Readiness check failed", "check", name, "error", checkErr)
+			ready = false
+			checks[name] = "unavailable"
+			return
+		}
+		checks[name] = "ok"
+	}
+
+	check("database", services.PingDatabase(ctx))
+	if checks["database"] == "ok" {
+		pending, migrationErr := services.PendingMigrations(ctx)
+		check("migrations", migrationErr)
+		switch blocked := services.BlockedMigration(); {
+		case migrationErr != nil || len(pending) == 0:
+		case blocked != "" && pending[0] == blocked:
+			// O servidor subiu sem a migração, que espera a correção das linhas repetidas
+			checks["migrations"] = blocked + " blocked by duplicate rows"
+		default:
+			slog.WarnContext(ctx, "Readiness check failed", "check", "migrations", "pending", pending)
+			ready = false
+			checks["migrations"] = strconv.Itoa(len(pending)) + " pending"
+		}
+	} else {
+		checks["migrations"] = "unavailable"
+	}
+	check("bus", websockets.Ready())
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "checks": checks})
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/internal/testdb"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Com o banco fora do ar, o /readyz responde 503 sem consultar as migrações.
+func TestReadyzDatabaseDown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, store := testdb.Open()
+	database.SetDB(db)
+	t.Cleanup(func() { database.SetDB(nil) })
+	store.FailPing(errors.New("connection refused"))
+	websockets.Initialize()
+
+	router := gin.New()
+	router.GET("/readyz", Readyz)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	var body struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"database": "unavailable", "migrations": "unavailable", "bus": "ok"}
+	for name, status := range want {
+		if body.Checks[name] != status {
+			t.Errorf("checks[%s] = %q, want %q", name, body.Checks[name], status)
+		}
+	}
+	if body.Status != "unavailable" {
+		t.Errorf("status = %q, want unavailable", body.Status)
+	}
+	if calls := store.Calls("schema_migrations"); len(calls) > 0 {
+		t.Errorf("migrations were queried with the database down: %v", calls)
+	}
+}
@@ -0,0 +1,15 @@
+package controllers
+
+import (
+	"messenger-pigeon-app/config/keys"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS publica as chaves públicas usadas para assinar os tokens de acesso, para que outros
+// serviços possam verificá-los sem compartilhar segredos.
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, keys.Default().PublicJWKS())
+}
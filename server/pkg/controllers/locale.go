@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateLocale salva o idioma preferido do usuário. Ele passa a valer nas respostas a partir
+// do próximo token de acesso (login ou refresh) e nos emails enviados ao usuário.
+func UpdateLocale(c *gin.Context) {
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
+		return
+	}
+
+	var request model.LocalePreference
+	if bindErr := c.ShouldBind(&request); bindErr != nil {
+		c.JSON(http.StatusBadRequest, bindingError(c, bindErr))
+		return
+	}
+
+	locale, localeErr := services.SetUserLocale(c.Request.Context(), userID, request.Locale)
+	if localeErr != nil {
+		if errors.Is(localeErr, services.ErrUnsupportedLocale) {
+			c.JSON(http.StatusBadRequest, i18n.ErrorWithFields(c, err.CodeValidation, "error.validation", map[string]string{"locale": i18n.Message(c, "error.unsupported_locale")}))
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Error updating locale", "error", localeErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.update_locale_failed"))
+		return
+	}
+
+	// A resposta já sai no idioma escolhido
+	c.Set(i18n.ContextKey, locale)
+	c.Header("Content-Language", locale)
+	c.JSON(http.StatusOK, gin.H{
+		"locale":  locale,
+		"message": i18n.Message(c, "message.locale_updated"),
+	})
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"log/slog"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/repository"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Me retorna o perfil do usuário autenticado. Substitui o "currentUsername" que as rotas
+// legadas embutiam nas respostas.
+// GET /v1/me
+func Me(c *gin.Context) {
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
+		return
+	}
+
+	name, username, iconBase64, infoErr := services.GetChatInfos(c.Request.Context(), userID)
+	if infoErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error retrieving user info", "error", infoErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.username_lookup_failed"))
+		return
+	}
+	locale, localeErr := repository.GetUserLocale(c.Request.Context(), userID)
+	if localeErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error retrieving user locale", "error", localeErr)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":         userID,
+		"username":   username,
+		"name":       name,
+		"iconBase64": iconBase64,
+		"locale":     locale,
+	})
+}
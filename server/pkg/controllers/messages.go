@@ -1,44 +1,35 @@
 package controllers
 
 import (
-	"fmt"
-	"log"
+	"log/slog"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/metrics"
 	"messenger-pigeon-app/pkg/repository"
 	"messenger-pigeon-app/pkg/services"
 	"messenger-pigeon-app/pkg/websockets"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gorilla/websocket"
 )
 
+// Messages lista as conversas do usuário com a última mensagem de cada uma.
 func Messages(c *gin.Context) {
-	userId, exists := c.Get("id")
-	if !exists {
-		log.Println("User ID not found in session")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in session"})
+	id := websockets.GetUserIDFromContext(c)
+	if id == 0 {
 		return
 	}
 
-	id, errId := strconv.Atoi(fmt.Sprintf("%v", userId))
-	if errId != nil {
-		log.Println("Error: ", errId)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
-		c.Abort()
+	chats, chatsErr := services.GetUserChats(c.Request.Context(), int64(id))
+	if chatsErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error in service layer", "error", chatsErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.chats_failed"))
 		return
 	}
 
-	chats, err := services.GetUserChats(int64(id))
-	if err != nil {
-		log.Println("Error in service layer:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	currentUsername, err := repository.GetUsernameByID(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user Username"})
+	currentUsername, usernameErr := repository.GetUsernameByID(c.Request.Context(), id)
+	if usernameErr != nil {
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.username_lookup_failed"))
 		return
 	}
 
@@ -49,25 +40,26 @@ func Messages(c *gin.Context) {
 }
 
 func WebSocketMessages(c *gin.Context) {
-	ws, err := websocket.Upgrade(c.Writer, c.Request, nil, 1024, 1024)
-	if err != nil {
-		log.Println("Error: ", err)
+	// O usuário é validado pelo WebSocketAuthMiddleware antes do upgrade
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
 		return
 	}
 
-	defer ws.Close()
-
-	userID := websockets.GetUserIDFromContext(c)
-	if userID == 0 {
+	ws, err := websockets.Upgrade(c.Writer, c.Request)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error upgrading websocket connection", "error", err)
 		return
 	}
 
-	// Registrar a conexão
-	websockets.UserConnectionsMessages[int64(userID)] = ws
+	defer ws.Close()
 
-	// Iniciar o controle de inatividade
-	go websockets.StartInactivityTimerMessages(ws, userID)
+	// Registrar a conexão
+	client := websockets.NewClient(c.Request.Context(), ws, int64(userID), websockets.GetSessionIDFromContext(c), i18n.FromContext(c))
+	websockets.UserConnectionsMessages.Register(client)
+	defer websockets.UserConnectionsMessages.Unregister(client)
+	defer metrics.TrackConnection(c.FullPath())()
 
 	// Iniciar o manuseio de mensagens
-	websockets.HandleMessages(ws, userID)
+	websockets.HandleMessages(client)
 }
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"log/slog"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Sessions lista as sessões ativas (dispositivos conectados) do usuário.
+func Sessions(c *gin.Context) {
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
+		return
+	}
+
+	sessions, listErr := services.ListSessions(c.Request.Context(), userID, websockets.GetSessionIDFromContext(c))
+	if listErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error listing sessions", "error", listErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.list_sessions_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession revoga uma sessão do usuário e fecha suas conexões WebSocket.
+func RevokeSession(c *gin.Context) {
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
+		return
+	}
+	sessionID := c.Param("id")
+
+	revoked, revokeErr := services.RevokeSession(c.Request.Context(), userID, sessionID)
+	if revokeErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error revoking session", "error", revokeErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.revoke_session_failed"))
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, i18n.Error(c, err.CodeSessionNotFound, "error.session_not_found"))
+		return
+	}
+	websockets.DisconnectSessions(userID, sessionID)
+
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Message(c, "message.session_revoked")})
+}
+
+// RevokeOtherSessions revoga todas as sessões do usuário, exceto a atual.
+func RevokeOtherSessions(c *gin.Context) {
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
+		return
+	}
+
+	revoked, revokeErr := services.RevokeOtherSessions(c.Request.Context(), userID, websockets.GetSessionIDFromContext(c))
+	if revokeErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error revoking sessions", "error", revokeErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.revoke_sessions_failed"))
+		return
+	}
+	websockets.DisconnectSessions(userID, revoked...)
+
+	c.JSON(http.StatusOK, gin.H{
+		"revoked": len(revoked),
+		"message": i18n.Message(c, "message.sessions_revoked"),
+	})
+}
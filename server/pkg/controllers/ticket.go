@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"log/slog"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebSocketTicket emite um ticket de uso único para autenticar o handshake do WebSocket
+// (ex.: /websocket/chat/:username?ticket=...).
+func WebSocketTicket(c *gin.Context) {
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
+		return
+	}
+
+	ticket, expiresAt, ticketErr := services.IssueWebSocketTicket(userID, websockets.GetSessionIDFromContext(c))
+	if ticketErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Error issuing websocket ticket", "error", ticketErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.ticket_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ticket":    ticket,
+		"expiresAt": expiresAt,
+	})
+}
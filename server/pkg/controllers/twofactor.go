@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorSetup gera o segredo TOTP e o otpauth URI para o aplicativo autenticador.
+// O 2FA só passa a valer depois da confirmação em TwoFactorConfirm.
+func TwoFactorSetup(c *gin.Context) {
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
+		return
+	}
+
+	setup, setupErr := services.BeginTwoFactorSetup(c.Request.Context(), userID)
+	if setupErr != nil {
+		if errors.Is(setupErr, services.ErrTwoFactorAlreadyEnabled) {
+			c.JSON(http.StatusConflict, i18n.Error(c, err.CodeTwoFactorEnabled, "error.two_factor_already_enabled"))
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Error starting two-factor setup", "error", setupErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.two_factor_setup_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// TwoFactorConfirm ativa o 2FA com um código do aplicativo e devolve os códigos de recuperação.
+func TwoFactorConfirm(c *gin.Context) {
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
+		return
+	}
+
+	var request model.TwoFactorCode
+	if bindErr := c.ShouldBind(&request); bindErr != nil {
+		c.JSON(http.StatusBadRequest, bindingError(c, bindErr))
+		return
+	}
+
+	codes, confirmErr := services.ConfirmTwoFactor(c.Request.Context(), userID, request.Code)
+	if confirmErr != nil {
+		switch {
+		case errors.Is(confirmErr, services.ErrInvalidTwoFactorCode):
+			c.JSON(http.StatusBadRequest, i18n.ErrorWithFields(c, err.CodeInvalidTwoFactorCode, "error.invalid_code", map[string]string{"code": i18n.Message(c, "error.invalid_code")}))
+		case errors.Is(confirmErr, services.ErrTwoFactorAlreadyEnabled):
+			c.JSON(http.StatusConflict, i18n.Error(c, err.CodeTwoFactorEnabled, "error.two_factor_already_enabled"))
+		case errors.Is(confirmErr, services.ErrTwoFactorNotPending):
+			c.JSON(http.StatusConflict, i18n.Error(c, err.CodeTwoFactorNotPending, "error.two_factor_not_pending"))
+		default:
+			slog.ErrorContext(c.Request.Context(), "Error confirming two-factor setup", "error", confirmErr)
+			c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.two_factor_enable_failed"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recoveryCodes": codes,
+		"message":       i18n.Message(c, "message.two_factor_enabled"),
+	})
+}
+
+// TwoFactorDisable desativa o 2FA, exigindo a senha e um código (TOTP ou de recuperação).
+func TwoFactorDisable(c *gin.Context) {
+	userID := websockets.GetUserIDFromContext(c)
+	if userID == 0 {
+		return
+	}
+
+	var request model.TwoFactorDisable
+	if bindErr := c.ShouldBind(&request); bindErr != nil {
+		c.JSON(http.StatusBadRequest, bindingError(c, bindErr))
+		return
+	}
+
+	disableErr := services.DisableTwoFactor(c.Request.Context(), userID, request.Password, request.Code, request.RecoveryCode)
+	if disableErr != nil {
+		switch {
+		case errors.Is(disableErr, services.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, i18n.ErrorWithFields(c, err.CodeInvalidCredentials, "error.invalid_password", map[string]string{"password": i18n.Message(c, "error.invalid_password")}))
+		case errors.Is(disableErr, services.ErrInvalidTwoFactorCode):
+			c.JSON(http.StatusUnauthorized, i18n.ErrorWithFields(c, err.CodeInvalidTwoFactorCode, "error.invalid_code", map[string]string{"code": i18n.Message(c, "error.invalid_code")}))
+		case errors.Is(disableErr, services.ErrTwoFactorNotEnabled):
+			c.JSON(http.StatusConflict, i18n.Error(c, err.CodeTwoFactorNotEnabled, "error.two_factor_not_enabled"))
+		default:
+			slog.ErrorContext(c.Request.Context(), "Error disabling two-factor", "error", disableErr)
+			c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.two_factor_disable_failed"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Message(c, "message.two_factor_disabled")})
+}
+
+// LoginTwoFactor conclui o login: troca o token parcial e o segundo fator pelos tokens da sessão.
+func LoginTwoFactor(c *gin.Context) {
+	var request model.TwoFactorLogin
+	if bindErr := c.ShouldBind(&request); bindErr != nil {
+		c.JSON(http.StatusBadRequest, bindingError(c, bindErr))
+		return
+	}
+
+	userID, tokenErr := services.ParseMFAToken(request.MFAToken)
+	if tokenErr != nil {
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, err.CodeInvalidMFAToken, "error.invalid_mfa_token"))
+		return
+	}
+
+	if verifyErr := services.VerifySecondFactor(c.Request.Context(), userID, request.Code, request.RecoveryCode); verifyErr != nil {
+		if errors.Is(verifyErr, services.ErrInvalidTwoFactorCode) || errors.Is(verifyErr, services.ErrTwoFactorNotEnabled) {
+			c.JSON(http.StatusUnauthorized, i18n.ErrorWithFields(c, err.CodeInvalidTwoFactorCode, "error.invalid_code", map[string]string{"code": i18n.Message(c, "error.invalid_code")}))
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Error verifying second factor", "error", verifyErr)
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, err.CodeInternal, "error.login_failed"))
+		return
+	}
+
+	startSession(c, userID, request.Device)
+}
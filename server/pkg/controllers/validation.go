@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"errors"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Usa o nome do campo JSON nas mensagens de validação
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "" || name == "-" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// bindingError converte o erro do binding no envelope de erro: erros de validação por campo
+// ou, se o corpo não pôde ser lido (JSON malformado, tipo errado), um erro de requisição inválida.
+// As mensagens seguem o idioma da requisição.
+func bindingError(c *gin.Context, bindErr error) err.ErrorResponse {
+	var verrs validator.ValidationErrors
+	if !errors.As(bindErr, &verrs) {
+		return i18n.Error(c, err.CodeBadRequest, "error.invalid_body")
+	}
+
+	locale := i18n.FromContext(c)
+	fields := make(map[string]string)
+	for _, fe := range verrs {
+		fields[fe.Field()] = validationMessage(locale, fe)
+	}
+	return i18n.ErrorWithFields(c, err.CodeValidation, "error.validation", fields)
+}
+
+func validationMessage(locale string, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_without":
+		return i18n.T(locale, "validation.required")
+	case "email":
+		return i18n.T(locale, "validation.email")
+	case "min":
+		return i18n.T(locale, "validation.min", fe.Param())
+	case "max":
+		return i18n.T(locale, "validation.max", fe.Param())
+	case "eqfield":
+		return i18n.T(locale, "validation.eqfield")
+	default:
+		return i18n.T(locale, "validation.rule", fe.Tag())
+	}
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/internal/testdb"
+	"messenger-pigeon-app/pkg/websockets"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// messageRouter expõe CreateNewMessage para a usuária 7, com o bruno (8) como destinatário.
+func messageRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, store := testdb.Open()
+	database.SetDB(db)
+	t.Cleanup(func() { database.SetDB(nil) })
+	store.Rows("SELECT id FROM user WHERE username", []string{"id"}, []driver.Value{int64(8)})
+	store.Handle("INSERT INTO user_message", func([]driver.Value) testdb.Result {
+		return testdb.Result{RowsAffected: 1, LastInsertID: 3}
+	})
+	websockets.Initialize()
+
+	router := gin.New()
+	router.Use(gin.CustomRecovery(Recovery))
+	router.POST("/v1/conversations/:id/messages", func(c *gin.Context) {
+		c.Set(i18n.ContextKey, "en")
+		c.Set("id", 7)
+	}, CreateNewMessage)
+	return router
+}
+
+func postMessage(router *gin.Engine, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/conversations/bruno/messages", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeError lê o envelope {"error": {"code", "message", "fields"}}, sem campos extras.
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) err.Error {
+	t.Helper()
+	var envelope map[string]json.RawMessage
+	if decodeErr := json.Unmarshal(rec.Body.Bytes(), &envelope); decodeErr != nil {
+		t.Fatalf("body %s is not JSON: %v", rec.Body, decodeErr)
+	}
+	if len(envelope) != 1 || envelope["error"] == nil {
+		t.Fatalf("body %s is not an error envelope", rec.Body)
+	}
+	var e err.Error
+	if decodeErr := json.Unmarshal(envelope["error"], &e); decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if e.Code == "" || e.Message == "" {
+		t.Fatalf("error %s has no code or message", envelope["error"])
+	}
+	return e
+}
+
+// A mensagem é aceita tanto em JSON quanto como formulário.
+func TestCreateNewMessageBodies(t *testing.T) {
+	router := messageRouter(t)
+	bodies := map[string]string{
+		"application/json":                  `{"content":"oi","clientMessageId":"c-1"}`,
+		"application/x-www-form-urlencoded": "content=oi&clientMessageId=c-1",
+	}
+	for contentType, body := range bodies {
+		rec := postMessage(router, contentType, body)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body %s", contentType, rec.Code, rec.Body)
+		}
+		var resp map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if resp["messageID"] != float64(3) || resp["clientMessageId"] != "c-1" {
+			t.Errorf("%s: response = %v", contentType, resp)
+		}
+	}
+}
+
+// Corpos inválidos respondem 400 com o envelope de erro, com erros por campo na validação.
+// Um conteúdo só com espaços respondia com pânico no mapa de erros nulo.
+func TestCreateNewMessageErrors(t *testing.T) {
+	router := messageRouter(t)
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		code        string
+		fields      map[string]string
+	}{
+		{"missing content json", "application/json", `{}`, err.CodeValidation, map[string]string{"content": "Values are missing!"}},
+		{"missing content form", "application/x-www-form-urlencoded", "", err.CodeValidation, map[string]string{"content": "Values are missing!"}},
+		{"blank content", "application/json", `{"content":"   "}`, err.CodeValidation, map[string]string{"content": "Values are missing!"}},
+		{"client id too long", "application/json", `{"content":"oi","clientMessageId":"` + strings.Repeat("x", 65) + `"}`, err.CodeValidation, map[string]string{"clientMessageId": "Must have at most 64 characters"}},
+		{"malformed json", "application/json", `{"content":`, err.CodeBadRequest, nil},
+		{"wrong type", "application/json", `{"content":7}`, err.CodeBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postMessage(router, tt.contentType, tt.body)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400 (body %s)", rec.Code, rec.Body)
+			}
+			e := decodeError(t, rec)
+			if e.Code != tt.code {
+				t.Errorf("code = %q, want %q", e.Code, tt.code)
+			}
+			if len(e.Fields) != len(tt.fields) {
+				t.Errorf("fields = %v, want %v", e.Fields, tt.fields)
+			}
+			for field, message := range tt.fields {
+				if e.Fields[field] != message {
+					t.Errorf("fields[%s] = %q, want %q", field, e.Fields[field], message)
+				}
+			}
+		})
+	}
+}
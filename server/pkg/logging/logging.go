@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"messenger-pigeon-app/config/env"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Valor gravado no lugar dos atributos sensíveis.
+const redacted = "[REDACTED]"
+
+// sensitiveKeys são os atributos omitidos dos logs com LOG_REDACT ativo: credenciais e o
+// conteúdo das mensagens e emails dos usuários.
+var sensitiveKeys = map[string]bool{
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"mfa_token":     true,
+	"ticket":        true,
+	"authorization": true,
+	"password":      true,
+	"secret":        true,
+	"recovery_code": true,
+	"content":       true,
+	"body":          true,
+}
+
+// Initialize troca o logger padrão (inclusive o do pacote log) pelo slog, configurado por
+// LOG_LEVEL ("debug", "info", "warn" ou "error"; padrão info), LOG_FORMAT ("json", o padrão,
+// ou "text") e LOG_REDACT (padrão true; false grava tokens e conteúdo das mensagens, só para
+// desenvolvimento).
+func Initialize() {
+	slog.SetDefault(slog.New(NewHandler(os.Stderr)))
+}
+
+// NewHandler cria o handler configurado pelas variáveis de ambiente, escrevendo em w.
+func NewHandler(w io.Writer) slog.Handler {
+	var level slog.Level
+	if levelErr := level.UnmarshalText([]byte(env.String("LOG_LEVEL", "info"))); levelErr != nil {
+		level = slog.LevelInfo
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	if env.Bool("LOG_REDACT", true) {
+		options.ReplaceAttr = redact
+	}
+
+	var handler slog.Handler
+	if strings.EqualFold(env.String("LOG_FORMAT", "json"), "text") {
+		handler = slog.NewTextHandler(w, options)
+	} else {
+		handler = slog.NewJSONHandler(w, options)
+	}
+	return contextHandler{handler}
+}
+
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redacted)
+	}
+	return attr
+}
+
+// Fatal registra o erro e encerra o processo, como o log.Fatal.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID guarda o id da requisição no contexto, incluído nos logs feitos com ele.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID retorna o id da requisição guardado no contexto, ou vazio.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Tamanho máximo de um id de correlação recebido de fora.
+const maxIDLength = 128
+
+// ValidID informa se um id de correlação recebido do cliente ou do proxy pode ser usado: até
+// 128 caracteres entre [A-Za-z0-9._-].
+func ValidID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// NewID gera um id aleatório para requisições e conexões.
+func NewID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// contextHandler acrescenta aos registros feitos com contexto (slog.InfoContext etc.) o id da
+// requisição e o trace e o span atuais, para correlacionar os logs com os traces.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			record.AddAttrs(slog.String("request_id", id))
+		}
+		if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+			record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
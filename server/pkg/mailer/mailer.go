@@ -0,0 +1,96 @@
+package mailer
+
+import (
+	"fmt"
+	"log/slog"
+	"messenger-pigeon-app/config/env"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message é um email de texto simples.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer envia emails transacionais (verificação de email, redefinição de senha).
+type Mailer interface {
+	Send(msg Message) error
+}
+
+var defaultMailer Mailer = LogMailer{}
+
+// Default retorna o Mailer configurado em Initialize.
+func Default() Mailer {
+	return defaultMailer
+}
+
+// Initialize escolhe a implementação por MAILER: "smtp" usa SMTP_HOST, SMTP_PORT,
+// SMTP_USERNAME, SMTP_PASSWORD e MAIL_FROM; qualquer outro valor apenas registra os emails no log.
+func Initialize() {
+	if env.String("MAILER", "log") != "smtp" {
+		defaultMailer = LogMailer{}
+		return
+	}
+
+	defaultMailer = &SMTPMailer{
+		Host:     env.String("SMTP_HOST", "localhost"),
+		Port:     env.Int("SMTP_PORT", 25),
+		Username: env.String("SMTP_USERNAME", ""),
+		Password: env.String("SMTP_PASSWORD", ""),
+		From:     env.String("MAIL_FROM", "no-reply@localhost"),
+	}
+}
+
+// LogMailer registra os emails no log em vez de enviá-los. Usado em desenvolvimento; o corpo,
+// que traz os links com tokens, só aparece com LOG_REDACT=false.
+type LogMailer struct{}
+
+func (LogMailer) Send(msg Message) error {
+	slog.Info("Email", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// SMTPMailer envia emails por um servidor SMTP. O STARTTLS é usado quando o servidor o
+// anuncia, e a autenticação só é feita quando Username está definido.
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	if err := smtp.SendMail(addr, auth, m.From, []string{msg.To}, m.format(msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+func (m *SMTPMailer) format(msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + m.From + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	// Cabeçalhos só aceitam ASCII: assuntos traduzidos vão codificados (RFC 2047)
+	b.WriteString("Subject: " + mime.QEncoding.Encode("UTF-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
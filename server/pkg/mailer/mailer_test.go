@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"messenger-pigeon-app/internal/smtptest"
+	"mime"
+	"strings"
+	"testing"
+)
+
+// O SMTPMailer entrega ao servidor configurado, com o assunto traduzido codificado.
+func TestSMTPMailerSend(t *testing.T) {
+	server := smtptest.Start(t)
+	t.Setenv("MAILER", "smtp")
+	t.Setenv("SMTP_HOST", server.Host)
+	t.Setenv("SMTP_PORT", server.Port)
+	t.Setenv("MAIL_FROM", "pigeon@example.com")
+	Initialize()
+	t.Cleanup(func() { defaultMailer = LogMailer{} })
+
+	err := Default().Send(Message{To: "ana@example.com", Subject: "Confirme seu endereço de email", Body: "linha 1\nlinha 2"})
+	if err != nil {
+		t.Fatalf("Send(): %v", err)
+	}
+
+	received := server.Received()
+	if len(received) != 1 {
+		t.Fatalf("received %d emails, want 1", len(received))
+	}
+	email := received[0]
+	if email.From != "pigeon@example.com" || strings.Join(email.To, ",") != "ana@example.com" {
+		t.Fatalf("envelope = %s -> %v", email.From, email.To)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(email.Message.Header.Get("Subject"))
+	if err != nil || subject != "Confirme seu endereço de email" {
+		t.Fatalf("Subject = %q (%v)", subject, err)
+	}
+	if strings.TrimSuffix(email.Body, "\r\n") != "linha 1\r\nlinha 2" {
+		t.Fatalf("Body = %q", email.Body)
+	}
+}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pigeon"
+
+// Rota usada nas requisições que não casaram com nenhuma rota, para não criar uma série por caminho.
+const unmatchedRoute = "unmatched"
+
+var (
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	connections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "realtime",
+		Name:      "connections",
+		Help:      "Live realtime connections (websocket, SSE, long-poll and gRPC streams) by endpoint.",
+	}, []string{"endpoint"})
+
+	queueDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "worker_pool",
+		Name:      "dropped_total",
+		Help:      "Deliveries dropped because the worker pool queue was full.",
+	}, []string{"channel"})
+
+	messagesSent = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "messages",
+		Name:      "sent_total",
+		Help:      "Messages saved and submitted for delivery.",
+	})
+
+	messagesDelivered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "messages",
+		Name:      "delivered_total",
+		Help:      "Messages handed to at least one live connection of the recipient.",
+	}, []string{"channel"})
+
+	deliveryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "messages",
+		Name:      "delivery_duration_seconds",
+		Help:      "Time from enqueueing a delivery in the worker pool to handing it to the connections.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"channel"})
+)
+
+// Handler responde às coletas do Prometheus. A compressão fica com o middleware gzip do servidor.
+func Handler() http.Handler {
+	return promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{DisableCompression: true}))
+}
+
+// ObserveRequest registra a duração de uma requisição HTTP. route é o padrão da rota
+// (ex.: /v1/conversations/:id), vazio quando nenhuma rota casou.
+func ObserveRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = unmatchedRoute
+	}
+	requestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// TrackConnection conta uma conexão aberta no endpoint e retorna a função que a descarta,
+// para ser chamada com defer quando a conexão terminar.
+func TrackConnection(endpoint string) func() {
+	gauge := connections.WithLabelValues(endpoint)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+// RegisterQueue expõe o tamanho atual da fila de um pool de workers.
+func RegisterQueue(channel string, depth func() int) {
+	register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Subsystem:   "worker_pool",
+		Name:        "queue_depth",
+		Help:        "Deliveries waiting in the worker pool queue.",
+		ConstLabels: prometheus.Labels{"channel": channel},
+	}, func() float64 {
+		return float64(depth())
+	}))
+}
+
+// QueueDropped conta uma entrega descartada com a fila do pool cheia.
+func QueueDropped(channel string) {
+	queueDropped.WithLabelValues(channel).Inc()
+}
+
+// MessageSent conta uma mensagem salva e enviada para entrega.
+func MessageSent() {
+	messagesSent.Inc()
+}
+
+// MessageDispatched registra a entrega de uma mensagem: o tempo desde que entrou na fila e,
+// se alguma conexão do destinatário a recebeu, o contador de entregues.
+func MessageDispatched(channel string, enqueued time.Time, delivered bool) {
+	deliveryDuration.WithLabelValues(channel).Observe(time.Since(enqueued).Seconds())
+	if delivered {
+		messagesDelivered.WithLabelValues(channel).Inc()
+	}
+}
+
+// RegisterDB expõe as estatísticas do pool de conexões do banco (abertas, em uso, esperas...).
+func RegisterDB(name string, db *sql.DB) {
+	register(collectors.NewDBStatsCollector(db, name))
+}
+
+func register(collector prometheus.Collector) {
+	if registerErr := prometheus.Register(collector); registerErr != nil {
+		slog.Error("Error registering metrics collector", "error", registerErr)
+	}
+}
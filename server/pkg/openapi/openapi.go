@@ -0,0 +1,225 @@
+package openapi
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version é a versão da especificação OpenAPI gerada.
+const Version = "3.0.3"
+
+// Document é o documento OpenAPI servido em /openapi.json.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem agrupa as operações de um caminho por método HTTP.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+// Route descreve uma rota da tabela do gin para a documentação. Request e os valores de
+// Responses são exemplos dos tipos trafegados (ex.: model.UserLogin{}, Object{...}), dos
+// quais os schemas são gerados por reflexão.
+type Route struct {
+	Summary    string
+	Tags       []string
+	Auth       bool                // Exige o token de acesso
+	Deprecated string              // Rota que substitui esta, se ela for obsoleta
+	WebSocket  bool                // Handshake WebSocket, responde 101
+	Headers    []Parameter         // Cabeçalhos aceitos além dos de autenticação
+	Request    interface{}         // Corpo aceito em JSON ou formulário
+	Responses  map[int]interface{} // Corpo por status de sucesso
+}
+
+// Build gera o documento a partir da tabela de rotas do gin. Rotas sem descrição em docs
+// entram com uma operação genérica, para que nenhuma rota fique de fora.
+func Build(info Info, routes gin.RoutesInfo, docs map[string]Route) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"cookieAuth": {Type: "apiKey", In: "cookie", Name: "token"},
+			},
+		},
+	}
+	schemas := newGenerator(doc.Components.Schemas)
+	errorSchema := schemas.schemaFor(errorResponseSample)
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	for _, info := range routes {
+		route, ok := docs[Key(info.Method, info.Path)]
+		if !ok {
+			slog.Warn("OpenAPI route has no documentation", "method", info.Method, "path", info.Path)
+			route = Route{Summary: info.Handler}
+		}
+
+		path, params := ConvertPath(info.Path)
+		item, exists := doc.Paths[path]
+		if !exists {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(info.Method)] = buildOperation(schemas, errorSchema, info, route, params)
+	}
+	return doc
+}
+
+// Key é a chave de uma rota em docs: método e caminho no formato do gin.
+func Key(method, path string) string {
+	return method + " " + path
+}
+
+func buildOperation(schemas *generator, errorSchema *Schema, info gin.RouteInfo, route Route, params []Parameter) *Operation {
+	op := &Operation{
+		OperationID: OperationID(info.Method, info.Path),
+		Summary:     route.Summary,
+		Tags:        route.Tags,
+		Deprecated:  route.Deprecated != "",
+		Parameters:  append(params, route.Headers...),
+		Responses:   make(map[string]*Response),
+	}
+	if route.Auth {
+		op.Security = []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}}
+	}
+
+	if route.Request != nil {
+		schema := schemas.schemaFor(route.Request)
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				"application/json":                  {Schema: schema},
+				"application/x-www-form-urlencoded": {Schema: schema},
+			},
+		}
+	}
+
+	var headers map[string]*Header
+	if route.Deprecated != "" {
+		headers = map[string]*Header{
+			"Deprecation": {Description: "Data em que a rota se tornou obsoleta (RFC 9745)", Schema: &Schema{Type: "string"}},
+			"Link":        {Description: "Rota substituta: " + route.Deprecated, Schema: &Schema{Type: "string"}},
+		}
+	}
+
+	if route.WebSocket {
+		op.Responses["101"] = &Response{Description: "Switching Protocols", Headers: headers}
+	}
+	for status, sample := range route.Responses {
+		response := &Response{Description: http.StatusText(status), Headers: headers}
+		if sample != nil {
+			response.Content = map[string]*MediaType{"application/json": {Schema: schemas.schemaFor(sample)}}
+		}
+		op.Responses[fmt.Sprint(status)] = response
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["200"] = &Response{Description: http.StatusText(http.StatusOK), Headers: headers}
+	}
+	op.Responses["default"] = &Response{
+		Description: "Erro no envelope {error: {code, message, fields}}",
+		Headers:     headers,
+		Content:     map[string]*MediaType{"application/json": {Schema: errorSchema}},
+	}
+	return op
+}
+
+// ConvertPath troca os parâmetros do gin (":id", "*path") pelo formato do OpenAPI ("{id}").
+func ConvertPath(path string) (string, []Parameter) {
+	var params []Parameter
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// OperationID gera um id estável a partir do método e do caminho (ex.: getV1ConversationsIdMessages).
+func OperationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,248 @@
+package openapi
+
+import (
+	"messenger-pigeon-app/internal/err"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema é o subconjunto do JSON Schema usado pelo OpenAPI 3.0.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+}
+
+// Object descreve um objeto JSON montado na hora (ex.: gin.H) pelos exemplos dos seus campos.
+type Object map[string]interface{}
+
+// Optional marca um campo de Object que pode faltar na resposta.
+type Optional struct {
+	Sample interface{}
+}
+
+// OneOf descreve um corpo que pode ter um entre vários formatos.
+type OneOf []interface{}
+
+var errorResponseSample = err.ErrorResponse{}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// generator gera os schemas por reflexão. Structs nomeadas viram componentes referenciados
+// por $ref; os nomes dos campos vêm da tag json e as restrições, da tag binding. Ponteiros,
+// slices e mapas são nullable, pois o encoding/json codifica o valor nil como null.
+type generator struct {
+	components map[string]*Schema
+}
+
+// SchemaFor gera o schema de um exemplo (struct, Object, OneOf...), registrando as structs
+// nomeadas em components. Usado também pelo documento AsyncAPI.
+func SchemaFor(components map[string]*Schema, sample interface{}) *Schema {
+	return newGenerator(components).schemaFor(sample)
+}
+
+func newGenerator(components map[string]*Schema) *generator {
+	return &generator{components: components}
+}
+
+func (g *generator) schemaFor(sample interface{}) *Schema {
+	switch value := sample.(type) {
+	case *Schema:
+		return value
+	case Object:
+		return g.object(value)
+	case OneOf:
+		schema := &Schema{}
+		for _, option := range value {
+			schema.OneOf = append(schema.OneOf, g.schemaFor(option))
+		}
+		return schema
+	}
+	return g.typeSchema(reflect.TypeOf(sample))
+}
+
+func (g *generator) object(fields Object) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for name, sample := range fields {
+		if optional, ok := sample.(Optional); ok {
+			schema.Properties[name] = g.schemaFor(optional.Sample)
+			continue
+		}
+		schema.Properties[name] = g.schemaFor(sample)
+		schema.Required = append(schema.Required, name)
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func (g *generator) typeSchema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	if t.Kind() == reflect.Ptr {
+		return nullable(g.typeSchema(indirect(t)))
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		// encoding/json codifica []byte em base64
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte", Nullable: t.Kind() == reflect.Slice}
+		}
+		return &Schema{Type: "array", Items: g.typeSchema(t.Elem()), Nullable: t.Kind() == reflect.Slice}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem()), Nullable: true}
+	case reflect.Struct:
+		return g.structSchema(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	name := t.Name()
+	if name != "" {
+		if _, exists := g.components[name]; !exists {
+			// Registra antes de descer nos campos, para suportar tipos recursivos
+			g.components[name] = &Schema{}
+			*g.components[name] = *g.fields(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return g.fields(t)
+}
+
+func (g *generator) fields(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // campo não exportado
+		}
+
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		// Structs embutidas sem nome na tag têm os campos promovidos
+		if field.Anonymous && name == "" {
+			embedded := g.fields(indirect(field.Type))
+			for key, value := range embedded.Properties {
+				schema.Properties[key] = value
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.typeSchema(field.Type)
+		if omitEmpty(field) {
+			// Com omitempty o valor nil some do JSON em vez de virar null
+			property = notNullable(property)
+		}
+		if applyBinding(property, field.Tag.Get("binding")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+// jsonName retorna o nome do campo na tag json (vazio se a tag não define um).
+func jsonName(field reflect.StructField) string {
+	return strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+}
+
+func omitEmpty(field reflect.StructField) bool {
+	_, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+	for _, option := range strings.Split(options, ",") {
+		if option == "omitempty" {
+			return true
+		}
+	}
+	return false
+}
+
+// nullable aceita null no schema. Irmãos de $ref são ignorados no OpenAPI 3.0, então a
+// referência vai dentro de um allOf.
+func nullable(schema *Schema) *Schema {
+	if schema.Ref != "" {
+		return &Schema{AllOf: []*Schema{schema}, Nullable: true}
+	}
+	schema.Nullable = true
+	return schema
+}
+
+func notNullable(schema *Schema) *Schema {
+	if len(schema.AllOf) == 1 && schema.Nullable {
+		return schema.AllOf[0]
+	}
+	schema.Nullable = false
+	return schema
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// applyBinding traduz as regras do validator para o schema e retorna se o campo é obrigatório.
+func applyBinding(schema *Schema, binding string) bool {
+	required := false
+	for _, rule := range strings.Split(binding, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "min", "max":
+			n, convErr := strconv.Atoi(param)
+			if convErr != nil || schema.Type != "string" {
+				continue
+			}
+			if name == "min" {
+				schema.MinLength = &n
+			} else {
+				schema.MaxLength = &n
+			}
+		case "required_without":
+			schema.Description = "Obrigatório sem " + param
+		case "eqfield":
+			schema.Description = "Deve ser igual a " + param
+		}
+	}
+	return required
+}
@@ -0,0 +1,184 @@
+package openapi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ResponseSchema retorna o schema JSON da resposta da operação (caminho no formato do
+// OpenAPI, ex.: "/v1/conversations/{id}") para o status, ou o da resposta "default".
+func (d *Document) ResponseSchema(method, path string, status int) (*Schema, error) {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil, fmt.Errorf("path %s is not documented", path)
+	}
+	op, ok := (*item)[strings.ToLower(method)]
+	if !ok {
+		return nil, fmt.Errorf("%s %s is not documented", method, path)
+	}
+
+	response, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		if status < http.StatusBadRequest {
+			return nil, fmt.Errorf("%s %s has no %d response", method, path, status)
+		}
+		response = op.Responses["default"]
+	}
+	if response == nil || response.Content["application/json"] == nil {
+		return nil, fmt.Errorf("%s %s has no JSON body for %d", method, path, status)
+	}
+	return response.Content["application/json"].Schema, nil
+}
+
+// Validate verifica se um valor decodificado pelo encoding/json (map[string]interface{},
+// []interface{}, float64...) segue o schema. Propriedades que o schema não declara também
+// são erro, para que campos novos não passem sem documentação.
+func (d *Document) Validate(schema *Schema, value interface{}) error {
+	return d.validate(schema, value, "$")
+}
+
+func (d *Document) validate(schema *Schema, value interface{}, path string) error {
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		target, ok := d.Components.Schemas[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown schema %s", path, schema.Ref)
+		}
+		return d.validate(target, value, path)
+	}
+
+	if value == nil {
+		if schema.Nullable || isAny(schema) {
+			return nil
+		}
+		return fmt.Errorf("%s: null is not allowed", path)
+	}
+
+	for _, part := range schema.AllOf {
+		if err := d.validate(part, value, path); err != nil {
+			return err
+		}
+	}
+	if len(schema.OneOf) > 0 {
+		matches := 0
+		for _, option := range schema.OneOf {
+			if d.validate(option, value, path) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fmt.Errorf("%s: matches %d of the oneOf schemas, want 1", path, matches)
+		}
+	}
+
+	switch schema.Type {
+	case "string":
+		return validateString(schema, value, path)
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			return fmt.Errorf("%s: %v is not an integer", path, value)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: %v is not a number", path, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: %v is not a boolean", path, value)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: %v is not an array", path, value)
+		}
+		if schema.Items == nil {
+			return nil
+		}
+		for i, item := range items {
+			if err := d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		return d.validateObject(schema, value, path)
+	}
+	return nil
+}
+
+func (d *Document) validateObject(schema *Schema, value interface{}, path string) error {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s: %v is not an object", path, value)
+	}
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			return fmt.Errorf("%s: missing required property %q", path, name)
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := schema.Properties[name]
+		switch {
+		case ok:
+		case schema.AdditionalProperties != nil:
+			property = schema.AdditionalProperties
+		case len(schema.Properties) == 0:
+			continue // Objeto livre, ex.: Object{}
+		default:
+			return fmt.Errorf("%s: undocumented property %q", path, name)
+		}
+		if err := d.validate(property, object[name], path+"."+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateString(schema *Schema, value interface{}, path string) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("%s: %v is not a string", path, value)
+	}
+	switch schema.Format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return fmt.Errorf("%s: %q is not a date-time", path, s)
+		}
+	case "byte":
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			return fmt.Errorf("%s: %q is not base64", path, s)
+		}
+	}
+	if schema.MinLength != nil && utf8.RuneCountInString(s) < *schema.MinLength {
+		return fmt.Errorf("%s: %q is shorter than %d", path, s, *schema.MinLength)
+	}
+	if schema.MaxLength != nil && utf8.RuneCountInString(s) > *schema.MaxLength {
+		return fmt.Errorf("%s: %q is longer than %d", path, s, *schema.MaxLength)
+	}
+	if len(schema.Enum) > 0 {
+		for _, option := range schema.Enum {
+			if s == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %q is not one of %v", path, s, schema.Enum)
+	}
+	return nil
+}
+
+// isAny indica um schema sem restrições ({}), que aceita qualquer valor.
+func isAny(schema *Schema) bool {
+	return schema.Type == "" && schema.Ref == "" && len(schema.OneOf) == 0 && len(schema.AllOf) == 0
+}
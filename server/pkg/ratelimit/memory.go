@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Buckets sem uso por esse tempo são descartados.
+const memoryBucketTTL = 10 * time.Minute
+
+// MemoryStore guarda os buckets na memória do processo. Cada réplica tem seus próprios limites.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewMemoryStore cria o store e inicia a limpeza periódica dos buckets ociosos.
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{buckets: make(map[string]*memoryBucket)}
+	go s.cleanupLoop()
+	return s
+}
+
+func (s *MemoryStore) Take(key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = bucket
+	}
+
+	tokens, allowed, retryAfter := refill(bucket.tokens, bucket.updated, now, limit)
+	bucket.tokens, bucket.updated = tokens, now
+	return allowed, retryAfter, nil
+}
+
+func (s *MemoryStore) cleanupLoop() {
+	ticker := time.NewTicker(memoryBucketTTL)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		s.mu.Lock()
+		for key, bucket := range s.buckets {
+			if now.Sub(bucket.updated) > memoryBucketTTL {
+				delete(s.buckets, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,164 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"messenger-pigeon-app/config/env"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Classes de rota com limites próprios.
+const (
+	ClassAuth    = "auth"     // Login, cadastro, 2FA e recuperação de conta
+	ClassSend    = "send"     // Envio de mensagens
+	ClassHistory = "history"  // Leitura de conversas e histórico
+	ClassWSFrame = "ws_frame" // Frames recebidos pelos WebSockets
+)
+
+// Limit é um token bucket: Burst requisições de uma vez, repostas à taxa de Burst por Period.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// Rate retorna quantos tokens são repostos por segundo.
+func (l Limit) Rate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+// Disabled indica que o limite não deve ser aplicado.
+func (l Limit) Disabled() bool {
+	return l.Burst <= 0 || l.Period <= 0
+}
+
+// ClassLimits são os limites de uma classe de rota por usuário e por IP.
+type ClassLimits struct {
+	PerUser Limit
+	PerIP   Limit
+}
+
+var defaultLimits = map[string]ClassLimits{
+	ClassAuth:    {PerUser: Limit{10, time.Minute}, PerIP: Limit{20, time.Minute}},
+	ClassSend:    {PerUser: Limit{60, time.Minute}, PerIP: Limit{120, time.Minute}},
+	ClassHistory: {PerUser: Limit{120, time.Minute}, PerIP: Limit{240, time.Minute}},
+	ClassWSFrame: {PerUser: Limit{30, 10 * time.Second}}, // Frames são limitados por usuário, somando todas as conexões
+}
+
+// Store guarda os buckets. Take consome um token da chave e, se não houver, informa
+// quanto tempo falta para o próximo.
+type Store interface {
+	Take(key string, limit Limit, now time.Time) (bool, time.Duration, error)
+}
+
+// Limiter aplica os limites de cada classe sobre um Store.
+type Limiter struct {
+	store  Store
+	limits map[string]ClassLimits
+}
+
+var defaultLimiter = &Limiter{store: NewMemoryStore(), limits: defaultLimits}
+
+// Default retorna o Limiter configurado em Initialize.
+func Default() *Limiter {
+	return defaultLimiter
+}
+
+// Initialize escolhe o Store por RATE_LIMIT_STORE ("memory", padrão, ou "sql" para compartilhar
+// os limites entre réplicas pelo banco) e lê os limites RATE_LIMIT_<CLASSE>_USER e
+// RATE_LIMIT_<CLASSE>_IP no formato "<quantidade>/<período>", ex.: "60/1m". "0/1m" desativa.
+func Initialize(db *sql.DB) {
+	var store Store = NewMemoryStore()
+	if env.String("RATE_LIMIT_STORE", "memory") == "sql" {
+		store = NewSQLStore(db)
+	}
+
+	limits := make(map[string]ClassLimits, len(defaultLimits))
+	for class, def := range defaultLimits {
+		prefix := "RATE_LIMIT_" + strings.ToUpper(class)
+		limits[class] = ClassLimits{
+			PerUser: limitFromEnv(prefix+"_USER", def.PerUser),
+			PerIP:   limitFromEnv(prefix+"_IP", def.PerIP),
+		}
+	}
+
+	defaultLimiter = &Limiter{store: store, limits: limits}
+}
+
+func limitFromEnv(key string, def Limit) Limit {
+	value := env.String(key, "")
+	if value == "" {
+		return def
+	}
+
+	count, period, ok := strings.Cut(value, "/")
+	burst, err := strconv.Atoi(strings.TrimSpace(count))
+	if !ok || err != nil {
+		slog.Warn("Invalid rate limit, using default", "variable", key, "value", value)
+		return def
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil {
+		slog.Warn("Invalid rate limit, using default", "variable", key, "value", value)
+		return def
+	}
+	return Limit{Burst: burst, Period: duration}
+}
+
+// Allow verifica os limites da classe para o IP (se informado) e para o usuário (se userID > 0).
+// O IP vem primeiro para que requisições barradas por ele não gastem os tokens do usuário,
+// que ele poderia usar de outra rede. Em caso de falha do Store, a requisição é liberada.
+func (l *Limiter) Allow(class string, userID int, ip string) (bool, time.Duration) {
+	limits, ok := l.limits[class]
+	if !ok {
+		return true, 0
+	}
+
+	if ip != "" {
+		if allowed, retryAfter := l.take(class+":ip:"+ip, limits.PerIP); !allowed {
+			return false, retryAfter
+		}
+	}
+	if userID > 0 {
+		if allowed, retryAfter := l.take(class+":user:"+strconv.Itoa(userID), limits.PerUser); !allowed {
+			return false, retryAfter
+		}
+	}
+	return true, 0
+}
+
+func (l *Limiter) take(key string, limit Limit) (bool, time.Duration) {
+	if limit.Disabled() {
+		return true, 0
+	}
+
+	allowed, retryAfter, err := l.store.Take(key, limit, time.Now())
+	if err != nil {
+		slog.Error("Rate limit store error", "key", key, "error", err)
+		return true, 0
+	}
+	return allowed, retryAfter
+}
+
+// refill calcula o bucket após o tempo decorrido e tenta consumir um token.
+func refill(tokens float64, updated, now time.Time, limit Limit) (float64, bool, time.Duration) {
+	elapsed := now.Sub(updated).Seconds()
+	if elapsed > 0 {
+		tokens = math.Min(float64(limit.Burst), tokens+elapsed*limit.Rate())
+	}
+
+	if tokens >= 1 {
+		return tokens - 1, true, 0
+	}
+
+	wait := time.Duration((1 - tokens) / limit.Rate() * float64(time.Second))
+	return tokens, false, wait
+}
+
+// RetryAfterSeconds arredonda a espera para cima, no formato do cabeçalho Retry-After.
+func RetryAfterSeconds(d time.Duration) string {
+	return fmt.Sprint(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,33 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// Uma requisição barrada pelo limite do IP não consome o token do usuário.
+func TestLimiterAllowChecksIPFirst(t *testing.T) {
+	limiter := &Limiter{
+		store:  NewMemoryStore(),
+		limits: map[string]ClassLimits{ClassSend: {PerUser: Limit{2, time.Hour}, PerIP: Limit{1, time.Hour}}},
+	}
+
+	steps := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.0.0.1", true},  // Consome o IP e um dos dois tokens do usuário
+		{"10.0.0.1", false}, // Barrada pelo IP, sem tocar no usuário
+		{"10.0.0.2", true},  // O usuário ainda tem um token
+		{"10.0.0.3", false}, // Agora o usuário esgotou
+	}
+	for i, step := range steps {
+		allowed, retryAfter := limiter.Allow(ClassSend, 7, step.ip)
+		if allowed != step.want {
+			t.Fatalf("request %d from %s: allowed = %v, want %v", i+1, step.ip, allowed, step.want)
+		}
+		if !allowed && retryAfter <= 0 {
+			t.Fatalf("request %d: retryAfter = %v, want > 0", i+1, retryAfter)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Buckets sem uso por esse tempo são apagados da tabela.
+const sqlBucketTTL = time.Hour
+
+// SQLStore guarda os buckets na tabela rate_limit_bucket, para que todas as réplicas
+// compartilhem os mesmos limites. Cada Take bloqueia apenas a linha da chave.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore cria o store e inicia a limpeza periódica dos buckets ociosos.
+func NewSQLStore(db *sql.DB) *SQLStore {
+	s := &SQLStore{db: db}
+	go s.cleanupLoop()
+	return s
+}
+
+func (s *SQLStore) Take(key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Garante que a linha exista antes de bloqueá-la
+	_, err = tx.Exec("INSERT IGNORE INTO rate_limit_bucket(bucket_key, tokens, updated_at) VALUES (?, ?, ?)",
+		key, float64(limit.Burst), now.UnixMilli())
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to insert bucket: %w", err)
+	}
+
+	var tokens float64
+	var updatedAt int64
+	err = tx.QueryRow("SELECT tokens, updated_at FROM rate_limit_bucket WHERE bucket_key = ? FOR UPDATE", key).Scan(&tokens, &updatedAt)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to query bucket: %w", err)
+	}
+
+	tokens, allowed, retryAfter := refill(tokens, time.UnixMilli(updatedAt), now, limit)
+
+	_, err = tx.Exec("UPDATE rate_limit_bucket SET tokens = ?, updated_at = ? WHERE bucket_key = ?", tokens, now.UnixMilli(), key)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to update bucket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, 0, fmt.Errorf("failed to commit bucket: %w", err)
+	}
+	return allowed, retryAfter, nil
+}
+
+func (s *SQLStore) cleanupLoop() {
+	ticker := time.NewTicker(sqlBucketTTL)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		_, err := s.db.Exec("DELETE FROM rate_limit_bucket WHERE updated_at < ?", now.Add(-sqlBucketTTL).UnixMilli())
+		if err != nil {
+			slog.Error("Error cleaning up rate limit buckets", "error", err)
+		}
+	}
+}
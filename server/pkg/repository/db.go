@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"messenger-pigeon-app/pkg/tracing"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// querier é satisfeito pelo *sql.DB e pelo *sql.Tx, para que os comandos dentro de uma
+// transação tenham o mesmo span dos executados direto no pool.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// startSpan abre o span de um comando SQL. statement identifica o comando no trace
+// (ex.: "SaveMessage" ou "EnableTOTP.delete_codes") sem expor os valores dos parâmetros.
+func startSpan(ctx context.Context, statement, query string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, statement,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemMySQL,
+			semconv.DBStatement(query),
+		),
+	)
+}
+
+// exec executa um comando sem retorno de linhas dentro do span do statement.
+func exec(ctx context.Context, conn querier, statement, query string, args ...any) (sql.Result, error) {
+	ctx, span := startSpan(ctx, statement, query)
+	defer span.End()
+
+	result, err := conn.ExecContext(ctx, query, args...)
+	return result, tracing.Fail(span, err)
+}
+
+// query executa uma consulta de várias linhas. O span cobre a execução no banco; a leitura
+// das linhas fica com quem chamou.
+func query(ctx context.Context, conn querier, statement, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := startSpan(ctx, statement, query)
+	defer span.End()
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	return rows, tracing.Fail(span, err)
+}
+
+// queryRow executa uma consulta de uma linha e a lê em dest. sql.ErrNoRows não marca o span
+// como erro, já que é uma resposta esperada das buscas.
+func queryRow(ctx context.Context, conn querier, statement, query string, args []any, dest ...any) error {
+	ctx, span := startSpan(ctx, statement, query)
+	defer span.End()
+
+	err := conn.QueryRowContext(ctx, query, args...).Scan(dest...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	return tracing.Fail(span, err)
+}
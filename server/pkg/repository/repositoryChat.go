@@ -1,15 +1,19 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"messenger-pigeon-app/config/database"
 	"messenger-pigeon-app/internal/model"
+
+	"github.com/go-sql-driver/mysql"
 )
 
-func FetchUserChats(db *sql.DB, userID int64) ([]model.UserMessage, error) {
-	query := `
+func FetchUserChats(ctx context.Context, db *sql.DB, userID int64) ([]model.UserMessage, error) {
+	statement := `
     SELECT 
         user.id AS user_id, user.username, user.name, user.icon, user_message.content, 
 		user_message.created_at
@@ -30,7 +34,7 @@ func FetchUserChats(db *sql.DB, userID int64) ([]model.UserMessage, error) {
     ORDER BY user_message.created_at DESC
     `
 
-	rows, err := db.Query(query, userID, userID, userID)
+	rows, err := query(ctx, db, "FetchUserChats", statement, userID, userID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query statements: %w", err)
 	}
@@ -66,31 +70,57 @@ func FetchUserChats(db *sql.DB, userID int64) ([]model.UserMessage, error) {
 }
 
 // Obter informações de usuário por ID
-func GetUserInfo(userID int) (string, string, []byte, error) {
+func GetUserInfo(ctx context.Context, userID int) (string, string, []byte, error) {
 	db := database.GetDB()
 	var name string
 	var username string
 	var icon []byte
-	err := db.QueryRow("SELECT name, username, icon FROM user WHERE id = ?", userID).Scan(&name, &username, &icon)
+	err := queryRow(ctx, db, "GetUserInfo", "SELECT name, username, icon FROM user WHERE id = ?", []any{userID}, &name, &username, &icon)
 	if err != nil {
 		return "", "", nil, fmt.Errorf("failed to query user info: %w", err)
 	}
 	return name, username, icon, nil
 }
 
-// Salvar nova mensagem
-func SaveMessage(message model.UserMessage) (int64, error) {
+// ErrDuplicateClientMessage indica que o remetente já salvou uma mensagem com o mesmo clientMessageId.
+var ErrDuplicateClientMessage = errors.New("duplicate client message id")
+
+// Código do MySQL para violação de chave única
+const mysqlDuplicateEntry = 1062
+
+// Salvar nova mensagem. Com ClientMessageID, a chave única (messageBy, client_message_id)
+// impede que reenvios do mesmo cliente gravem a mensagem de novo.
+func SaveMessage(ctx context.Context, message model.UserMessage) (int64, error) {
 	db := database.GetDB()
-	stmt, err := db.Prepare("INSERT INTO user_message(content, messageBy, messageTo, created_at) VALUES (?, ?, ?, NOW())")
-	if err != nil {
-		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	var clientMessageID sql.NullString
+	if message.ClientMessageID != "" {
+		clientMessageID = sql.NullString{String: message.ClientMessageID, Valid: true}
 	}
-	defer stmt.Close()
 
-	result, err := stmt.Exec(message.Content, message.MessageBy, message.MessageTo)
+	result, err := exec(ctx, db, "SaveMessage",
+		"INSERT INTO user_message(content, messageBy, messageTo, created_at, client_message_id) VALUES (?, ?, ?, NOW(), ?)",
+		message.Content, message.MessageBy, message.MessageTo, clientMessageID)
 	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return 0, ErrDuplicateClientMessage
+		}
 		return 0, fmt.Errorf("failed to execute statement: %w", err)
 	}
 
 	return result.LastInsertId()
 }
+
+// Obter a mensagem já salva pelo remetente com o clientMessageId informado
+func GetMessageByClientID(ctx context.Context, senderID int, clientMessageID string) (model.UserMessage, error) {
+	db := database.GetDB()
+	message := model.UserMessage{MessageBy: senderID, ClientMessageID: clientMessageID}
+	err := queryRow(ctx, db, "GetMessageByClientID",
+		"SELECT message_id, messageTo, content, created_at FROM user_message WHERE messageBy = ? AND client_message_id = ?",
+		[]any{senderID, clientMessageID},
+		&message.MessageID, &message.MessageTo, &message.Content, &message.CreatedAt)
+	if err != nil {
+		return model.UserMessage{}, fmt.Errorf("failed to query message by client id: %w", err)
+	}
+	return message, nil
+}
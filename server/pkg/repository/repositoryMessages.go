@@ -1,46 +1,42 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"messenger-pigeon-app/config/database"
 	"messenger-pigeon-app/internal/model"
+	"time"
 )
 
-func MessageGetUserIDByUsername(username string) (int, error) {
+func MessageGetUserIDByUsername(ctx context.Context, username string) (int, error) {
 	db := database.GetDB()
 	var id int
-	err := db.QueryRow("SELECT id FROM user WHERE username = ?", username).Scan(&id)
+	err := queryRow(ctx, db, "MessageGetUserIDByUsername", "SELECT id FROM user WHERE username = ?", []any{username}, &id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return 0, errors.New("User not found")
+			return 0, ErrUserNotFound
 		}
-		log.Println("Error querying user ID:", err)
+		slog.ErrorContext(ctx, "Error querying user ID", "error", err)
 		return 0, err
 	}
 	return id, nil
 }
 
 // Obter mensagens entre usuários
-func GetUserMessages(user1ID, user2ID int) ([]model.UserMessage, error) {
+func GetUserMessages(ctx context.Context, user1ID, user2ID int) ([]model.UserMessage, error) {
 	db := database.GetDB()
-	stmt, err := db.Prepare(`
+	rows, err := query(ctx, db, "GetUserMessages", `
 		SELECT user_message.message_id, user_message.messageBy, user_message.content,
-		       user.id, user.username, user.name, user.icon, user_message.created_at
+		       user.id, user.username, user.name, user.icon, user_message.created_at,
+		       COALESCE(user_message.client_message_id, '')
 		FROM user_message
 		JOIN user ON user.id = user_message.messageBy
 		WHERE (user_message.messageBy = ? AND user_message.messageTo = ?) OR 
 		      (user_message.messageBy = ? AND user_message.messageTo = ?)
 		ORDER BY user_message.created_at ASC
-	`)
-	if err != nil {
-		return nil, fmt.Errorf("failed to prepare statement: %w", err)
-	}
-	defer stmt.Close()
-
-	rows, err := stmt.Query(user1ID, user2ID, user2ID, user1ID)
+	`, user1ID, user2ID, user2ID, user1ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
@@ -49,7 +45,7 @@ func GetUserMessages(user1ID, user2ID int) ([]model.UserMessage, error) {
 	var messages []model.UserMessage
 	for rows.Next() {
 		var message model.UserMessage
-		if err := rows.Scan(&message.MessageID, &message.MessageUserID, &message.Content, &message.UserID, &message.CreatedBy, &message.Name, &message.Icon, &message.CreatedAt); err != nil {
+		if err := rows.Scan(&message.MessageID, &message.MessageUserID, &message.Content, &message.UserID, &message.CreatedBy, &message.Name, &message.Icon, &message.CreatedAt, &message.ClientMessageID); err != nil {
 			return nil, fmt.Errorf("failed to scan rows: %w", err)
 		}
 		messages = append(messages, message)
@@ -57,13 +53,64 @@ func GetUserMessages(user1ID, user2ID int) ([]model.UserMessage, error) {
 	return messages, nil
 }
 
-func GetUsernameByID(userID int) (string, error) {
+func GetUsernameByID(ctx context.Context, userID int) (string, error) {
 	db := database.GetDB()
 	var username string
-	err := db.QueryRow("SELECT username FROM user WHERE id = ?", userID).Scan(&username)
+	err := queryRow(ctx, db, "GetUsernameByID", "SELECT username FROM user WHERE id = ?", []any{userID}, &username)
 	if err != nil {
-		log.Println("Erro ao consultar username:", err)
+		slog.ErrorContext(ctx, "Error querying username", "user_id", userID, "error", err)
 		return "", err
 	}
 	return username, nil
 }
+
+// Obter todas as mensagens entre dois usuários, em ordem, para exportação
+func ExportConversation(ctx context.Context, user1ID, user2ID int) ([]model.ExportedMessage, error) {
+	db := database.GetDB()
+	rows, err := query(ctx, db, "ExportConversation", `
+		SELECT user_message.message_id, sender.username, recipient.username, user_message.content,
+		       user_message.created_at, COALESCE(user_message.client_message_id, '')
+		FROM user_message
+		JOIN user sender ON sender.id = user_message.messageBy
+		JOIN user recipient ON recipient.id = user_message.messageTo
+		WHERE (user_message.messageBy = ? AND user_message.messageTo = ?) OR
+		      (user_message.messageBy = ? AND user_message.messageTo = ?)
+		ORDER BY user_message.created_at ASC, user_message.message_id ASC
+	`, user1ID, user2ID, user2ID, user1ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []model.ExportedMessage
+	for rows.Next() {
+		var message model.ExportedMessage
+		if err := rows.Scan(&message.MessageID, &message.From, &message.To, &message.Content, &message.CreatedAt, &message.ClientMessageID); err != nil {
+			return nil, fmt.Errorf("failed to scan rows: %w", err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+// Contar as mensagens com mais que a idade informada. A idade é medida pelo relógio do banco,
+// o mesmo que grava o created_at.
+func CountMessagesOlderThan(ctx context.Context, age time.Duration) (int64, error) {
+	db := database.GetDB()
+	var count int64
+	err := queryRow(ctx, db, "CountMessagesOlderThan", "SELECT COUNT(*) FROM user_message WHERE created_at < NOW() - INTERVAL ? SECOND", []any{int64(age.Seconds())}, &count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+	return count, nil
+}
+
+// Apagar até limit mensagens com mais que a idade informada. Retorna quantas foram apagadas.
+func DeleteMessagesOlderThan(ctx context.Context, age time.Duration, limit int) (int64, error) {
+	db := database.GetDB()
+	result, err := exec(ctx, db, "DeleteMessagesOlderThan", "DELETE FROM user_message WHERE created_at < NOW() - INTERVAL ? SECOND ORDER BY created_at LIMIT ?", int64(age.Seconds()), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete messages: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/internal/model"
+	"time"
+)
+
+// ErrSessionNotFound é retornado quando a sessão não existe, expirou ou foi revogada.
+var ErrSessionNotFound = errors.New("session not found")
+
+// Salvar nova sessão com o hash do refresh token
+func CreateSession(ctx context.Context, session model.Session, refreshTokenHash string, ttl time.Duration) error {
+	db := database.GetDB()
+	_, err := exec(ctx, db, "CreateSession", `
+		INSERT INTO user_session(id, user_id, refresh_token_hash, device, ip, created_at, last_used_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, NOW(), NOW(), NOW() + INTERVAL ? SECOND)`,
+		session.ID, session.UserID, refreshTokenHash, session.Device, session.IP, int64(ttl.Seconds()))
+	if err != nil {
+		return fmt.Errorf("failed to insert session: %w", err)
+	}
+	return nil
+}
+
+// Obter o dono e o hash do refresh token de uma sessão ativa
+func GetActiveSessionToken(ctx context.Context, sessionID string) (int, string, error) {
+	db := database.GetDB()
+	var userID int
+	var hash string
+	err := queryRow(ctx, db, "GetActiveSessionToken", `
+		SELECT user_id, refresh_token_hash FROM user_session
+		WHERE id = ? AND revoked_at IS NULL AND expires_at > NOW()`, []any{sessionID}, &userID, &hash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, "", ErrSessionNotFound
+		}
+		return 0, "", fmt.Errorf("failed to query session: %w", err)
+	}
+	return userID, hash, nil
+}
+
+// Substituir o refresh token da sessão, desde que o hash atual ainda seja o esperado. O hash
+// substituído é guardado para reconhecer o reuso do token antigo.
+func RotateSessionToken(ctx context.Context, sessionID, currentHash, newHash string, ttl time.Duration) error {
+	db := database.GetDB()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := exec(ctx, tx, "RotateSessionToken.update", `
+		UPDATE user_session
+		SET refresh_token_hash = ?, last_used_at = NOW(), expires_at = NOW() + INTERVAL ? SECOND
+		WHERE id = ? AND refresh_token_hash = ? AND revoked_at IS NULL`,
+		newHash, int64(ttl.Seconds()), sessionID, currentHash)
+	if err != nil {
+		return fmt.Errorf("failed to rotate session token: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrSessionNotFound
+	}
+
+	_, err = exec(ctx, tx, "RotateSessionToken.insert", `
+		INSERT INTO user_session_rotated_token(session_id, token_hash, rotated_at) VALUES (?, ?, NOW())`,
+		sessionID, currentHash)
+	if err != nil {
+		return fmt.Errorf("failed to save rotated session token: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Verificar se o hash é de um refresh token já substituído na sessão
+func IsRotatedSessionToken(ctx context.Context, sessionID, tokenHash string) (bool, error) {
+	db := database.GetDB()
+	var rotated bool
+	err := queryRow(ctx, db, "IsRotatedSessionToken", `
+		SELECT EXISTS(SELECT 1 FROM user_session_rotated_token
+		WHERE session_id = ? AND token_hash = ?)`, []any{sessionID, tokenHash}, &rotated)
+	if err != nil {
+		return false, fmt.Errorf("failed to query rotated session token: %w", err)
+	}
+	return rotated, nil
+}
+
+// Verificar se a sessão pertence ao usuário e ainda está ativa
+func IsSessionActive(ctx context.Context, sessionID string, userID int) (bool, error) {
+	db := database.GetDB()
+	var active bool
+	err := queryRow(ctx, db, "IsSessionActive", `
+		SELECT EXISTS(SELECT 1 FROM user_session
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > NOW())`, []any{sessionID, userID}, &active)
+	if err != nil {
+		return false, fmt.Errorf("failed to query session: %w", err)
+	}
+	return active, nil
+}
+
+// Listar as sessões ativas do usuário, da mais recente para a mais antiga
+func ListActiveSessions(ctx context.Context, userID int) ([]model.Session, error) {
+	db := database.GetDB()
+	rows, err := query(ctx, db, "ListActiveSessions", `
+		SELECT id, user_id, device, ip, created_at, last_used_at, expires_at FROM user_session
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []model.Session
+	for rows.Next() {
+		var session model.Session
+		if err := rows.Scan(&session.ID, &session.UserID, &session.Device, &session.IP, &session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// Revogar uma sessão do usuário
+func RevokeSession(ctx context.Context, userID int, sessionID string) (bool, error) {
+	db := database.GetDB()
+	result, err := exec(ctx, db, "RevokeSession", "UPDATE user_session SET revoked_at = NOW() WHERE id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke session: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// Revogar todas as sessões ativas do usuário, exceto a informada. Retorna os IDs revogados.
+func RevokeSessionsExcept(ctx context.Context, userID int, keepSessionID string) ([]string, error) {
+	db := database.GetDB()
+	rows, err := query(ctx, db, "RevokeSessionsExcept", "SELECT id FROM user_session WHERE user_id = ? AND id != ? AND revoked_at IS NULL", userID, keepSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if _, err := RevokeSession(ctx, userID, id); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
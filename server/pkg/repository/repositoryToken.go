@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"messenger-pigeon-app/config/database"
+	"time"
+)
+
+// ErrTokenNotFound é retornado quando o token não existe, expirou ou já foi usado.
+var ErrTokenNotFound = errors.New("token not found")
+
+// Salvar o hash de um token de uso único (verificação de email, redefinição de senha)
+func CreateUserToken(ctx context.Context, userID int, purpose, tokenHash string, ttl time.Duration) error {
+	db := database.GetDB()
+	_, err := exec(ctx, db, "CreateUserToken", `
+		INSERT INTO user_token(user_id, purpose, token_hash, created_at, expires_at)
+		VALUES (?, ?, ?, NOW(), NOW() + INTERVAL ? SECOND)`,
+		userID, purpose, tokenHash, int64(ttl.Seconds()))
+	if err != nil {
+		return fmt.Errorf("failed to insert token: %w", err)
+	}
+	return nil
+}
+
+// Marcar o token como usado e retornar o dono. Só funciona uma vez e antes da expiração.
+func ConsumeUserToken(ctx context.Context, purpose, tokenHash string) (int, error) {
+	db := database.GetDB()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = queryRow(ctx, tx, "ConsumeUserToken.select", `
+		SELECT user_id FROM user_token
+		WHERE token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > NOW()
+		FOR UPDATE`, []any{tokenHash, purpose}, &userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrTokenNotFound
+		}
+		return 0, fmt.Errorf("failed to query token: %w", err)
+	}
+
+	if _, err := exec(ctx, tx, "ConsumeUserToken.update", "UPDATE user_token SET used_at = NOW() WHERE token_hash = ?", tokenHash); err != nil {
+		return 0, fmt.Errorf("failed to consume token: %w", err)
+	}
+	return userID, tx.Commit()
+}
+
+// Invalidar os tokens ainda não usados do usuário para a finalidade informada
+func InvalidateUserTokens(ctx context.Context, userID int, purpose string) error {
+	db := database.GetDB()
+	_, err := exec(ctx, db, "InvalidateUserTokens", "UPDATE user_token SET used_at = NOW() WHERE user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate tokens: %w", err)
+	}
+	return nil
+}
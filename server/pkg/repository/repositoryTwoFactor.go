@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"messenger-pigeon-app/config/database"
+)
+
+// Obter o estado do TOTP do usuário. O segredo existe, com enabled falso, durante o cadastro.
+func GetTwoFactor(ctx context.Context, userID int) (string, bool, error) {
+	db := database.GetDB()
+	var secret sql.NullString
+	var enabled bool
+	err := queryRow(ctx, db, "GetTwoFactor", "SELECT totp_secret, totp_enabled FROM user WHERE id = ?", []any{userID}, &secret, &enabled)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, ErrUserNotFound
+		}
+		return "", false, fmt.Errorf("failed to query two-factor settings: %w", err)
+	}
+	return secret.String, enabled, nil
+}
+
+// Guardar o segredo pendente de confirmação
+func SetPendingTOTPSecret(ctx context.Context, userID int, secret string) error {
+	db := database.GetDB()
+	_, err := exec(ctx, db, "SetPendingTOTPSecret", "UPDATE user SET totp_secret = ?, totp_enabled = FALSE, totp_last_step = 0 WHERE id = ? AND totp_enabled = FALSE", secret, userID)
+	if err != nil {
+		return fmt.Errorf("failed to store totp secret: %w", err)
+	}
+	return nil
+}
+
+// Registrar o último intervalo TOTP aceito. Retorna false se ele já foi usado,
+// impedindo que o mesmo código seja aceito duas vezes.
+func ConsumeTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	db := database.GetDB()
+	result, err := exec(ctx, db, "ConsumeTOTPStep", "UPDATE user SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?", step, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to update totp step: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// Ativar o TOTP e substituir os códigos de recuperação
+func EnableTOTP(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	db := database.GetDB()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := exec(ctx, tx, "EnableTOTP.enable", "UPDATE user SET totp_enabled = TRUE WHERE id = ?", userID); err != nil {
+		return fmt.Errorf("failed to enable totp: %w", err)
+	}
+	if _, err := exec(ctx, tx, "EnableTOTP.delete_codes", "DELETE FROM user_recovery_code WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, hash := range recoveryCodeHashes {
+		if _, err := exec(ctx, tx, "EnableTOTP.insert_code", "INSERT INTO user_recovery_code(user_id, code_hash) VALUES (?, ?)", userID, hash); err != nil {
+			return fmt.Errorf("failed to insert recovery code: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Marcar um código de recuperação como usado. Retorna false se ele não existir ou já tiver sido usado.
+func UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	db := database.GetDB()
+	result, err := exec(ctx, db, "UseRecoveryCode", "UPDATE user_recovery_code SET used_at = NOW() WHERE user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// Desativar o TOTP, apagando o segredo e os códigos de recuperação
+func DisableTOTP(ctx context.Context, userID int) error {
+	db := database.GetDB()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := exec(ctx, tx, "DisableTOTP.disable", "UPDATE user SET totp_secret = NULL, totp_enabled = FALSE, totp_last_step = 0 WHERE id = ?", userID); err != nil {
+		return fmt.Errorf("failed to disable totp: %w", err)
+	}
+	if _, err := exec(ctx, tx, "DisableTOTP.delete_codes", "DELETE FROM user_recovery_code WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	return tx.Commit()
+}
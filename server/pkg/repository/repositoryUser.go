@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/internal/model"
+)
+
+// ErrUserNotFound é retornado quando nenhum usuário corresponde à busca.
+var ErrUserNotFound = errors.New("user not found")
+
+// Verifica se já existe um usuário com o username informado
+func UsernameExists(ctx context.Context, username string) (bool, error) {
+	db := database.GetDB()
+	var exists bool
+	err := queryRow(ctx, db, "UsernameExists", "SELECT EXISTS(SELECT 1 FROM user WHERE username = ?)", []any{username}, &exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to query username: %w", err)
+	}
+	return exists, nil
+}
+
+// Verifica se já existe um usuário com o email informado
+func EmailExists(ctx context.Context, email string) (bool, error) {
+	db := database.GetDB()
+	var exists bool
+	err := queryRow(ctx, db, "EmailExists", "SELECT EXISTS(SELECT 1 FROM user WHERE email = ?)", []any{email}, &exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to query email: %w", err)
+	}
+	return exists, nil
+}
+
+// Salvar novo usuário com a senha já convertida em hash
+func CreateUser(ctx context.Context, user model.User, passwordHash string) (int64, error) {
+	db := database.GetDB()
+	var locale sql.NullString
+	if user.Locale != "" {
+		locale = sql.NullString{String: user.Locale, Valid: true}
+	}
+
+	result, err := exec(ctx, db, "CreateUser",
+		"INSERT INTO user(username, name, bio, email, password, locale) VALUES (?, ?, ?, ?, ?, ?)",
+		user.Username, user.Name, user.Bio, user.Email, passwordHash, locale)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return result.LastInsertId()
+}
+
+// Obter ID e hash da senha pelo username ou email. Usuários desativados não são encontrados.
+func GetUserCredentials(ctx context.Context, login string) (int, string, error) {
+	db := database.GetDB()
+	var id int
+	var passwordHash string
+	err := queryRow(ctx, db, "GetUserCredentials", "SELECT id, password FROM user WHERE (username = ? OR email = ?) AND disabled_at IS NULL", []any{login, login}, &id, &passwordHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, "", ErrUserNotFound
+		}
+		return 0, "", fmt.Errorf("failed to query user credentials: %w", err)
+	}
+	return id, passwordHash, nil
+}
+
+// Obter o ID do usuário pelo username ou email, inclusive de usuários desativados
+func GetUserIDByLogin(ctx context.Context, login string) (int, error) {
+	db := database.GetDB()
+	var id int
+	err := queryRow(ctx, db, "GetUserIDByLogin", "SELECT id FROM user WHERE username = ? OR email = ?", []any{login, login}, &id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrUserNotFound
+		}
+		return 0, fmt.Errorf("failed to query user: %w", err)
+	}
+	return id, nil
+}
+
+// Desativar o usuário. Retorna false se ele já estava desativado.
+func DisableUser(ctx context.Context, userID int) (bool, error) {
+	db := database.GetDB()
+	result, err := exec(ctx, db, "DisableUser", "UPDATE user SET disabled_at = NOW() WHERE id = ? AND disabled_at IS NULL", userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to disable user: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// Obter o hash da senha pelo ID do usuário
+func GetPasswordHash(ctx context.Context, userID int) (string, error) {
+	db := database.GetDB()
+	var passwordHash string
+	err := queryRow(ctx, db, "GetPasswordHash", "SELECT password FROM user WHERE id = ?", []any{userID}, &passwordHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrUserNotFound
+		}
+		return "", fmt.Errorf("failed to query password: %w", err)
+	}
+	return passwordHash, nil
+}
+
+// Obter o email do usuário e se ele já foi verificado
+func GetUserEmail(ctx context.Context, userID int) (string, bool, error) {
+	db := database.GetDB()
+	var email string
+	var verifiedAt sql.NullString
+	err := queryRow(ctx, db, "GetUserEmail", "SELECT email, email_verified_at FROM user WHERE id = ?", []any{userID}, &email, &verifiedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, ErrUserNotFound
+		}
+		return "", false, fmt.Errorf("failed to query email: %w", err)
+	}
+	return email, verifiedAt.Valid, nil
+}
+
+// Obter o ID do usuário pelo email
+func GetUserIDByEmail(ctx context.Context, email string) (int, error) {
+	db := database.GetDB()
+	var id int
+	err := queryRow(ctx, db, "GetUserIDByEmail", "SELECT id FROM user WHERE email = ?", []any{email}, &id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrUserNotFound
+		}
+		return 0, fmt.Errorf("failed to query user by email: %w", err)
+	}
+	return id, nil
+}
+
+// Marcar o email do usuário como verificado
+func MarkEmailVerified(ctx context.Context, userID int) error {
+	db := database.GetDB()
+	_, err := exec(ctx, db, "MarkEmailVerified", "UPDATE user SET email_verified_at = NOW() WHERE id = ? AND email_verified_at IS NULL", userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark email as verified: %w", err)
+	}
+	return nil
+}
+
+// Atualizar o hash da senha do usuário
+func UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	db := database.GetDB()
+	_, err := exec(ctx, db, "UpdatePassword", "UPDATE user SET password = ? WHERE id = ?", passwordHash, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	return nil
+}
+
+// Obter o idioma preferido do usuário (vazio se ele não escolheu)
+func GetUserLocale(ctx context.Context, userID int) (string, error) {
+	db := database.GetDB()
+	var locale sql.NullString
+	err := queryRow(ctx, db, "GetUserLocale", "SELECT locale FROM user WHERE id = ?", []any{userID}, &locale)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrUserNotFound
+		}
+		return "", fmt.Errorf("failed to query locale: %w", err)
+	}
+	return locale.String, nil
+}
+
+// Atualizar o idioma preferido do usuário
+func UpdateUserLocale(ctx context.Context, userID int, locale string) error {
+	db := database.GetDB()
+	_, err := exec(ctx, db, "UpdateUserLocale", "UPDATE user SET locale = ? WHERE id = ?", locale, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update locale: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package rpc
+
+import (
+	"context"
+	"log/slog"
+	pigeonv1 "messenger-pigeon-app/api/proto/pigeon/v1"
+	"messenger-pigeon-app/config/middleware"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/logging"
+	"messenger-pigeon-app/pkg/ratelimit"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// session é o usuário autenticado da chamada, guardado no contexto pelos interceptors.
+type session struct {
+	userID    int64
+	sessionID string
+	locale    string
+}
+
+type sessionKey struct{}
+
+func sessionFrom(ctx context.Context) session {
+	s, _ := ctx.Value(sessionKey{}).(session)
+	return s
+}
+
+// Classes de limite dos métodos unários, as mesmas das rotas REST equivalentes.
+var methodClasses = map[string]string{
+	pigeonv1.Messenger_SendMessage_FullMethodName:       ratelimit.ClassSend,
+	pigeonv1.Messenger_ListConversations_FullMethodName: ratelimit.ClassHistory,
+	pigeonv1.Messenger_FetchHistory_FullMethodName:      ratelimit.ClassHistory,
+}
+
+// publicMethod indica os métodos que não exigem token (a reflexão usada por ferramentas como o grpcurl).
+func publicMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/grpc.reflection.")
+}
+
+// unaryInterceptor autentica a chamada e aplica o limite da classe do método.
+func unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, callErr error) {
+	defer recoverPanic(ctx, info.FullMethod, &callErr)
+	if publicMethod(info.FullMethod) {
+		return handler(ctx, req)
+	}
+
+	ctx, authErr := authenticate(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+	if class, ok := methodClasses[info.FullMethod]; ok {
+		if allowed, retryAfter := allow(ctx, class); !allowed {
+			locale := sessionFrom(ctx).locale
+			return nil, rateLimitError(err.New(err.CodeTooManyRequests, i18n.T(locale, "error.too_many_requests")), retryAfter)
+		}
+	}
+	return handler(ctx, req)
+}
+
+// streamInterceptor autentica o stream; o limite é aplicado a cada evento recebido.
+func streamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (callErr error) {
+	defer recoverPanic(stream.Context(), info.FullMethod, &callErr)
+	if publicMethod(info.FullMethod) {
+		return handler(srv, stream)
+	}
+
+	ctx, authErr := authenticate(stream.Context())
+	if authErr != nil {
+		return authErr
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate valida o token do metadata "authorization" como o AuthMiddleware e guarda o
+// usuário no contexto. O idioma vem do "accept-language", com prioridade para o salvo pelo usuário.
+func authenticate(ctx context.Context) (context.Context, error) {
+	// Id de correlação dos logs, como o cabeçalho X-Request-ID das rotas REST
+	requestID := metadataValue(ctx, "x-request-id")
+	if !logging.ValidID(requestID) {
+		requestID = logging.NewID()
+	}
+	ctx = logging.WithRequestID(ctx, requestID)
+
+	locale := i18n.Negotiate(metadataValue(ctx, "accept-language"))
+
+	tokenString := ""
+	if tokenParts := strings.Split(metadataValue(ctx, "authorization"), " "); len(tokenParts) == 2 && tokenParts[0] == "Bearer" {
+		tokenString = tokenParts[1]
+	}
+	if tokenString == "" {
+		return ctx, statusError(err.New(err.CodeTokenMissing, i18n.T(locale, "error.token_missing")))
+	}
+
+	claims, authErr := middleware.Authenticate(ctx, tokenString)
+	if authErr != nil {
+		code, key := middleware.AuthErrorCode(authErr)
+		return ctx, statusError(err.New(code, i18n.T(locale, key)))
+	}
+	if preferred, ok := i18n.Match(claims.Locale); ok {
+		locale = preferred
+	}
+
+	return context.WithValue(ctx, sessionKey{}, session{
+		userID:    int64(claims.UserID),
+		sessionID: claims.SessionID,
+		locale:    locale,
+	}), nil
+}
+
+// allow consome um token da classe para o usuário e o IP da chamada.
+func allow(ctx context.Context, class string) (bool, time.Duration) {
+	ip := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, splitErr := net.SplitHostPort(p.Addr.String()); splitErr == nil {
+			ip = host
+		}
+	}
+
+	return ratelimit.Default().Allow(class, int(sessionFrom(ctx).userID), ip)
+}
+
+func metadataValue(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// recoverPanic transforma um panic do handler em codes.Internal, como o Recovery das rotas REST.
+func recoverPanic(ctx context.Context, method string, callErr *error) {
+	if recovered := recover(); recovered != nil {
+		slog.ErrorContext(ctx, "Recovered from panic", "method", method, "panic", recovered)
+		*callErr = statusError(err.New(err.CodeInternal, i18n.T(i18n.Default(), "error.internal")))
+	}
+}
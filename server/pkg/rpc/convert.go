@@ -0,0 +1,92 @@
+package rpc
+
+import (
+	"encoding/base64"
+	pigeonv1 "messenger-pigeon-app/api/proto/pigeon/v1"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/websockets"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toMessage converte a mensagem dos serviços, que vem com campos diferentes conforme a
+// origem (histórico ou dispatcher), na mensagem do protobuf.
+func toMessage(message model.UserMessage) *pigeonv1.Message {
+	senderID := message.MessageBy
+	if senderID == 0 {
+		senderID = message.MessageUserID
+	}
+	return &pigeonv1.Message{
+		Id:              int64(message.MessageID),
+		SenderId:        int64(senderID),
+		RecipientId:     int64(message.MessageTo),
+		SenderUsername:  message.CreatedBy,
+		SenderName:      message.Name,
+		SenderIcon:      icon(message),
+		Content:         message.Content,
+		CreatedAt:       message.CreatedAt,
+		ClientMessageId: message.ClientMessageID,
+		Own:             message.MessageSession,
+	}
+}
+
+// toConversation converte um item de services.GetUserChats, cujo usuário é o outro participante.
+func toConversation(chat model.UserMessage) *pigeonv1.Conversation {
+	return &pigeonv1.Conversation{
+		Username:      chat.CreatedBy,
+		Name:          chat.Name,
+		Icon:          icon(chat),
+		LastMessage:   chat.Content,
+		LastMessageAt: chat.CreatedAt,
+	}
+}
+
+// icon retorna o ícone em bytes; os serviços às vezes só preenchem o IconBase64.
+func icon(message model.UserMessage) []byte {
+	if message.Icon != nil || message.IconBase64 == "" {
+		return message.Icon
+	}
+	decoded, decodeErr := base64.StdEncoding.DecodeString(message.IconBase64)
+	if decodeErr != nil {
+		return nil
+	}
+	return decoded
+}
+
+// toServerEvent converte um evento do protocolo versionado no evento do stream. Retorna nil
+// para tipos sem equivalente.
+func toServerEvent(envelope websockets.Envelope) *pigeonv1.ServerEvent {
+	event := &pigeonv1.ServerEvent{
+		Id:        envelope.ID,
+		ReplyTo:   envelope.ReplyTo,
+		Timestamp: timestamppb.New(envelope.Timestamp),
+	}
+
+	switch payload := envelope.Payload.(type) {
+	case websockets.MessageAckPayload:
+		event.Event = &pigeonv1.ServerEvent_MessageAck{MessageAck: &pigeonv1.SendMessageResponse{
+			MessageId:       payload.MessageID,
+			ClientMessageId: payload.ClientMessageID,
+			Replayed:        payload.Replayed,
+		}}
+	case model.UserMessage:
+		if envelope.Type == websockets.EventMessageSent {
+			event.Event = &pigeonv1.ServerEvent_MessageSent{MessageSent: toMessage(payload)}
+		} else {
+			event.Event = &pigeonv1.ServerEvent_MessageCreated{MessageCreated: toMessage(payload)}
+		}
+	case err.Error:
+		event.Event = &pigeonv1.ServerEvent_Error{Error: &pigeonv1.Error{
+			Code:    payload.Code,
+			Message: payload.Message,
+			Fields:  payload.Fields,
+		}}
+	default:
+		if envelope.Type != websockets.EventSyncRequired {
+			return nil
+		}
+		event.Event = &pigeonv1.ServerEvent_SyncRequired{SyncRequired: &pigeonv1.SyncRequired{}}
+	}
+	return event
+}
@@ -0,0 +1,63 @@
+package rpc
+
+import (
+	"messenger-pigeon-app/internal/err"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Domínio do google.rpc.ErrorInfo dos erros da API; o Reason é o código estável do envelope REST.
+const errorDomain = "pigeon"
+
+// Status do gRPC equivalente a cada código de erro da API.
+var statusCodes = map[string]codes.Code{
+	err.CodeBadRequest:          codes.InvalidArgument,
+	err.CodeValidation:          codes.InvalidArgument,
+	err.CodeUnauthorized:        codes.Unauthenticated,
+	err.CodeTokenMissing:        codes.Unauthenticated,
+	err.CodeInvalidToken:        codes.Unauthenticated,
+	err.CodeSessionRevoked:      codes.Unauthenticated,
+	err.CodeForbidden:           codes.PermissionDenied,
+	err.CodeNotFound:            codes.NotFound,
+	err.CodeUserNotFound:        codes.NotFound,
+	err.CodeConflict:            codes.AlreadyExists,
+	err.CodeClientMessageReused: codes.AlreadyExists,
+	err.CodeTooManyRequests:     codes.ResourceExhausted,
+	err.CodeUnavailable:         codes.Unavailable,
+	err.CodeInternal:            codes.Internal,
+}
+
+// statusError converte o envelope de erro da API no status do gRPC, com o código e os erros
+// por campo em um google.rpc.ErrorInfo.
+func statusError(e err.ErrorResponse) error {
+	return withDetails(e, &errdetails.ErrorInfo{
+		Reason:   e.Error.Code,
+		Domain:   errorDomain,
+		Metadata: e.Error.Fields,
+	})
+}
+
+// rateLimitError é o statusError do limite de requisições, com o google.rpc.RetryInfo no lugar do Retry-After.
+func rateLimitError(e err.ErrorResponse, retryAfter time.Duration) error {
+	return withDetails(e,
+		&errdetails.ErrorInfo{Reason: e.Error.Code, Domain: errorDomain},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)},
+	)
+}
+
+func withDetails(e err.ErrorResponse, details ...protoadapt.MessageV1) error {
+	code, ok := statusCodes[e.Error.Code]
+	if !ok {
+		code = codes.Unknown
+	}
+	st := status.New(code, e.Error.Message)
+	if detailed, detailsErr := st.WithDetails(details...); detailsErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -0,0 +1,159 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	pigeonv1 "messenger-pigeon-app/api/proto/pigeon/v1"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/metrics"
+	"messenger-pigeon-app/pkg/ratelimit"
+	"messenger-pigeon-app/pkg/repository"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/tracing"
+	"messenger-pigeon-app/pkg/websockets"
+	"strings"
+)
+
+// messengerServer implementa o serviço Messenger sobre os mesmos serviços da API REST e o
+// mesmo Registry dos WebSockets.
+type messengerServer struct {
+	pigeonv1.UnimplementedMessengerServer
+}
+
+// SendMessage envia a mensagem como o POST /v1/conversations/:id/messages.
+func (m *messengerServer) SendMessage(ctx context.Context, req *pigeonv1.SendMessageRequest) (*pigeonv1.SendMessageResponse, error) {
+	s := sessionFrom(ctx)
+	ack, sendErr := websockets.SubmitMessage(ctx, s.userID, s.locale, websockets.SendMessagePayload{
+		To:              req.GetTo(),
+		Content:         req.GetContent(),
+		ClientMessageID: req.GetClientMessageId(),
+	})
+	if sendErr != nil {
+		return nil, statusError(*sendErr)
+	}
+
+	return &pigeonv1.SendMessageResponse{
+		MessageId:       ack.MessageID,
+		ClientMessageId: ack.ClientMessageID,
+		Replayed:        ack.Replayed,
+	}, nil
+}
+
+// ListConversations lista as conversas como o GET /v1/conversations.
+func (m *messengerServer) ListConversations(ctx context.Context, req *pigeonv1.ListConversationsRequest) (*pigeonv1.ListConversationsResponse, error) {
+	s := sessionFrom(ctx)
+	chats, chatsErr := services.GetUserChats(ctx, s.userID)
+	if chatsErr != nil {
+		slog.ErrorContext(ctx, "Error listing conversations", "error", chatsErr)
+		return nil, statusError(err.New(err.CodeInternal, i18n.T(s.locale, "error.chats_failed")))
+	}
+
+	resp := &pigeonv1.ListConversationsResponse{Conversations: make([]*pigeonv1.Conversation, 0, len(chats))}
+	for _, chat := range chats {
+		resp.Conversations = append(resp.Conversations, toConversation(chat))
+	}
+	return resp, nil
+}
+
+// FetchHistory retorna as mensagens da conversa como o GET /v1/conversations/:id/messages.
+func (m *messengerServer) FetchHistory(ctx context.Context, req *pigeonv1.FetchHistoryRequest) (*pigeonv1.FetchHistoryResponse, error) {
+	s := sessionFrom(ctx)
+	username := strings.TrimSpace(req.GetUsername())
+	if username == "" {
+		return nil, statusError(err.WithFields(err.CodeValidation, i18n.T(s.locale, "error.validation"), map[string]string{
+			"username": i18n.T(s.locale, "validation.required"),
+		}))
+	}
+
+	partnerID, lookupErr := repository.MessageGetUserIDByUsername(ctx, username)
+	if lookupErr != nil {
+		if errors.Is(lookupErr, repository.ErrUserNotFound) {
+			return nil, statusError(err.New(err.CodeUserNotFound, i18n.T(s.locale, "error.user_not_found")))
+		}
+		slog.ErrorContext(ctx, "Error getting user ID", "error", lookupErr)
+		return nil, statusError(err.New(err.CodeInternal, i18n.T(s.locale, "error.user_lookup_failed")))
+	}
+
+	messages, messagesErr := services.GetChatMessages(ctx, int(s.userID), partnerID)
+	if messagesErr != nil {
+		slog.ErrorContext(ctx, "Error retrieving messages", "error", messagesErr)
+		return nil, statusError(err.New(err.CodeInternal, i18n.T(s.locale, "error.messages_failed")))
+	}
+
+	resp := &pigeonv1.FetchHistoryResponse{Messages: make([]*pigeonv1.Message, 0, len(messages))}
+	for _, message := range messages {
+		resp.Messages = append(resp.Messages, toMessage(message))
+	}
+	return resp, nil
+}
+
+// Events registra o stream como uma conexão do usuário, como o SSE, e trata os eventos
+// enviados pelo cliente como o WebSocket versionado. As respostas passam pela fila da
+// conexão, para que só uma goroutine escreva no stream.
+func (m *messengerServer) Events(stream pigeonv1.Messenger_EventsServer) error {
+	ctx := stream.Context()
+	s := sessionFrom(ctx)
+
+	client := websockets.NewStreamClient(ctx, s.userID, s.sessionID)
+	backlog, _ := websockets.Subscribe(websockets.UserConnections, client, metadataValue(ctx, "last-event-id"))
+	defer websockets.UserConnections.Unregister(client)
+	defer client.Close()
+	defer metrics.TrackConnection(pigeonv1.Messenger_Events_FullMethodName)()
+
+	go func() {
+		for {
+			event, recvErr := stream.Recv()
+			if recvErr != nil {
+				// Com o envio encerrado pelo cliente (io.EOF) o stream continua entregando eventos
+				if !errors.Is(recvErr, io.EOF) {
+					client.Close()
+				}
+				return
+			}
+			client.Send(handleClientEvent(ctx, s, event))
+		}
+	}()
+
+	forwardErr := websockets.Forward(ctx, client, backlog, func(envelope websockets.Envelope) error {
+		event := toServerEvent(envelope)
+		if event == nil {
+			return nil
+		}
+		return stream.Send(event)
+	})
+	if forwardErr != nil {
+		return forwardErr
+	}
+
+	// A conexão foi encerrada pelo servidor (sessão revogada ou fila cheia); o cliente
+	// reconecta com o last-event-id e, se a sessão não vale mais, recebe Unauthenticated
+	return statusError(err.New(err.CodeUnavailable, i18n.T(s.locale, "error.stream_closed")))
+}
+
+// handleClientEvent trata um evento do cliente e retorna a resposta (message.ack ou error).
+// Cada evento tem o seu span, filho do span do stream.
+func handleClientEvent(ctx context.Context, s session, event *pigeonv1.ClientEvent) websockets.Envelope {
+	ctx, span := tracing.Start(ctx, "Messenger.Events client_event")
+	defer span.End()
+
+	send := event.GetSendMessage()
+	if send == nil {
+		return websockets.NewReply(event.GetId(), websockets.EventError, err.New(err.CodeBadRequest, i18n.T(s.locale, "error.invalid_event")).Error)
+	}
+	if allowed, _ := allow(ctx, ratelimit.ClassSend); !allowed {
+		return websockets.NewReply(event.GetId(), websockets.EventError, err.New(err.CodeTooManyRequests, i18n.T(s.locale, "error.too_many_requests")).Error)
+	}
+
+	ack, sendErr := websockets.SubmitMessage(ctx, s.userID, s.locale, websockets.SendMessagePayload{
+		To:              send.GetTo(),
+		Content:         send.GetContent(),
+		ClientMessageID: send.GetClientMessageId(),
+	})
+	if sendErr != nil {
+		return websockets.NewReply(event.GetId(), websockets.EventError, sendErr.Error)
+	}
+	return websockets.NewReply(event.GetId(), websockets.EventMessageAck, ack)
+}
@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"fmt"
+	"log/slog"
+	pigeonv1 "messenger-pigeon-app/api/proto/pigeon/v1"
+	"messenger-pigeon-app/config/env"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+)
+
+// NewServer cria o servidor gRPC com o serviço Messenger, os interceptors de autenticação, o
+// tracing e a reflexão. GRPC_KEEPALIVE é o intervalo dos pings que mantêm o stream de eventos aberto
+// através de proxies. options completa a configuração (ex.: as credenciais TLS).
+func NewServer(options ...grpc.ServerOption) *grpc.Server {
+	keepaliveInterval := env.Duration("GRPC_KEEPALIVE", 30*time.Second)
+
+	server := grpc.NewServer(append([]grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unaryInterceptor),
+		grpc.ChainStreamInterceptor(streamInterceptor),
+		grpc.KeepaliveParams(keepalive.ServerParameters{Time: keepaliveInterval}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: keepaliveInterval / 2, PermitWithoutStream: true}),
+	}, options...)...)
+	pigeonv1.RegisterMessengerServer(server, &messengerServer{})
+	reflection.Register(server)
+	return server
+}
+
+// ListenAndServe atende a API gRPC no endereço informado até que o servidor pare. Com
+// GRPC_TLS_CERT e GRPC_TLS_KEY (arquivos PEM) a conexão usa TLS; sem eles, os tokens trafegam
+// em texto puro, o que só é seguro no loopback ou atrás de um proxy que termine o TLS.
+func ListenAndServe(addr string) error {
+	var options []grpc.ServerOption
+	certFile, keyFile := env.String("GRPC_TLS_CERT", ""), env.String("GRPC_TLS_KEY", "")
+	if certFile != "" || keyFile != "" {
+		creds, credsErr := credentials.NewServerTLSFromFile(certFile, keyFile)
+		if credsErr != nil {
+			return fmt.Errorf("loading gRPC TLS certificate: %w", credsErr)
+		}
+		options = append(options, grpc.Creds(creds))
+	}
+
+	listener, listenErr := net.Listen("tcp", addr)
+	if listenErr != nil {
+		return listenErr
+	}
+	if len(options) == 0 && !loopback(listener.Addr()) {
+		slog.Warn("gRPC server is serving plaintext on a non-loopback address, set GRPC_TLS_CERT and GRPC_TLS_KEY", "addr", listener.Addr().String())
+	}
+	slog.Info("gRPC server listening", "addr", listener.Addr().String(), "tls", len(options) > 0)
+	return NewServer(options...).Serve(listener)
+}
+
+// loopback indica se o endereço só aceita conexões da própria máquina.
+func loopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
@@ -0,0 +1,280 @@
+package rpc
+
+import (
+	"context"
+	"database/sql/driver"
+	pigeonv1 "messenger-pigeon-app/api/proto/pigeon/v1"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/config/keys"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/testdb"
+	"messenger-pigeon-app/pkg/ratelimit"
+	"messenger-pigeon-app/pkg/services"
+	"messenger-pigeon-app/pkg/websockets"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// startServer sobe o servidor gRPC em memória, com a usuária ana (7) e o bruno (8) no banco, e
+// retorna o cliente do Messenger.
+func startServer(t *testing.T) pigeonv1.MessengerClient {
+	t.Helper()
+	t.Setenv("SESSION_SECRET", "rpc-secret")
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_JWKS_URL", "")
+	if keysErr := keys.Initialize(); keysErr != nil {
+		t.Fatal(keysErr)
+	}
+
+	db, store := testdb.Open()
+	database.SetDB(db)
+	t.Cleanup(func() { database.SetDB(nil) })
+	ratelimit.Initialize(db)
+	store.Rows("SELECT EXISTS(SELECT 1 FROM user_session", []string{"active"}, []driver.Value{true})
+	store.Rows("SELECT locale FROM user", []string{"locale"}, []driver.Value{"en"})
+	store.Handle("SELECT name, username, icon FROM user", func(args []driver.Value) testdb.Result {
+		columns := []string{"name", "username", "icon"}
+		if args[0] == int64(8) {
+			return testdb.Result{Columns: columns, Rows: [][]driver.Value{{"Bruno", "bruno", nil}}}
+		}
+		return testdb.Result{Columns: columns, Rows: [][]driver.Value{{"Ana", "ana", nil}}}
+	})
+	store.Handle("SELECT id FROM user WHERE username", func(args []driver.Value) testdb.Result {
+		if args[0] == "bruno" {
+			return testdb.Result{Columns: []string{"id"}, Rows: [][]driver.Value{{int64(8)}}}
+		}
+		return testdb.Result{Columns: []string{"id"}}
+	})
+	store.Handle("INSERT INTO user_message", func([]driver.Value) testdb.Result {
+		return testdb.Result{RowsAffected: 1, LastInsertID: 3}
+	})
+	websockets.Initialize()
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer()
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, dialErr := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if dialErr != nil {
+		t.Fatal(dialErr)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pigeonv1.NewMessengerClient(conn)
+}
+
+// withToken adiciona o token de acesso ao metadata da chamada.
+func withToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func accessToken(t *testing.T, userID int) string {
+	t.Helper()
+	token, _, issueErr := services.IssueAccessToken(context.Background(), userID, "s1")
+	if issueErr != nil {
+		t.Fatal(issueErr)
+	}
+	return token
+}
+
+// errorInfo retorna o google.rpc.ErrorInfo dos detalhes do status.
+func errorInfo(t *testing.T, st *status.Status) *errdetails.ErrorInfo {
+	t.Helper()
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+	t.Fatalf("status %v has no ErrorInfo", st)
+	return nil
+}
+
+// Chamadas sem token, com token expirado ou com um token parcial (com typ, como o do 2FA)
+// são recusadas com Unauthenticated, nos métodos unários e no stream.
+func TestAuthInterceptorRejectsTokens(t *testing.T) {
+	client := startServer(t)
+
+	expired, signErr := keys.Default().Sign(jwt.MapClaims{
+		"id":  7,
+		"sid": "s1",
+		"iat": time.Now().Add(-time.Hour).Unix(),
+		"exp": time.Now().Add(-time.Minute).Unix(),
+	})
+	if signErr != nil {
+		t.Fatal(signErr)
+	}
+	partial, _, issueErr := services.IssueMFAToken(7)
+	if issueErr != nil {
+		t.Fatal(issueErr)
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		reason string
+	}{
+		{"missing", context.Background(), err.CodeTokenMissing},
+		{"expired", withToken(context.Background(), expired), err.CodeInvalidToken},
+		{"typ", withToken(context.Background(), partial), err.CodeInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, callErr := client.ListConversations(tt.ctx, &pigeonv1.ListConversationsRequest{})
+			st := status.Convert(callErr)
+			if st.Code() != codes.Unauthenticated {
+				t.Fatalf("ListConversations code = %v, want Unauthenticated", st.Code())
+			}
+			if info := errorInfo(t, st); info.Reason != tt.reason || info.Domain != errorDomain {
+				t.Errorf("ErrorInfo = %s/%s, want %s/%s", info.Domain, info.Reason, errorDomain, tt.reason)
+			}
+
+			stream, openErr := client.Events(tt.ctx)
+			if openErr != nil {
+				t.Fatal(openErr)
+			}
+			if _, recvErr := stream.Recv(); status.Code(recvErr) != codes.Unauthenticated {
+				t.Errorf("Events error = %v, want Unauthenticated", recvErr)
+			}
+		})
+	}
+}
+
+// Os erros da API viram status do gRPC com o código do envelope REST no ErrorInfo, os erros
+// por campo no metadata e o Retry-After no RetryInfo.
+func TestStatusDetails(t *testing.T) {
+	t.Setenv("RATE_LIMIT_HISTORY_USER", "2/1m")
+	client := startServer(t)
+	ctx := withToken(context.Background(), accessToken(t, 7))
+
+	_, callErr := client.FetchHistory(ctx, &pigeonv1.FetchHistoryRequest{Username: " "})
+	st := status.Convert(callErr)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("validation code = %v, want InvalidArgument", st.Code())
+	}
+	if info := errorInfo(t, st); info.Reason != err.CodeValidation || info.Metadata["username"] == "" {
+		t.Errorf("validation ErrorInfo = %v, want %s with the username field", info, err.CodeValidation)
+	}
+
+	_, callErr = client.FetchHistory(ctx, &pigeonv1.FetchHistoryRequest{Username: "nobody"})
+	st = status.Convert(callErr)
+	if st.Code() != codes.NotFound {
+		t.Fatalf("not found code = %v, want NotFound", st.Code())
+	}
+	if info := errorInfo(t, st); info.Reason != err.CodeUserNotFound {
+		t.Errorf("not found ErrorInfo reason = %q, want %q", info.Reason, err.CodeUserNotFound)
+	}
+
+	// A terceira leitura passa do limite de 2 por minuto
+	_, callErr = client.FetchHistory(ctx, &pigeonv1.FetchHistoryRequest{Username: "nobody"})
+	st = status.Convert(callErr)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("rate limit code = %v, want ResourceExhausted", st.Code())
+	}
+	if info := errorInfo(t, st); info.Reason != err.CodeTooManyRequests {
+		t.Errorf("rate limit ErrorInfo reason = %q, want %q", info.Reason, err.CodeTooManyRequests)
+	}
+	var retry *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	if retry == nil || retry.GetRetryDelay().AsDuration() <= 0 {
+		t.Errorf("RetryInfo = %v, want a positive retry delay", retry)
+	}
+}
+
+// Um send_message no stream é confirmado com o message_ack ao remetente e entregue como
+// message_created no stream do destinatário.
+func TestEventsStreamSubmitsMessage(t *testing.T) {
+	client := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// O bruno acompanha a partir de agora; o last-event-id cobre a mensagem mesmo que o
+	// stream seja registrado depois do envio
+	brunoCtx := metadata.AppendToOutgoingContext(withToken(ctx, accessToken(t, 8)), "last-event-id", websockets.UserConnections.Journal().Cursor())
+	bruno, openErr := client.Events(brunoCtx)
+	if openErr != nil {
+		t.Fatal(openErr)
+	}
+
+	ana, openErr := client.Events(withToken(ctx, accessToken(t, 7)))
+	if openErr != nil {
+		t.Fatal(openErr)
+	}
+	if sendErr := ana.Send(&pigeonv1.ClientEvent{
+		Id: "e-1",
+		Event: &pigeonv1.ClientEvent_SendMessage{SendMessage: &pigeonv1.SendMessageRequest{
+			To:              "bruno",
+			Content:         "oi",
+			ClientMessageId: "c-1",
+		}},
+	}); sendErr != nil {
+		t.Fatal(sendErr)
+	}
+
+	// O eco message_sent também pode chegar à ana; só o ack responde ao evento
+	for {
+		event, recvErr := ana.Recv()
+		if recvErr != nil {
+			t.Fatal(recvErr)
+		}
+		if ack := event.GetMessageAck(); ack != nil {
+			if event.GetReplyTo() != "e-1" || ack.GetMessageId() != 3 || ack.GetClientMessageId() != "c-1" {
+				t.Errorf("ack = %v, want reply to e-1 for message 3", event)
+			}
+			break
+		}
+	}
+
+	event, recvErr := bruno.Recv()
+	if recvErr != nil {
+		t.Fatal(recvErr)
+	}
+	created := event.GetMessageCreated()
+	if created == nil || created.GetId() != 3 || created.GetContent() != "oi" || created.GetSenderId() != 7 || event.GetId() == "" {
+		t.Fatalf("bruno received %v, want message_created 3 from ana", event)
+	}
+}
+
+// Um certificado configurado mas ilegível impede a subida, em vez de cair no texto puro.
+func TestListenAndServeRequiresReadableCertificate(t *testing.T) {
+	t.Setenv("GRPC_TLS_CERT", t.TempDir()+"/missing.pem")
+	t.Setenv("GRPC_TLS_KEY", "")
+	if serveErr := ListenAndServe("127.0.0.1:0"); serveErr == nil {
+		t.Fatal("ListenAndServe started without the TLS certificate")
+	}
+}
+
+func TestLoopback(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1:9090": true,
+		"[::1]:9090":     true,
+		"0.0.0.0:9090":   false,
+		"[::]:9090":      false,
+		"10.0.0.5:9090":  false,
+	}
+	for addr, want := range tests {
+		tcp, resolveErr := net.ResolveTCPAddr("tcp", addr)
+		if resolveErr != nil {
+			t.Fatal(resolveErr)
+		}
+		if got := loopback(tcp); got != want {
+			t.Errorf("loopback(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
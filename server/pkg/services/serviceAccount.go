@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"messenger-pigeon-app/config/env"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/mailer"
+	"messenger-pigeon-app/pkg/repository"
+	"messenger-pigeon-app/pkg/tracing"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Finalidades dos tokens enviados por email.
+const (
+	purposeEmailVerification = "email_verification"
+	purposePasswordReset     = "password_reset"
+)
+
+// ErrInvalidAccountToken é retornado para tokens de email inválidos, expirados ou já usados.
+var ErrInvalidAccountToken = errors.New("invalid or expired token")
+
+// Enviar o link de verificação para o email do usuário, no idioma preferido dele
+// (ou em locale, se ele não escolheu um)
+func SendEmailVerification(ctx context.Context, userID int, locale string) error {
+	ctx, span := tracing.Start(ctx, "services.SendEmailVerification")
+	defer span.End()
+
+	email, verified, err := repository.GetUserEmail(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("error retrieving email: %w", err)
+	}
+	if verified {
+		return nil
+	}
+
+	token, err := issueAccountToken(ctx, userID, purposeEmailVerification, env.Duration("EMAIL_VERIFICATION_TTL", 48*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	locale = userLocale(ctx, userID, locale)
+	return mailer.Default().Send(mailer.Message{
+		To:      email,
+		Subject: i18n.T(locale, "email.verify.subject"),
+		Body:    i18n.T(locale, "email.verify.body", appLink("/verify-email", token)),
+	})
+}
+
+// Confirmar o email com o token recebido
+func VerifyEmail(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "services.VerifyEmail")
+	defer span.End()
+
+	userID, err := consumeAccountToken(ctx, token, purposeEmailVerification)
+	if err != nil {
+		return err
+	}
+	if err := repository.MarkEmailVerified(ctx, userID); err != nil {
+		return fmt.Errorf("error verifying email: %w", err)
+	}
+	return nil
+}
+
+// Enviar o link de redefinição de senha. Emails desconhecidos são ignorados em silêncio,
+// para não revelar quais emails estão cadastrados.
+func RequestPasswordReset(ctx context.Context, email, locale string) error {
+	ctx, span := tracing.Start(ctx, "services.RequestPasswordReset")
+	defer span.End()
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	userID, err := repository.GetUserIDByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			slog.InfoContext(ctx, "Password reset requested for unknown email")
+			return nil
+		}
+		return fmt.Errorf("error retrieving user: %w", err)
+	}
+
+	token, err := issueAccountToken(ctx, userID, purposePasswordReset, env.Duration("PASSWORD_RESET_TTL", time.Hour))
+	if err != nil {
+		return err
+	}
+
+	locale = userLocale(ctx, userID, locale)
+	return mailer.Default().Send(mailer.Message{
+		To:      email,
+		Subject: i18n.T(locale, "email.reset.subject"),
+		Body:    i18n.T(locale, "email.reset.body", appLink("/reset-password", token)),
+	})
+}
+
+// Redefinir a senha com o token recebido. Todas as sessões do usuário são revogadas;
+// retorna o usuário e as sessões revogadas para que suas conexões sejam fechadas.
+func ResetPassword(ctx context.Context, token, password string) (int, []string, error) {
+	ctx, span := tracing.Start(ctx, "services.ResetPassword")
+	defer span.End()
+
+	userID, err := consumeAccountToken(ctx, token, purposePasswordReset)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	revoked, err := replacePassword(ctx, userID, password)
+	if err != nil {
+		return 0, nil, err
+	}
+	return userID, revoked, nil
+}
+
+// replacePassword troca a senha do usuário, invalida os links de redefinição pendentes e
+// revoga todas as sessões. Retorna as sessões revogadas.
+func replacePassword(ctx context.Context, userID int, password string) ([]string, error) {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.UpdatePassword(ctx, userID, hash); err != nil {
+		return nil, fmt.Errorf("error updating password: %w", err)
+	}
+	if err := repository.InvalidateUserTokens(ctx, userID, purposePasswordReset); err != nil {
+		return nil, err
+	}
+
+	revoked, err := repository.RevokeSessionsExcept(ctx, userID, "")
+	if err != nil {
+		return nil, fmt.Errorf("error revoking sessions: %w", err)
+	}
+	return revoked, nil
+}
+
+// issueAccountToken gera um token assinado no formato <payload>.<assinatura>, em que o payload
+// traz finalidade, usuário, expiração e um valor aleatório. O hash do token é salvo para que
+// ele só possa ser usado uma vez.
+func issueAccountToken(ctx context.Context, userID int, purpose string, ttl time.Duration) (string, error) {
+	nonce, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(ttl).Unix()
+	payload := strings.Join([]string{purpose, strconv.Itoa(userID), strconv.FormatInt(expiresAt, 10), nonce}, ":")
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	token := encoded + "." + base64.RawURLEncoding.EncodeToString(signAccountToken(encoded))
+
+	if err := repository.CreateUserToken(ctx, userID, purpose, hashToken(token), ttl); err != nil {
+		return "", fmt.Errorf("error storing token: %w", err)
+	}
+	return token, nil
+}
+
+// consumeAccountToken valida assinatura, finalidade e expiração antes de consumir o token no banco.
+func consumeAccountToken(ctx context.Context, token, purpose string) (int, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, ErrInvalidAccountToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, signAccountToken(encoded)) {
+		return 0, ErrInvalidAccountToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, ErrInvalidAccountToken
+	}
+	parts := strings.Split(string(payload), ":")
+	if len(parts) != 4 || parts[0] != purpose {
+		return 0, ErrInvalidAccountToken
+	}
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return 0, ErrInvalidAccountToken
+	}
+
+	userID, err := repository.ConsumeUserToken(ctx, purpose, hashToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrTokenNotFound) {
+			return 0, ErrInvalidAccountToken
+		}
+		return 0, fmt.Errorf("error consuming token: %w", err)
+	}
+	if strconv.Itoa(userID) != parts[1] {
+		return 0, ErrInvalidAccountToken
+	}
+	return userID, nil
+}
+
+// Separa as assinaturas destes tokens de outros HMACs feitos com o mesmo segredo.
+const purposeSeparator = "account-token:"
+
+func signAccountToken(encoded string) []byte {
+	secret := env.String("ACCOUNT_TOKEN_SECRET", os.Getenv("SESSION_SECRET"))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purposeSeparator + encoded))
+	return mac.Sum(nil)
+}
+
+// appLink monta o link do frontend (APP_URL) com o token como parâmetro.
+func appLink(path, token string) string {
+	base := strings.TrimSuffix(env.String("APP_URL", "http://localhost:3000"), "/")
+	return base + path + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/internal/smtptest"
+	"messenger-pigeon-app/internal/testdb"
+	"messenger-pigeon-app/pkg/mailer"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// openAccountMail aponta o mailer para um servidor SMTP local e o banco para o usuário 7
+// (ana@example.com), com o idioma preferido informado.
+func openAccountMail(t *testing.T, locale string) (*smtptest.Server, *testdb.Store) {
+	t.Helper()
+	server := smtptest.Start(t)
+	t.Setenv("MAILER", "smtp")
+	t.Setenv("SMTP_HOST", server.Host)
+	t.Setenv("SMTP_PORT", server.Port)
+	t.Setenv("APP_URL", "https://pigeon.example.com/")
+	t.Setenv("ACCOUNT_TOKEN_SECRET", "test-secret")
+	mailer.Initialize()
+	t.Cleanup(func() {
+		t.Setenv("MAILER", "log")
+		mailer.Initialize()
+	})
+
+	db, store := testdb.Open()
+	database.SetDB(db)
+	t.Cleanup(func() { database.SetDB(nil) })
+	store.Rows("SELECT email, email_verified_at FROM user", []string{"email", "email_verified_at"}, []driver.Value{"ana@example.com", nil})
+	store.Rows("SELECT id FROM user WHERE email", []string{"id"}, []driver.Value{int64(7)})
+	store.Rows("SELECT locale FROM user", []string{"locale"}, []driver.Value{locale})
+	store.Exec("INSERT INTO user_token", 1)
+	return server, store
+}
+
+var tokenLink = regexp.MustCompile(`https://\S+`)
+
+// checkAccountEmail confere destinatário, assunto e o link com o token salvo no banco.
+func checkAccountEmail(t *testing.T, server *smtptest.Server, store *testdb.Store, subject, path, purpose string) {
+	t.Helper()
+	received := server.Received()
+	if len(received) != 1 {
+		t.Fatalf("received %d emails, want 1", len(received))
+	}
+	email := received[0]
+	if strings.Join(email.To, ",") != "ana@example.com" || email.Message.Header.Get("To") != "ana@example.com" {
+		t.Fatalf("recipient = %v / %q, want ana@example.com", email.To, email.Message.Header.Get("To"))
+	}
+	if got, _ := new(mime.WordDecoder).DecodeHeader(email.Message.Header.Get("Subject")); got != subject {
+		t.Fatalf("Subject = %q, want %q", got, subject)
+	}
+
+	link, err := url.Parse(tokenLink.FindString(email.Body))
+	if err != nil || link.Host != "pigeon.example.com" || link.Path != path {
+		t.Fatalf("link = %v (%v), want https://pigeon.example.com%s", link, err, path)
+	}
+	token := link.Query().Get("token")
+	inserts := store.Calls("INSERT INTO user_token")
+	if len(inserts) != 1 {
+		t.Fatalf("%d tokens stored, want 1", len(inserts))
+	}
+	if args := inserts[0].Args; args[0] != int64(7) || args[1] != purpose || args[2] != hashToken(token) {
+		t.Fatalf("stored token %v does not match the emailed token", args)
+	}
+}
+
+func TestSendEmailVerificationMail(t *testing.T) {
+	server, store := openAccountMail(t, "pt-BR")
+
+	if err := SendEmailVerification(context.Background(), 7, "en"); err != nil {
+		t.Fatalf("SendEmailVerification(): %v", err)
+	}
+	checkAccountEmail(t, server, store, "Confirme seu endereço de email", "/verify-email", purposeEmailVerification)
+}
+
+func TestRequestPasswordResetMail(t *testing.T) {
+	server, store := openAccountMail(t, "")
+
+	if err := RequestPasswordReset(context.Background(), " Ana@Example.com ", "en"); err != nil {
+		t.Fatalf("RequestPasswordReset(): %v", err)
+	}
+	if calls := store.Calls("SELECT id FROM user WHERE email"); len(calls) != 1 || calls[0].Args[0] != "ana@example.com" {
+		t.Fatalf("user lookup = %v, want the normalized email", calls)
+	}
+	checkAccountEmail(t, server, store, "Reset your password", "/reset-password", purposePasswordReset)
+}
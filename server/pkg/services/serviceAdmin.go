@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/repository"
+	"messenger-pigeon-app/pkg/tracing"
+	"strings"
+	"time"
+)
+
+// Operações dos comandos administrativos (cmd), sobre os mesmos repositórios usados pela API.
+
+// Obter o ID do usuário pelo username ou email, inclusive de usuários desativados
+func FindUserID(ctx context.Context, login string) (int, error) {
+	ctx, span := tracing.Start(ctx, "services.FindUserID")
+	defer span.End()
+
+	return repository.GetUserIDByLogin(ctx, strings.TrimSpace(login))
+}
+
+// GeneratePassword gera uma senha aleatória de 16 caracteres, o máximo aceito no cadastro.
+func GeneratePassword() (string, error) {
+	return randomHex(8)
+}
+
+// Desativar o usuário: ele não consegue mais entrar, suas sessões são revogadas e os links de
+// verificação e redefinição pendentes deixam de valer. Retorna false se ele já estava desativado.
+func DisableUser(ctx context.Context, userID int) (bool, []string, error) {
+	ctx, span := tracing.Start(ctx, "services.DisableUser")
+	defer span.End()
+
+	disabled, err := repository.DisableUser(ctx, userID)
+	if err != nil {
+		return false, nil, err
+	}
+	for _, purpose := range []string{purposeEmailVerification, purposePasswordReset} {
+		if err := repository.InvalidateUserTokens(ctx, userID, purpose); err != nil {
+			return false, nil, err
+		}
+	}
+
+	revoked, err := repository.RevokeSessionsExcept(ctx, userID, "")
+	if err != nil {
+		return false, nil, fmt.Errorf("error revoking sessions: %w", err)
+	}
+	return disabled, revoked, nil
+}
+
+// Definir a senha do usuário sem o token de redefinição. Assim como na redefinição pelo
+// email, todas as sessões são revogadas; retorna as sessões revogadas.
+func SetPassword(ctx context.Context, userID int, password string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "services.SetPassword")
+	defer span.End()
+
+	return replacePassword(ctx, userID, password)
+}
+
+// Marcar o email do usuário como verificado, para contas criadas pelos administradores
+func MarkEmailVerified(ctx context.Context, userID int) error {
+	ctx, span := tracing.Start(ctx, "services.MarkEmailVerified")
+	defer span.End()
+
+	return repository.MarkEmailVerified(ctx, userID)
+}
+
+// Obter todas as mensagens entre dois usuários, da mais antiga para a mais recente
+func ExportConversation(ctx context.Context, user1ID, user2ID int) ([]model.ExportedMessage, error) {
+	ctx, span := tracing.Start(ctx, "services.ExportConversation")
+	defer span.End()
+
+	messages, err := repository.ExportConversation(ctx, user1ID, user2ID)
+	if err != nil {
+		return nil, fmt.Errorf("error exporting conversation: %w", err)
+	}
+	return messages, nil
+}
+
+// Contar as mensagens com mais que a idade informada
+func CountMessagesOlderThan(ctx context.Context, age time.Duration) (int64, error) {
+	ctx, span := tracing.Start(ctx, "services.CountMessagesOlderThan")
+	defer span.End()
+
+	return repository.CountMessagesOlderThan(ctx, age)
+}
+
+// Apagar as mensagens com mais que a idade informada, em lotes de batchSize para não prender
+// a tabela numa transação longa. Retorna quantas foram apagadas, mesmo se um lote falhar.
+func PurgeMessages(ctx context.Context, age time.Duration, batchSize int) (int64, error) {
+	ctx, span := tracing.Start(ctx, "services.PurgeMessages")
+	defer span.End()
+
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		deleted, err := repository.DeleteMessagesOlderThan(ctx, age, batchSize)
+		total += deleted
+		if err != nil {
+			return total, fmt.Errorf("error purging messages: %w", err)
+		}
+		if deleted < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
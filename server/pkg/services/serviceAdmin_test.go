@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/internal/testdb"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openMessageStore responde aos DELETE da limpeza com os números de linhas de deleted, na ordem.
+func openMessageStore(t *testing.T, deleted ...int64) *testdb.Store {
+	t.Helper()
+	db, store := testdb.Open()
+	database.SetDB(db)
+	t.Cleanup(func() { database.SetDB(nil) })
+
+	store.Rows("SELECT COUNT(*) FROM user_message", []string{"count"}, []driver.Value{int64(42)})
+	calls := 0
+	store.Handle("DELETE FROM user_message", func([]driver.Value) testdb.Result {
+		calls++
+		if calls > len(deleted) {
+			return testdb.Result{Err: errors.New("unexpected DELETE")}
+		}
+		return testdb.Result{RowsAffected: deleted[calls-1]}
+	})
+	return store
+}
+
+// A contagem do --dry-run e a limpeza usam o mesmo limite: estritamente mais antigas que a
+// idade, em segundos, pelo relógio do banco.
+func TestPurgeMessagesAgeBoundary(t *testing.T) {
+	store := openMessageStore(t, 0)
+	age := 90 * 24 * time.Hour
+
+	if _, err := CountMessagesOlderThan(context.Background(), age); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PurgeMessages(context.Background(), age, 500); err != nil {
+		t.Fatal(err)
+	}
+
+	count, purge := store.Calls("SELECT COUNT(*)"), store.Calls("DELETE FROM user_message")
+	if len(count) != 1 || len(purge) != 1 {
+		t.Fatalf("got %d counts and %d deletes, want 1 of each", len(count), len(purge))
+	}
+	const boundary = "created_at < NOW() - INTERVAL ? SECOND"
+	for _, call := range []testdb.Call{count[0], purge[0]} {
+		if !strings.Contains(call.Query, boundary) {
+			t.Errorf("query %q does not filter by %q", call.Query, boundary)
+		}
+		if call.Args[0] != int64(7776000) {
+			t.Errorf("age argument = %v, want 7776000 seconds", call.Args[0])
+		}
+	}
+	if !strings.Contains(purge[0].Query, "ORDER BY created_at LIMIT ?") || purge[0].Args[1] != int64(500) {
+		t.Errorf("delete is not limited to the batch, oldest first: %q %v", purge[0].Query, purge[0].Args)
+	}
+}
+
+// A limpeza repete os lotes até um vir incompleto e soma o total apagado.
+func TestPurgeMessagesBatches(t *testing.T) {
+	cases := []struct {
+		name    string
+		deleted []int64
+		total   int64
+	}{
+		{"partial last batch", []int64{100, 100, 37}, 237},
+		{"exact multiple", []int64{100, 100, 0}, 200},
+		{"nothing to delete", []int64{0}, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := openMessageStore(t, tc.deleted...)
+			total, err := PurgeMessages(context.Background(), time.Hour, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != tc.total {
+				t.Errorf("total = %d, want %d", total, tc.total)
+			}
+			if calls := len(store.Calls("DELETE FROM user_message")); calls != len(tc.deleted) {
+				t.Errorf("%d deletes, want %d", calls, len(tc.deleted))
+			}
+		})
+	}
+}
+
+// Se um lote falha ou o comando é interrompido, o total já apagado é retornado com o erro.
+func TestPurgeMessagesStopsEarly(t *testing.T) {
+	openMessageStore(t, 100)
+	total, err := PurgeMessages(context.Background(), time.Hour, 100)
+	if err == nil || total != 100 {
+		t.Errorf("failed batch: total = %d, err = %v; want 100 and an error", total, err)
+	}
+
+	store := openMessageStore(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if total, err := PurgeMessages(ctx, time.Hour, 100); !errors.Is(err, context.Canceled) || total != 0 {
+		t.Errorf("canceled: total = %d, err = %v; want 0 and context.Canceled", total, err)
+	}
+	if calls := store.Calls("DELETE"); len(calls) > 0 {
+		t.Errorf("deleted after cancellation: %v", calls)
+	}
+}
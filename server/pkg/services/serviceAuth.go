@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"messenger-pigeon-app/config/env"
+	"messenger-pigeon-app/config/keys"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/repository"
+	"messenger-pigeon-app/pkg/tracing"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidCredentials é retornado quando o login ou a senha não conferem.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Verifica se username e email ainda estão disponíveis. Retorna os erros por campo.
+func CheckUserAvailability(ctx context.Context, user model.User) (map[string]string, error) {
+	ctx, span := tracing.Start(ctx, "services.CheckUserAvailability")
+	defer span.End()
+
+	fields := make(map[string]string)
+
+	usernameTaken, err := repository.UsernameExists(ctx, user.Username)
+	if err != nil {
+		return nil, fmt.Errorf("error checking username: %w", err)
+	}
+	if usernameTaken {
+		fields["username"] = "validation.username_taken"
+	}
+
+	emailTaken, err := repository.EmailExists(ctx, user.Email)
+	if err != nil {
+		return nil, fmt.Errorf("error checking email: %w", err)
+	}
+	if emailTaken {
+		fields["email"] = "validation.email_taken"
+	}
+
+	return fields, nil
+}
+
+// Cadastrar novo usuário com a senha protegida por bcrypt
+func RegisterUser(ctx context.Context, user model.User) (int64, error) {
+	ctx, span := tracing.Start(ctx, "services.RegisterUser")
+	defer span.End()
+
+	user.Username = strings.TrimSpace(user.Username)
+	user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+
+	hash, err := HashPassword(user.Password)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := repository.CreateUser(ctx, user, hash)
+	if err != nil {
+		return 0, fmt.Errorf("error creating user: %w", err)
+	}
+	return id, nil
+}
+
+// HashPassword gera o hash bcrypt da senha.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// Autenticar usuário por username ou email e senha. Retorna o ID do usuário.
+func AuthenticateUser(ctx context.Context, login, password string) (int, error) {
+	ctx, span := tracing.Start(ctx, "services.AuthenticateUser")
+	defer span.End()
+
+	id, hash, err := repository.GetUserCredentials(ctx, strings.TrimSpace(login))
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			// Compara com um hash qualquer para que o tempo de resposta não revele
+			// se o usuário existe
+			bcrypt.CompareHashAndPassword(unknownUserHash(), []byte(password))
+			return 0, ErrInvalidCredentials
+		}
+		return 0, fmt.Errorf("error retrieving credentials: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return 0, ErrInvalidCredentials
+	}
+	return id, nil
+}
+
+// Hash bcrypt, com o custo padrão, usado na comparação de logins inexistentes
+var unknownUserHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("unknown user"), bcrypt.DefaultCost)
+	return hash
+})
+
+// Confirmar a senha do usuário já autenticado (reautenticação em operações sensíveis)
+func VerifyPassword(ctx context.Context, userID int, password string) error {
+	ctx, span := tracing.Start(ctx, "services.VerifyPassword")
+	defer span.End()
+
+	hash, err := repository.GetPasswordHash(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("error retrieving password: %w", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// Gerar o token de acesso de curta duração aceito pelo AuthMiddleware, assinado com a
+// chave de assinatura atual (ou HS256 com SESSION_SECRET, se não houver chaves configuradas)
+func IssueAccessToken(ctx context.Context, userID int, sessionID string) (string, time.Time, error) {
+	ctx, span := tracing.Start(ctx, "services.IssueAccessToken")
+	defer span.End()
+
+	now := time.Now()
+	expiresAt := now.Add(env.Duration("ACCESS_TOKEN_TTL", 15*time.Minute))
+
+	claims := jwt.MapClaims{
+		"id":  userID,
+		"sid": sessionID,
+		"iat": now.Unix(),
+		"exp": expiresAt.Unix(),
+	}
+	// Idioma preferido do usuário, usado nas respostas no lugar do Accept-Language
+	if locale := userLocale(ctx, userID, ""); locale != "" {
+		claims["locale"] = locale
+	}
+
+	signed, err := keys.Default().Sign(claims)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error signing token: %w", err)
+	}
+	return signed, expiresAt, nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/internal/testdb"
+	"testing"
+	"time"
+)
+
+// Um login inexistente passa pela mesma comparação bcrypt que uma senha errada, para que o
+// tempo de resposta não revele quais usuários existem.
+func TestAuthenticateUserUnknownLoginRunsBcrypt(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, store := testdb.Open()
+	database.SetDB(db)
+	t.Cleanup(func() { database.SetDB(nil) })
+	store.Handle("SELECT id, password FROM user", func(args []driver.Value) testdb.Result {
+		if args[0] == "ana" {
+			return testdb.Result{Columns: []string{"id", "password"}, Rows: [][]driver.Value{{int64(7), hash}}}
+		}
+		return testdb.Result{Columns: []string{"id", "password"}}
+	})
+	unknownUserHash() // O hash fixo é gerado uma vez, fora da medição
+
+	timeLogin := func(login string) time.Duration {
+		start := time.Now()
+		_, authErr := AuthenticateUser(context.Background(), login, "wrong password")
+		if !errors.Is(authErr, ErrInvalidCredentials) {
+			t.Fatalf("AuthenticateUser(%q) error = %v, want ErrInvalidCredentials", login, authErr)
+		}
+		return time.Since(start)
+	}
+
+	known := timeLogin("ana")
+	unknown := timeLogin("nobody")
+	if unknown < known/4 {
+		t.Errorf("unknown login took %v, wrong password took %v", unknown, known)
+	}
+}
@@ -1,17 +1,22 @@
 package services
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
-	"log"
+	"log/slog"
 	"messenger-pigeon-app/internal/model"
 	"messenger-pigeon-app/pkg/repository"
+	"messenger-pigeon-app/pkg/tracing"
 	"time"
 )
 
 // Obter mensagens entre usuários e processá-las
-func GetChatMessages(user1ID, user2ID int) ([]model.UserMessage, error) {
-	messages, err := repository.GetUserMessages(user1ID, user2ID)
+func GetChatMessages(ctx context.Context, user1ID, user2ID int) ([]model.UserMessage, error) {
+	ctx, span := tracing.Start(ctx, "services.GetChatMessages")
+	defer span.End()
+
+	messages, err := repository.GetUserMessages(ctx, user1ID, user2ID)
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving messages: %w", err)
 	}
@@ -19,7 +24,7 @@ func GetChatMessages(user1ID, user2ID int) ([]model.UserMessage, error) {
 	for i, message := range messages {
 		createdAt, err := time.Parse("2006-01-02 15:04:05", message.CreatedAt)
 		if err != nil {
-			log.Println("Failed to parse created_at:", err)
+			slog.WarnContext(ctx, "Failed to parse created_at", "message_id", message.MessageID, "error", err)
 			continue
 		}
 		messages[i].CreatedAt = createdAt.Format("15:04")
@@ -34,13 +39,19 @@ func GetChatMessages(user1ID, user2ID int) ([]model.UserMessage, error) {
 }
 
 // Salvar nova mensagem
-func SendMessage(message model.UserMessage) (int64, error) {
-	return repository.SaveMessage(message)
+func SendMessage(ctx context.Context, message model.UserMessage) (int64, error) {
+	ctx, span := tracing.Start(ctx, "services.SendMessage")
+	defer span.End()
+
+	return repository.SaveMessage(ctx, message)
 }
 
 // Obter informações de parceiro de chat
-func GetChatInfos(userID int) (string, string, string, error) {
-	name, username, icon, err := repository.GetUserInfo(userID)
+func GetChatInfos(ctx context.Context, userID int) (string, string, string, error) {
+	ctx, span := tracing.Start(ctx, "services.GetChatInfos")
+	defer span.End()
+
+	name, username, icon, err := repository.GetUserInfo(ctx, userID)
 	if err != nil {
 		return "", "", "", fmt.Errorf("error retrieving chat partner info: %w", err)
 	}
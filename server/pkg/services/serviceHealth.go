@@ -0,0 +1,21 @@
+package services
+
+import (
+	"context"
+	"messenger-pigeon-app/config/database"
+)
+
+// PingDatabase verifica se o banco de dados responde.
+func PingDatabase(ctx context.Context) error {
+	return database.GetDB().PingContext(ctx)
+}
+
+// PendingMigrations retorna as migrações ainda não aplicadas no banco.
+func PendingMigrations(ctx context.Context) ([]string, error) {
+	return database.PendingMigrations(ctx)
+}
+
+// BlockedMigration retorna a migração deixada pendente por linhas repetidas, ou "" se nenhuma.
+func BlockedMigration() string {
+	return database.BlockedMigration()
+}
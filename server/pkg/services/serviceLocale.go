@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/repository"
+	"messenger-pigeon-app/pkg/tracing"
+)
+
+// ErrUnsupportedLocale é retornado quando o idioma pedido não está no catálogo.
+var ErrUnsupportedLocale = errors.New("unsupported locale")
+
+// Definir o idioma preferido do usuário. Retorna o idioma normalizado (ex.: "pt" -> "pt-BR").
+// Os tokens de acesso emitidos a partir de agora trazem o novo idioma.
+func SetUserLocale(ctx context.Context, userID int, locale string) (string, error) {
+	ctx, span := tracing.Start(ctx, "services.SetUserLocale")
+	defer span.End()
+
+	matched, ok := i18n.Match(locale)
+	if !ok {
+		return "", ErrUnsupportedLocale
+	}
+	if err := repository.UpdateUserLocale(ctx, userID, matched); err != nil {
+		return "", fmt.Errorf("error updating locale: %w", err)
+	}
+	return matched, nil
+}
+
+// Obter o idioma do usuário para textos enviados fora de uma requisição (ex.: emails).
+// Sem preferência salva, usa o fallback (normalmente o idioma da requisição).
+func userLocale(ctx context.Context, userID int, fallback string) string {
+	locale, err := repository.GetUserLocale(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error retrieving user locale", "user_id", userID, "error", err)
+		return fallback
+	}
+	if matched, ok := i18n.Match(locale); ok {
+		return matched
+	}
+	return fallback
+}
+
+// PreferredLocale retorna o idioma salvo pelo usuário, ou "" se ele não tiver preferência
+// (ou se ela não puder ser lida), para quem autentica sem o claim "locale" do token.
+func PreferredLocale(ctx context.Context, userID int) string {
+	ctx, span := tracing.Start(ctx, "services.PreferredLocale")
+	defer span.End()
+
+	return userLocale(ctx, userID, "")
+}
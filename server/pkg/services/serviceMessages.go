@@ -1,16 +1,21 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"messenger-pigeon-app/config/database"
 	"messenger-pigeon-app/internal/model"
 	"messenger-pigeon-app/pkg/repository"
+	"messenger-pigeon-app/pkg/tracing"
 )
 
-func GetUserChats(userID int64) ([]model.UserMessage, error) {
+func GetUserChats(ctx context.Context, userID int64) ([]model.UserMessage, error) {
+	ctx, span := tracing.Start(ctx, "services.GetUserChats")
+	defer span.End()
+
 	db := database.GetDB()
 
-	chats, err := repository.FetchUserChats(db, userID)
+	chats, err := repository.FetchUserChats(ctx, db, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch user chats: %w", err)
 	}
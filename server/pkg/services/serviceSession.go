@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"messenger-pigeon-app/config/env"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/repository"
+	"messenger-pigeon-app/pkg/tracing"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRefreshToken é retornado quando o refresh token não corresponde a uma sessão ativa.
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// RefreshTokenReusedError indica que um refresh token já substituído foi apresentado novamente.
+// A sessão é revogada, pois o token provavelmente foi roubado.
+type RefreshTokenReusedError struct {
+	UserID    int
+	SessionID string
+}
+
+func (e *RefreshTokenReusedError) Error() string {
+	return fmt.Sprintf("refresh token reused for session %s", e.SessionID)
+}
+
+// AuthTokens é o par de tokens entregue no login e a cada renovação.
+type AuthTokens struct {
+	AccessToken      string    `json:"token"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	RefreshToken     string    `json:"refreshToken"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+	SessionID        string    `json:"sessionId"`
+}
+
+func refreshTokenTTL() time.Duration {
+	return env.Duration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+}
+
+// Criar uma sessão para o usuário e emitir seus tokens
+func StartSession(ctx context.Context, userID int, device, ip string) (AuthTokens, error) {
+	ctx, span := tracing.Start(ctx, "services.StartSession")
+	defer span.End()
+
+	sessionID, err := randomHex(16)
+	if err != nil {
+		return AuthTokens{}, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return AuthTokens{}, err
+	}
+
+	ttl := refreshTokenTTL()
+	session := model.Session{ID: sessionID, UserID: userID, Device: truncate(device, 255), IP: ip}
+	if err := repository.CreateSession(ctx, session, hashToken(secret), ttl); err != nil {
+		return AuthTokens{}, fmt.Errorf("error creating session: %w", err)
+	}
+
+	return issueTokens(ctx, userID, sessionID, secret, ttl)
+}
+
+// Trocar um refresh token por um novo par de tokens. O refresh token apresentado deixa de valer.
+func RefreshSession(ctx context.Context, refreshToken string) (AuthTokens, error) {
+	ctx, span := tracing.Start(ctx, "services.RefreshSession")
+	defer span.End()
+
+	sessionID, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return AuthTokens{}, ErrInvalidRefreshToken
+	}
+
+	userID, storedHash, err := repository.GetActiveSessionToken(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return AuthTokens{}, ErrInvalidRefreshToken
+		}
+		return AuthTokens{}, fmt.Errorf("error retrieving session: %w", err)
+	}
+
+	secretHash := hashToken(secret)
+	if subtle.ConstantTimeCompare([]byte(secretHash), []byte(storedHash)) != 1 {
+		// O ID da sessão não é secreto: só um segredo que já foi válido nesta sessão indica roubo.
+		// Qualquer outro é apenas inválido e não pode derrubar a sessão de quem a conhece.
+		rotated, err := repository.IsRotatedSessionToken(ctx, sessionID, secretHash)
+		if err != nil {
+			return AuthTokens{}, fmt.Errorf("error checking rotated refresh token: %w", err)
+		}
+		if !rotated {
+			return AuthTokens{}, ErrInvalidRefreshToken
+		}
+		if _, err := repository.RevokeSession(ctx, userID, sessionID); err != nil {
+			return AuthTokens{}, fmt.Errorf("error revoking session: %w", err)
+		}
+		return AuthTokens{}, &RefreshTokenReusedError{UserID: userID, SessionID: sessionID}
+	}
+
+	newSecret, err := randomHex(32)
+	if err != nil {
+		return AuthTokens{}, err
+	}
+
+	ttl := refreshTokenTTL()
+	if err := repository.RotateSessionToken(ctx, sessionID, storedHash, hashToken(newSecret), ttl); err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			// Outra requisição renovou a sessão ao mesmo tempo
+			return AuthTokens{}, ErrInvalidRefreshToken
+		}
+		return AuthTokens{}, fmt.Errorf("error rotating session: %w", err)
+	}
+
+	return issueTokens(ctx, userID, sessionID, newSecret, ttl)
+}
+
+// Verificar se a sessão do token de acesso ainda está ativa
+func IsSessionActive(ctx context.Context, sessionID string, userID int) (bool, error) {
+	ctx, span := tracing.Start(ctx, "services.IsSessionActive")
+	defer span.End()
+
+	return repository.IsSessionActive(ctx, sessionID, userID)
+}
+
+// Listar as sessões ativas do usuário, marcando a sessão atual
+func ListSessions(ctx context.Context, userID int, currentSessionID string) ([]model.Session, error) {
+	ctx, span := tracing.Start(ctx, "services.ListSessions")
+	defer span.End()
+
+	sessions, err := repository.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing sessions: %w", err)
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// Revogar uma sessão do usuário. Retorna false se ela não existir ou já estiver revogada.
+func RevokeSession(ctx context.Context, userID int, sessionID string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "services.RevokeSession")
+	defer span.End()
+
+	return repository.RevokeSession(ctx, userID, sessionID)
+}
+
+// Revogar todas as outras sessões do usuário. Retorna os IDs revogados.
+func RevokeOtherSessions(ctx context.Context, userID int, currentSessionID string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "services.RevokeOtherSessions")
+	defer span.End()
+
+	return repository.RevokeSessionsExcept(ctx, userID, currentSessionID)
+}
+
+func issueTokens(ctx context.Context, userID int, sessionID, secret string, ttl time.Duration) (AuthTokens, error) {
+	accessToken, expiresAt, err := IssueAccessToken(ctx, userID, sessionID)
+	if err != nil {
+		return AuthTokens{}, err
+	}
+
+	return AuthTokens{
+		AccessToken:      accessToken,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     sessionID + "." + secret,
+		RefreshExpiresAt: time.Now().Add(ttl),
+		SessionID:        sessionID,
+	}, nil
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
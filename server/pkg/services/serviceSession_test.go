@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/config/keys"
+	"messenger-pigeon-app/internal/testdb"
+	"sync"
+	"testing"
+)
+
+// sessionTable simula a sessão "s1" do usuário 7 nas tabelas user_session e
+// user_session_rotated_token.
+type sessionTable struct {
+	mu      sync.Mutex
+	hash    string
+	rotated map[string]bool
+	revoked bool
+}
+
+func openSessionTable(t *testing.T, secret string) *sessionTable {
+	t.Helper()
+	t.Setenv("SESSION_SECRET", "test-secret")
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_JWKS_URL", "")
+	if err := keys.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	table := &sessionTable{hash: hashToken(secret), rotated: map[string]bool{}}
+	db, store := testdb.Open()
+	database.SetDB(db)
+	t.Cleanup(func() { database.SetDB(nil) })
+
+	store.Handle("SELECT user_id, refresh_token_hash FROM user_session", func(args []driver.Value) testdb.Result {
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		if args[0] != "s1" || table.revoked {
+			return testdb.Result{Columns: []string{"user_id", "refresh_token_hash"}}
+		}
+		return testdb.Result{Columns: []string{"user_id", "refresh_token_hash"}, Rows: [][]driver.Value{{int64(7), table.hash}}}
+	})
+	store.Handle("UPDATE user_session SET refresh_token_hash", func(args []driver.Value) testdb.Result {
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		if table.revoked || args[3] != table.hash {
+			return testdb.Result{}
+		}
+		table.hash = args[0].(string)
+		return testdb.Result{RowsAffected: 1}
+	})
+	store.Handle("INSERT INTO user_session_rotated_token", func(args []driver.Value) testdb.Result {
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		table.rotated[args[1].(string)] = true
+		return testdb.Result{RowsAffected: 1}
+	})
+	store.Handle("FROM user_session_rotated_token", func(args []driver.Value) testdb.Result {
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		return testdb.Result{Columns: []string{"rotated"}, Rows: [][]driver.Value{{table.rotated[args[1].(string)]}}}
+	})
+	store.Handle("UPDATE user_session SET revoked_at", func(args []driver.Value) testdb.Result {
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		table.revoked = true
+		return testdb.Result{RowsAffected: 1}
+	})
+	store.Rows("SELECT locale FROM user", []string{"locale"}, []driver.Value{nil})
+	return table
+}
+
+func (s *sessionTable) isRevoked() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.revoked
+}
+
+// Um segredo que nunca pertenceu à sessão é só inválido: quem conhece o ID da sessão
+// não consegue revogá-la.
+func TestRefreshSessionUnknownSecretKeepsSession(t *testing.T) {
+	table := openSessionTable(t, "current")
+
+	_, err := RefreshSession(context.Background(), "s1.guessed")
+	if !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("RefreshSession() error = %v, want ErrInvalidRefreshToken", err)
+	}
+	if table.isRevoked() {
+		t.Fatal("session was revoked by an unknown secret")
+	}
+
+	if _, err := RefreshSession(context.Background(), "s1.current"); err != nil {
+		t.Fatalf("RefreshSession() with the current secret: %v", err)
+	}
+}
+
+// Reapresentar um segredo já substituído revoga a sessão.
+func TestRefreshSessionRotatedSecretRevokesSession(t *testing.T) {
+	table := openSessionTable(t, "first")
+
+	tokens, err := RefreshSession(context.Background(), "s1.first")
+	if err != nil {
+		t.Fatalf("RefreshSession(): %v", err)
+	}
+	if tokens.RefreshToken == "s1.first" {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	_, err = RefreshSession(context.Background(), "s1.first")
+	var reused *RefreshTokenReusedError
+	if !errors.As(err, &reused) {
+		t.Fatalf("RefreshSession() error = %v, want RefreshTokenReusedError", err)
+	}
+	if reused.UserID != 7 || reused.SessionID != "s1" {
+		t.Fatalf("reused = %+v, want user 7 session s1", reused)
+	}
+	if !table.isRevoked() {
+		t.Fatal("session was not revoked after reuse")
+	}
+	if _, err := RefreshSession(context.Background(), tokens.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("RefreshSession() after revocation error = %v, want ErrInvalidRefreshToken", err)
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"messenger-pigeon-app/config/env"
+	"messenger-pigeon-app/pkg/tracing"
+	"sync"
+	"time"
+)
+
+// Tickets de uso único trocados por um usuário autenticado antes de abrir um WebSocket,
+// já que navegadores não conseguem enviar o cabeçalho Authorization no handshake.
+var (
+	ticketsMu sync.Mutex
+	tickets   = make(map[string]webSocketTicket)
+)
+
+type webSocketTicket struct {
+	userID    int
+	sessionID string
+	expiresAt time.Time
+}
+
+// IssueWebSocketTicket gera um ticket válido por WS_TICKET_TTL (padrão 30s), vinculado à sessão do usuário.
+func IssueWebSocketTicket(userID int, sessionID string) (string, time.Time, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate ticket: %w", err)
+	}
+	ticket := base64.RawURLEncoding.EncodeToString(buf)
+	expiresAt := time.Now().Add(env.Duration("WS_TICKET_TTL", 30*time.Second))
+
+	ticketsMu.Lock()
+	defer ticketsMu.Unlock()
+
+	// Remove os tickets expirados que nunca foram usados.
+	now := time.Now()
+	for key, t := range tickets {
+		if now.After(t.expiresAt) {
+			delete(tickets, key)
+		}
+	}
+	tickets[ticket] = webSocketTicket{userID: userID, sessionID: sessionID, expiresAt: expiresAt}
+
+	return ticket, expiresAt, nil
+}
+
+// RedeemWebSocketTicket consome o ticket e retorna o usuário e a sessão donos dele.
+func RedeemWebSocketTicket(ctx context.Context, ticket string) (int, string, bool) {
+	ctx, span := tracing.Start(ctx, "services.RedeemWebSocketTicket")
+	defer span.End()
+
+	ticketsMu.Lock()
+	t, ok := tickets[ticket]
+	delete(tickets, ticket)
+	ticketsMu.Unlock()
+
+	if !ok || time.Now().After(t.expiresAt) {
+		return 0, "", false
+	}
+
+	// A sessão pode ter sido revogada depois que o ticket foi emitido
+	active, err := IsSessionActive(ctx, t.sessionID, t.userID)
+	if err != nil || !active {
+		return 0, "", false
+	}
+	return t.userID, t.sessionID, true
+}
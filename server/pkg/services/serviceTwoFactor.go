@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"messenger-pigeon-app/config/env"
+	"messenger-pigeon-app/config/keys"
+	"messenger-pigeon-app/internal/totp"
+	"messenger-pigeon-app/pkg/repository"
+	"messenger-pigeon-app/pkg/tracing"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Tipo do token parcial emitido quando o login ainda depende do segundo fator.
+const mfaTokenType = "mfa"
+
+// Quantidade de códigos de recuperação gerados ao ativar o 2FA.
+const recoveryCodeCount = 10
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotPending     = errors.New("two-factor setup has not been started")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrInvalidMFAToken         = errors.New("invalid mfa token")
+)
+
+// TwoFactorSetup é o segredo entregue ao usuário para cadastrar no aplicativo autenticador.
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauthUri"`
+}
+
+// Verificar se o usuário tem o 2FA ativo
+func IsTwoFactorEnabled(ctx context.Context, userID int) (bool, error) {
+	ctx, span := tracing.Start(ctx, "services.IsTwoFactorEnabled")
+	defer span.End()
+
+	_, enabled, err := repository.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("error retrieving two-factor settings: %w", err)
+	}
+	return enabled, nil
+}
+
+// Gerar um novo segredo TOTP pendente de confirmação
+func BeginTwoFactorSetup(ctx context.Context, userID int) (TwoFactorSetup, error) {
+	ctx, span := tracing.Start(ctx, "services.BeginTwoFactorSetup")
+	defer span.End()
+
+	enabled, err := IsTwoFactorEnabled(ctx, userID)
+	if err != nil {
+		return TwoFactorSetup{}, err
+	}
+	if enabled {
+		return TwoFactorSetup{}, ErrTwoFactorAlreadyEnabled
+	}
+
+	username, err := repository.GetUsernameByID(ctx, userID)
+	if err != nil {
+		return TwoFactorSetup{}, fmt.Errorf("error retrieving username: %w", err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return TwoFactorSetup{}, err
+	}
+	if err := repository.SetPendingTOTPSecret(ctx, userID, secret); err != nil {
+		return TwoFactorSetup{}, fmt.Errorf("error storing totp secret: %w", err)
+	}
+
+	return TwoFactorSetup{
+		Secret: secret,
+		URI:    totp.URI(env.String("TOTP_ISSUER", "Messenger Pigeon"), username, secret),
+	}, nil
+}
+
+// Confirmar o cadastro com um código do aplicativo. Retorna os códigos de recuperação,
+// que só são exibidos neste momento.
+func ConfirmTwoFactor(ctx context.Context, userID int, code string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "services.ConfirmTwoFactor")
+	defer span.End()
+
+	secret, enabled, err := repository.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving two-factor settings: %w", err)
+	}
+	if enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if secret == "" {
+		return nil, ErrTwoFactorNotPending
+	}
+
+	if err := checkTOTP(ctx, userID, secret, code); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = generateRecoveryCode(); err != nil {
+			return nil, err
+		}
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+
+	if err := repository.EnableTOTP(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("error enabling two-factor: %w", err)
+	}
+	return codes, nil
+}
+
+// Verificar o segundo fator do usuário: um código TOTP ou um código de recuperação de uso único
+func VerifySecondFactor(ctx context.Context, userID int, code, recoveryCode string) error {
+	ctx, span := tracing.Start(ctx, "services.VerifySecondFactor")
+	defer span.End()
+
+	secret, enabled, err := repository.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("error retrieving two-factor settings: %w", err)
+	}
+	if !enabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if recoveryCode != "" {
+		used, err := repository.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			return fmt.Errorf("error using recovery code: %w", err)
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	return checkTOTP(ctx, userID, secret, code)
+}
+
+// Desativar o 2FA depois de confirmar a senha e o segundo fator
+func DisableTwoFactor(ctx context.Context, userID int, password, code, recoveryCode string) error {
+	ctx, span := tracing.Start(ctx, "services.DisableTwoFactor")
+	defer span.End()
+
+	if err := VerifyPassword(ctx, userID, password); err != nil {
+		return err
+	}
+	if err := VerifySecondFactor(ctx, userID, code, recoveryCode); err != nil {
+		return err
+	}
+	if err := repository.DisableTOTP(ctx, userID); err != nil {
+		return fmt.Errorf("error disabling two-factor: %w", err)
+	}
+	return nil
+}
+
+// Gerar o token parcial trocado pelos tokens da sessão após o segundo fator
+func IssueMFAToken(userID int) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(env.Duration("MFA_TOKEN_TTL", 5*time.Minute))
+
+	signed, err := keys.Default().Sign(jwt.MapClaims{
+		"id":  userID,
+		"typ": mfaTokenType,
+		"iat": now.Unix(),
+		"exp": expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error signing mfa token: %w", err)
+	}
+	return signed, expiresAt, nil
+}
+
+// Validar o token parcial e retornar o ID do usuário
+func ParseMFAToken(tokenString string) (int, error) {
+	token, err := jwt.Parse(tokenString, keys.Default().Keyfunc)
+	if err != nil || !token.Valid {
+		return 0, ErrInvalidMFAToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != mfaTokenType {
+		return 0, ErrInvalidMFAToken
+	}
+	if _, ok := claims["exp"].(float64); !ok {
+		return 0, ErrInvalidMFAToken
+	}
+
+	id, ok := claims["id"].(float64)
+	if !ok || id <= 0 {
+		return 0, ErrInvalidMFAToken
+	}
+	return int(id), nil
+}
+
+func checkTOTP(ctx context.Context, userID int, secret, code string) error {
+	step, ok := totp.Validate(secret, code, time.Now(), 1)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	fresh, err := repository.ConsumeTOTPStep(ctx, userID, step)
+	if err != nil {
+		return fmt.Errorf("error consuming totp code: %w", err)
+	}
+	if !fresh {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
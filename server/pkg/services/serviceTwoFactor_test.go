@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/internal/testdb"
+	"messenger-pigeon-app/internal/totp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Segredo TOTP usado nos testes, em base32.
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// twoFactorTable simula as colunas de 2FA do usuário 7, com a senha "correct horse", e a
+// tabela user_recovery_code.
+type twoFactorTable struct {
+	mu       sync.Mutex
+	secret   string
+	enabled  bool
+	lastStep int64
+	recovery map[string]bool // hash -> usado
+}
+
+func openTwoFactorTable(t *testing.T) *twoFactorTable {
+	t.Helper()
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := &twoFactorTable{recovery: map[string]bool{}}
+	db, store := testdb.Open()
+	database.SetDB(db)
+	t.Cleanup(func() { database.SetDB(nil) })
+
+	store.Handle("SELECT totp_secret, totp_enabled FROM user", func([]driver.Value) testdb.Result {
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		var secret driver.Value
+		if table.secret != "" {
+			secret = table.secret
+		}
+		return testdb.Result{Columns: []string{"totp_secret", "totp_enabled"}, Rows: [][]driver.Value{{secret, table.enabled}}}
+	})
+	store.Rows("SELECT password FROM user", []string{"password"}, []driver.Value{hash})
+	store.Rows("SELECT username FROM user", []string{"username"}, []driver.Value{"ana"})
+	store.Handle("UPDATE user SET totp_secret = ?", func(args []driver.Value) testdb.Result {
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		table.secret, table.lastStep = args[0].(string), 0
+		return testdb.Result{RowsAffected: 1}
+	})
+	store.Handle("UPDATE user SET totp_last_step", func(args []driver.Value) testdb.Result {
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		step := args[0].(int64)
+		if table.lastStep >= step {
+			return testdb.Result{}
+		}
+		table.lastStep = step
+		return testdb.Result{RowsAffected: 1}
+	})
+	store.Handle("UPDATE user SET totp_enabled = TRUE", func([]driver.Value) testdb.Result {
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		table.enabled = true
+		return testdb.Result{RowsAffected: 1}
+	})
+	store.Handle("UPDATE user SET totp_secret = NULL", func([]driver.Value) testdb.Result {
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		table.secret, table.enabled, table.lastStep = "", false, 0
+		return testdb.Result{RowsAffected: 1}
+	})
+	store.Handle("DELETE FROM user_recovery_code", func([]driver.Value) testdb.Result {
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		table.recovery = map[string]bool{}
+		return testdb.Result{}
+	})
+	store.Handle("INSERT INTO user_recovery_code", func(args []driver.Value) testdb.Result {
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		table.recovery[args[1].(string)] = false
+		return testdb.Result{RowsAffected: 1}
+	})
+	store.Handle("UPDATE user_recovery_code SET used_at", func(args []driver.Value) testdb.Result {
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		used, ok := table.recovery[args[1].(string)]
+		if !ok || used {
+			return testdb.Result{}
+		}
+		table.recovery[args[1].(string)] = true
+		return testdb.Result{RowsAffected: 1}
+	})
+	return table
+}
+
+// enable ativa o 2FA com o segredo de teste e devolve os códigos de recuperação.
+func (s *twoFactorTable) enable(t *testing.T) []string {
+	t.Helper()
+	s.mu.Lock()
+	s.secret = testTOTPSecret
+	s.mu.Unlock()
+	// Um intervalo anterior, para não consumir o código atual usado pelos testes
+	codes, err := ConfirmTwoFactor(context.Background(), 7, codeAt(t, time.Now().Add(-totp.Period)))
+	if err != nil {
+		t.Fatalf("ConfirmTwoFactor(): %v", err)
+	}
+	return codes
+}
+
+func (s *twoFactorTable) isEnabled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enabled
+}
+
+func codeAt(t *testing.T, at time.Time) string {
+	t.Helper()
+	code, err := totp.Code(testTOTPSecret, totp.Step(at))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// Um código TOTP vale uma vez: reapresentá-lo, ou usar um de intervalo anterior ao último
+// aceito, falha.
+func TestVerifySecondFactorRejectsReplay(t *testing.T) {
+	table := openTwoFactorTable(t)
+	table.enable(t)
+
+	code := codeAt(t, time.Now())
+	if err := VerifySecondFactor(context.Background(), 7, code, ""); err != nil {
+		t.Fatalf("first use of the code: %v", err)
+	}
+	if err := VerifySecondFactor(context.Background(), 7, code, ""); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("replayed code: error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	// O código do intervalo anterior ainda está na janela de tolerância, mas é mais antigo
+	if err := VerifySecondFactor(context.Background(), 7, codeAt(t, time.Now().Add(-totp.Period)), ""); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("older code: error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+}
+
+// Os códigos de recuperação são guardados só como hash e cada um vale uma vez, com ou sem
+// o hífen e em qualquer caixa.
+func TestRecoveryCodesAreHashedAndSingleUse(t *testing.T) {
+	table := openTwoFactorTable(t)
+	codes := table.enable(t)
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("%d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+
+	table.mu.Lock()
+	for hash := range table.recovery {
+		for _, code := range codes {
+			if strings.Contains(hash, normalizeRecoveryCode(code)) {
+				t.Errorf("recovery code %s stored in clear", code)
+			}
+		}
+	}
+	if len(table.recovery) != recoveryCodeCount {
+		t.Errorf("%d recovery codes stored, want %d", len(table.recovery), recoveryCodeCount)
+	}
+	table.mu.Unlock()
+
+	typed := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+	if err := VerifySecondFactor(context.Background(), 7, "", typed); err != nil {
+		t.Fatalf("first use of the recovery code: %v", err)
+	}
+	if err := VerifySecondFactor(context.Background(), 7, "", codes[0]); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("reused recovery code: error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	if err := VerifySecondFactor(context.Background(), 7, "", codes[1]); err != nil {
+		t.Fatalf("another recovery code: %v", err)
+	}
+}
+
+// Desativar o 2FA exige a senha e o segundo fator; sem eles, o 2FA continua ativo.
+func TestDisableTwoFactorRequiresReauthentication(t *testing.T) {
+	table := openTwoFactorTable(t)
+	codes := table.enable(t)
+
+	err := DisableTwoFactor(context.Background(), 7, "wrong password", codeAt(t, time.Now()), "")
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("wrong password: error = %v, want ErrInvalidCredentials", err)
+	}
+	err = DisableTwoFactor(context.Background(), 7, "correct horse", "000000", "")
+	if !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("wrong code: error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	if !table.isEnabled() {
+		t.Fatal("two-factor disabled without reauthentication")
+	}
+
+	if err := DisableTwoFactor(context.Background(), 7, "correct horse", "", codes[0]); err != nil {
+		t.Fatalf("DisableTwoFactor(): %v", err)
+	}
+	if table.isEnabled() {
+		t.Fatal("two-factor still enabled")
+	}
+}
@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"messenger-pigeon-app/config/env"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName é o nome do serviço nos traces, se OTEL_SERVICE_NAME não estiver definido.
+const ServiceName = "messenger-pigeon"
+
+// Nome do escopo de instrumentação dos spans criados pela aplicação
+const instrumentationName = "messenger-pigeon-app"
+
+var tracer = otel.Tracer(instrumentationName)
+
+// Initialize configura o exportador escolhido em OTEL_TRACES_EXPORTER: "otlp" (protocolo em
+// OTEL_EXPORTER_OTLP_PROTOCOL, "http/protobuf" ou "grpc", e endpoint nas variáveis
+// OTEL_EXPORTER_OTLP_* padrão), "console" (JSON na saída padrão) ou "none" (padrão, só
+// propaga o contexto recebido). A amostragem segue OTEL_TRACES_SAMPLER. Retorna a função que
+// envia os spans pendentes no encerramento.
+func Initialize() (shutdown func(context.Context) error, err error) {
+	// W3C Trace Context e Baggage, nos cabeçalhos HTTP, no metadata do gRPC e nos eventos WebSocket
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, err := newExporter(strings.ToLower(env.String("OTEL_TRACES_EXPORTER", "none")))
+	if err != nil || exporter == nil {
+		return func(context.Context) error { return nil }, err
+	}
+
+	// O OTEL_SERVICE_NAME e o OTEL_RESOURCE_ATTRIBUTES têm prioridade sobre o nome padrão
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil && !errors.Is(err, resource.ErrPartialResource) {
+		return nil, fmt.Errorf("error creating trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	slog.Info("Tracing enabled", "exporter", exporterName(exporter))
+	return provider.Shutdown, nil
+}
+
+func newExporter(name string) (sdktrace.SpanExporter, error) {
+	switch name {
+	case "none", "":
+		return nil, nil
+	case "console", "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		protocol := env.String("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", env.String("OTEL_EXPORTER_OTLP_PROTOCOL", "http/protobuf"))
+		switch protocol {
+		case "grpc":
+			return otlptracegrpc.New(context.Background())
+		case "http/protobuf":
+			return otlptracehttp.New(context.Background())
+		default:
+			return nil, fmt.Errorf("unsupported OTLP protocol %q", protocol)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_TRACES_EXPORTER %q", name)
+	}
+}
+
+func exporterName(exporter sdktrace.SpanExporter) string {
+	if _, ok := exporter.(*stdouttrace.Exporter); ok {
+		return "stdout"
+	}
+	return "OTLP"
+}
+
+// Start abre um span filho do span do contexto.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, opts...)
+}
+
+// Fail marca o span com o erro, se houver, e o retorna.
+func Fail(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// Inject retorna o contexto de trace de ctx (traceparent, tracestate e baggage) como mapa,
+// para acompanhar eventos enviados fora do HTTP. Retorna nil se não houver span.
+func Inject(ctx context.Context) map[string]string {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return nil
+	}
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+// Extract retoma em ctx o contexto de trace guardado por Inject.
+func Extract(ctx context.Context, meta map[string]string) context.Context {
+	if len(meta) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(meta))
+}
@@ -0,0 +1,357 @@
+package websockets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/logging"
+	"messenger-pigeon-app/pkg/ratelimit"
+	"messenger-pigeon-app/pkg/repository"
+	"messenger-pigeon-app/pkg/tracing"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tamanho do buffer de saída de cada conexão.
+const clientSendBuffer = 64
+
+// Client representa uma conexão WebSocket registrada. Todas as escritas, inclusive os
+// pings, passam pela goroutine de escrita, já que o gorilla/websocket não permite
+// escritas concorrentes na mesma conexão.
+type Client struct {
+	conn         *websocket.Conn
+	id           string       // Id da conexão nos logs
+	logger       *slog.Logger // Logger com o id da conexão, o usuário e o id da requisição do handshake
+	userID       int64
+	sessionID    string
+	opened       time.Time
+	codec        Codec  // Codificação do protocolo versionado; nil no protocolo legado
+	locale       string // Idioma das mensagens de erro enviadas ao cliente
+	heartbeat    HeartbeatConfig
+	transport    TransportConfig
+	send         chan interface{}
+	done         chan struct{}
+	closeOnce    sync.Once
+	lastActivity atomic.Int64 // Unix nano do último tráfego de mensagens
+
+	sender *model.UserMessage // Nome e ícone do dono da conexão, para o protocolo legado
+}
+
+// NewClient cria o cliente e inicia sua goroutine de escrita. sessionID identifica a
+// sessão de login que abriu a conexão, para que ela seja fechada quando a sessão for revogada.
+// O formato dos frames segue o subprotocolo negociado no handshake. ctx é o da requisição do
+// handshake, cujo id acompanha os logs da conexão.
+func NewClient(ctx context.Context, conn *websocket.Conn, userID int64, sessionID, locale string) *Client {
+	id := logging.NewID()
+	client := &Client{
+		conn:      conn,
+		id:        id,
+		opened:    time.Now(),
+		logger:    connectionLogger(ctx, id, userID).With("subprotocol", conn.Subprotocol()),
+		userID:    userID,
+		sessionID: sessionID,
+		codec:     codecs[conn.Subprotocol()],
+		locale:    locale,
+		heartbeat: heartbeat,
+		transport: transport,
+		send:      make(chan interface{}, clientSendBuffer),
+		done:      make(chan struct{}),
+	}
+	// Frames acima do limite fazem a leitura falhar e fecham a conexão com o código 1009
+	conn.SetReadLimit(transport.MaxMessageSize)
+	if transport.Compression {
+		// Sem efeito se o cliente não negociou permessage-deflate
+		conn.SetCompressionLevel(transport.CompressionLevel)
+	}
+	client.touch()
+	client.logger.Debug("Connection opened")
+	go client.writeLoop()
+	return client
+}
+
+// connectionLogger cria o logger de uma conexão, com o id da requisição que a abriu.
+func connectionLogger(ctx context.Context, id string, userID int64) *slog.Logger {
+	logger := slog.With("conn_id", id, "user_id", userID)
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		logger = logger.With("request_id", requestID)
+	}
+	return logger
+}
+
+// ID retorna o id da conexão.
+func (c *Client) ID() string {
+	return c.id
+}
+
+// UserID retorna o ID do usuário dono da conexão.
+func (c *Client) UserID() int64 {
+	return c.userID
+}
+
+// SessionID retorna a sessão de login que abriu a conexão.
+func (c *Client) SessionID() string {
+	return c.sessionID
+}
+
+// Info descreve a conexão para os diagnósticos.
+func (c *Client) Info() ConnectionInfo {
+	lastActivity := time.Unix(0, c.lastActivity.Load())
+	return ConnectionInfo{
+		ID:           c.id,
+		SessionID:    c.sessionID,
+		Transport:    "websocket",
+		Subprotocol:  c.conn.Subprotocol(),
+		OpenedAt:     c.opened,
+		LastActivity: &lastActivity,
+	}
+}
+
+// Versioned informa se a conexão usa o protocolo versionado, com os frames em Envelope.
+func (c *Client) Versioned() bool {
+	return c.codec != nil
+}
+
+// Send enfileira um payload para a conexão sem bloquear. Retorna false se a conexão
+// estiver fechada ou com o buffer cheio.
+func (c *Client) Send(payload interface{}) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+
+	select {
+	case c.send <- payload:
+		return true
+	default:
+		c.logger.Warn("Send buffer full, dropping payload")
+		return false
+	}
+}
+
+// Close encerra a goroutine de escrita e fecha a conexão. Pode ser chamado mais de uma vez.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+		c.logger.Debug("Connection closed")
+	})
+}
+
+// Done é fechado quando o cliente é encerrado.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// touch registra tráfego de mensagens, adiando a detecção de ociosidade.
+func (c *Client) touch() {
+	c.lastActivity.Store(time.Now().UnixNano())
+}
+
+func (c *Client) idleFor(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, c.lastActivity.Load()))
+}
+
+// ReadMessages lê as mensagens da conexão até que ela seja encerrada, repassando cada
+// uma para handle. No protocolo versionado, os eventos são tratados pelo próprio Client.
+// Cada mensagem ou pong recebido estende o prazo de leitura.
+func (c *Client) ReadMessages(handle func(context.Context, model.UserMessage)) {
+	defer c.Close()
+
+	c.conn.SetReadDeadline(time.Now().Add(c.heartbeat.PongWait))
+	c.conn.SetPongHandler(func(appData string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(c.heartbeat.PongWait))
+	})
+
+	for {
+		data, err := c.readMessage()
+		if errors.Is(err, websocket.ErrReadLimit) {
+			c.logger.Warn("Closing connection: inbound message too large", "limit_bytes", c.transport.MaxMessageSize)
+			return
+		}
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				c.logger.Warn("Error receiving message", "error", err)
+			}
+			return
+		}
+
+		c.conn.SetReadDeadline(time.Now().Add(c.heartbeat.PongWait))
+		c.touch()
+
+		// Clientes que excedem o limite de frames são desconectados
+		if allowed, _ := ratelimit.Default().Allow(ratelimit.ClassWSFrame, int(c.userID), ""); !allowed {
+			c.logger.Warn("Closing connection: inbound frame rate exceeded")
+			c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "rate limit exceeded"),
+				time.Now().Add(c.heartbeat.WriteWait))
+			return
+		}
+
+		if c.Versioned() {
+			c.handleEvent(data)
+			continue
+		}
+
+		var msg model.UserMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.logger.Warn("Invalid message", "error", err)
+			return
+		}
+		ctx, span := tracing.Start(context.Background(), "websocket message",
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.Int64("pigeon.user_id", c.userID)),
+		)
+		relayed, senderErr := c.legacyMessage(ctx, msg)
+		if senderErr != nil {
+			c.logger.Error("Error loading message sender", "error", senderErr)
+			tracing.Fail(span, senderErr)
+			span.End()
+			continue
+		}
+		handle(ctx, relayed)
+		span.End()
+	}
+}
+
+// legacyMessage monta a mensagem repassada no protocolo legado. Do frame do cliente só vêm o
+// destinatário, o conteúdo e os ids; o remetente é sempre o dono da conexão, com o nome e o
+// ícone lidos do banco na primeira mensagem.
+func (c *Client) legacyMessage(ctx context.Context, frame model.UserMessage) (model.UserMessage, error) {
+	if c.sender == nil {
+		name, username, icon, err := repository.GetUserInfo(ctx, int(c.userID))
+		if err != nil {
+			return model.UserMessage{}, err
+		}
+		c.sender = &model.UserMessage{Name: name, CreatedBy: username, Icon: icon}
+		if icon != nil {
+			c.sender.IconBase64 = base64.StdEncoding.EncodeToString(icon)
+		}
+	}
+
+	return model.UserMessage{
+		MessageID:       frame.MessageID,
+		MessageUserID:   int(c.userID),
+		UserID:          int(c.userID),
+		Content:         frame.Content,
+		Icon:            c.sender.Icon,
+		IconBase64:      c.sender.IconBase64,
+		CreatedBy:       c.sender.CreatedBy,
+		Name:            c.sender.Name,
+		MessageBy:       int(c.userID),
+		MessageTo:       frame.MessageTo,
+		CreatedAt:       time.Now().Format("15:04"),
+		ClientMessageID: frame.ClientMessageID,
+	}, nil
+}
+
+func (c *Client) writeLoop() {
+	ticker := time.NewTicker(c.heartbeat.PingInterval)
+	defer func() {
+		ticker.Stop()
+		c.Close()
+	}()
+
+	for {
+		select {
+		case payload := <-c.send:
+			if err := c.write(payload); err != nil {
+				c.logger.Warn("Error sending payload", "error", err)
+				return
+			}
+			c.touch()
+		case now := <-ticker.C:
+			if c.heartbeat.IdleTimeout > 0 && c.idleFor(now) >= c.heartbeat.IdleTimeout {
+				c.logger.Info("Closing connection due to inactivity", "idle_timeout", c.heartbeat.IdleTimeout.String())
+				c.conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "idle timeout"),
+					now.Add(c.heartbeat.WriteWait))
+				return
+			}
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, now.Add(c.heartbeat.WriteWait)); err != nil {
+				c.logger.Warn("Error sending ping", "error", err)
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// readMessage lê a próxima mensagem. O SetReadLimit do gorilla conta os bytes recebidos,
+// antes da descompressão, então o tamanho descomprimido também é limitado aqui.
+func (c *Client) readMessage() ([]byte, error) {
+	_, reader, err := c.conn.NextReader()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, c.transport.MaxMessageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > c.transport.MaxMessageSize {
+		c.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseMessageTooBig, ""),
+			time.Now().Add(c.heartbeat.WriteWait))
+		return nil, websocket.ErrReadLimit
+	}
+	return data, nil
+}
+
+// write envia o payload no formato do protocolo da conexão. Os payloads do dispatcher abrem
+// o span de escrita como filho do span da entrega.
+func (c *Client) write(payload interface{}) (writeErr error) {
+	if f, ok := payload.(frame); ok && f.span.IsValid() {
+		_, span := tracing.Start(trace.ContextWithRemoteSpanContext(context.Background(), f.span), "websocket.write",
+			trace.WithAttributes(
+				attribute.Int64("pigeon.user_id", c.userID),
+				attribute.String("pigeon.session_id", c.sessionID),
+				attribute.Bool("pigeon.versioned", c.Versioned()),
+			),
+		)
+		defer func() {
+			tracing.Fail(span, writeErr)
+			span.End()
+		}()
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(c.heartbeat.WriteWait))
+	if !c.Versioned() {
+		if f, ok := payload.(frame); ok {
+			payload = f.legacy
+		}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			c.logger.Error("Error encoding payload", "error", err)
+			return nil
+		}
+		return c.writeFrame(websocket.TextMessage, data)
+	}
+	for _, event := range envelopes(payload) {
+		data, err := c.codec.Marshal(event)
+		if err != nil {
+			c.logger.Error("Error encoding event", "event_type", event.Type, "error", err)
+			continue
+		}
+		if err := c.writeFrame(c.codec.FrameType(), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFrame envia o frame, comprimido apenas a partir do limite de compressão, já que o
+// custo do deflate não compensa em mensagens pequenas.
+func (c *Client) writeFrame(frameType int, data []byte) error {
+	c.conn.EnableWriteCompression(c.transport.Compression && len(data) >= c.transport.CompressionThreshold)
+	return c.conn.WriteMessage(frameType, data)
+}
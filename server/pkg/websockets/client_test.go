@@ -0,0 +1,84 @@
+package websockets
+
+import (
+	"context"
+	"database/sql/driver"
+	"messenger-pigeon-app/config/database"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/internal/testdb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// serveClient sobe um servidor que abre um Client do usuário em cada handshake e passa a
+// conexão para serve. Devolve a URL ws:// do servidor.
+func serveClient(t *testing.T, userID int64, serve func(*Client)) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, upgradeErr := Upgrade(w, r)
+		if upgradeErr != nil {
+			return
+		}
+		serve(NewClient(r.Context(), conn, userID, "s1", "en"))
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// No protocolo legado, o remetente da mensagem repassada vem da conexão autenticada e do
+// banco, nunca do frame enviado pelo cliente.
+func TestLegacyRelayIgnoresClientSender(t *testing.T) {
+	db, store := testdb.Open()
+	database.SetDB(db)
+	t.Cleanup(func() { database.SetDB(nil) })
+	store.Rows("SELECT name, username, icon FROM user", []string{"name", "username", "icon"},
+		[]driver.Value{"Ana", "ana", []byte{1, 2, 3}})
+
+	relayed := make(chan model.UserMessage, 2)
+	url := serveClient(t, 7, func(client *Client) {
+		client.ReadMessages(func(ctx context.Context, message model.UserMessage) { relayed <- message })
+	})
+	conn, _, dialErr := websocket.DefaultDialer.Dial(url, nil)
+	if dialErr != nil {
+		t.Fatal(dialErr)
+	}
+	defer conn.Close()
+
+	spoofed := model.UserMessage{MessageID: 42, Content: "oi", MessageTo: 8, ClientMessageID: "c-1",
+		MessageBy: 8, MessageUserID: 8, UserID: 8, CreatedBy: "bruno", Name: "Bruno", IconBase64: "AAAA", CreatedAt: "00:00"}
+	for i := 0; i < 2; i++ {
+		if writeErr := conn.WriteJSON(spoofed); writeErr != nil {
+			t.Fatal(writeErr)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		var message model.UserMessage
+		select {
+		case message = <-relayed:
+		case <-time.After(time.Second):
+			t.Fatal("message was not relayed")
+		}
+		if message.MessageBy != 7 || message.MessageUserID != 7 || message.UserID != 7 {
+			t.Errorf("sender ids = %d/%d/%d, want 7", message.MessageBy, message.MessageUserID, message.UserID)
+		}
+		if message.CreatedBy != "ana" || message.Name != "Ana" || message.IconBase64 != "AQID" {
+			t.Errorf("sender = %q/%q/%q, want ana/Ana/AQID", message.CreatedBy, message.Name, message.IconBase64)
+		}
+		if message.MessageTo != 8 || message.Content != "oi" || message.MessageID != 42 || message.ClientMessageID != "c-1" {
+			t.Errorf("frame fields not kept: %+v", message)
+		}
+		if message.CreatedAt == "00:00" && time.Now().Format("15:04") != "00:00" {
+			t.Errorf("created_at taken from the client frame")
+		}
+	}
+	// O remetente é lido uma vez por conexão
+	if calls := len(store.Calls("SELECT name, username, icon FROM user")); calls != 1 {
+		t.Errorf("user info loaded %d times, want 1", calls)
+	}
+}
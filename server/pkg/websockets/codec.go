@@ -0,0 +1,56 @@
+package websockets
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+	"github.com/ugorji/go/codec"
+)
+
+// Codec codifica os envelopes do protocolo versionado. Os codecs usam os nomes da tag json,
+// de forma que os eventos têm o mesmo schema em qualquer codificação.
+type Codec interface {
+	FrameType() int // websocket.TextMessage ou websocket.BinaryMessage
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// codecs mapeia os subprotocolos versionados para a codificação dos seus frames.
+var codecs = map[string]Codec{
+	SubprotocolV1JSON:    jsonCodec{},
+	SubprotocolV1MsgPack: msgpackCodec{},
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) FrameType() int {
+	return websocket.TextMessage
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// Handle compartilhado pelos encoders; não deve ser alterado depois de inicializado.
+// WriteExt envia []byte como bin (sem base64) e time.Time com a extensão de timestamp.
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) FrameType() int {
+	return websocket.BinaryMessage
+}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var data []byte
+	err := codec.NewEncoderBytes(&data, msgpackHandle).Encode(v)
+	return data, err
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return codec.NewDecoderBytes(data, msgpackHandle).Decode(v)
+}
@@ -0,0 +1,130 @@
+package websockets
+
+import (
+	"encoding/json"
+	"messenger-pigeon-app/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// benchmarkMessage é uma mensagem típica, com um avatar de 4 KiB no ícone.
+func benchmarkMessage() model.UserMessage {
+	icon := make([]byte, 4096)
+	for i := range icon {
+		icon[i] = byte(i * 31)
+	}
+	return model.UserMessage{MessageID: 42, MessageUserID: 8, UserID: 8, Content: "Chego em 10 minutos, pode pedir o café",
+		Icon: icon, CreatedBy: "bruno", Name: "Bruno", MessageBy: 8, MessageTo: 7, CreatedAt: "10:30", ClientMessageID: "c-2"}
+}
+
+// dialDiscard abre um WebSocket com um servidor que descarta tudo o que recebe.
+func dialDiscard(tb testing.TB) *websocket.Conn {
+	tb.Helper()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, upgradeErr := upgrader.Upgrade(w, r, nil)
+		if upgradeErr != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, readErr := conn.NextReader(); readErr != nil {
+				return
+			}
+		}
+	}))
+	tb.Cleanup(server.Close)
+
+	conn, _, dialErr := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if dialErr != nil {
+		tb.Fatal(dialErr)
+	}
+	tb.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// Custo de escrever uma mensagem e bytes por frame: o WriteJSON do protocolo legado contra
+// os codecs do protocolo versionado, que também carregam o envelope.
+func BenchmarkCodecWrite(b *testing.B) {
+	message := benchmarkMessage()
+	envelope := Envelope{Type: EventMessageCreated, ID: newEventID(), Timestamp: time.Now().UTC(), Payload: message}
+
+	b.Run("write_json", func(b *testing.B) {
+		conn := dialDiscard(b)
+		encoded, _ := json.Marshal(message)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if writeErr := conn.WriteJSON(message); writeErr != nil {
+				b.Fatal(writeErr)
+			}
+		}
+		b.ReportMetric(float64(len(encoded)+1), "bytes/frame") // O json.Encoder acrescenta "\n"
+	})
+
+	for _, name := range []string{SubprotocolV1JSON, SubprotocolV1MsgPack} {
+		codec := codecs[name]
+		b.Run(name, func(b *testing.B) {
+			conn := dialDiscard(b)
+			var size int
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				data, marshalErr := codec.Marshal(envelope)
+				if marshalErr != nil {
+					b.Fatal(marshalErr)
+				}
+				if writeErr := conn.WriteMessage(codec.FrameType(), data); writeErr != nil {
+					b.Fatal(writeErr)
+				}
+				size = len(data)
+			}
+			b.ReportMetric(float64(size), "bytes/frame")
+		})
+	}
+}
+
+// Custo só da codificação, sem a escrita no socket.
+func BenchmarkCodecMarshal(b *testing.B) {
+	message := benchmarkMessage()
+	envelope := Envelope{Type: EventMessageCreated, ID: newEventID(), Timestamp: time.Now().UTC(), Payload: message}
+
+	b.Run("write_json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, marshalErr := json.Marshal(message); marshalErr != nil {
+				b.Fatal(marshalErr)
+			}
+		}
+	})
+	for _, name := range []string{SubprotocolV1JSON, SubprotocolV1MsgPack} {
+		codec := codecs[name]
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, marshalErr := codec.Marshal(envelope); marshalErr != nil {
+					b.Fatal(marshalErr)
+				}
+			}
+		})
+	}
+}
+
+// O frame MessagePack, mesmo com o envelope, é menor que o JSON legado: o ícone vai como
+// bin em vez de base64.
+func TestMsgpackFrameSmallerThanWriteJSON(t *testing.T) {
+	message := benchmarkMessage()
+	legacy, _ := json.Marshal(message)
+	binary, marshalErr := msgpackCodec{}.Marshal(Envelope{Type: EventMessageCreated, ID: newEventID(), Timestamp: time.Now().UTC(), Payload: message})
+	if marshalErr != nil {
+		t.Fatal(marshalErr)
+	}
+	if len(binary) >= len(legacy) {
+		t.Fatalf("msgpack frame has %d bytes, WriteJSON %d", len(binary), len(legacy)+1)
+	}
+}
@@ -0,0 +1,51 @@
+package websockets
+
+import "errors"
+
+var (
+	// ErrNotRunning indica que a entrega em tempo real não foi iniciada, foi encerrada ou
+	// ficou sem workers.
+	ErrNotRunning = errors.New("realtime delivery is not running")
+	// ErrSaturated indica que a fila de um pool está cheia e as mensagens novas são descartadas.
+	ErrSaturated = errors.New("realtime delivery queue is full")
+)
+
+// Ready informa se os pools de workers dos dois canais estão entregando: com workers em
+// execução e espaço na fila. Eles são o barramento da aplicação: toda mensagem enviada passa
+// por eles até as conexões.
+func Ready() error {
+	for _, pool := range []*WorkerPool{workerPool, workerPoolMessages} {
+		if err := pool.ready(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WorkerPools retorna o estado dos pools de workers dos canais iniciados.
+func WorkerPools() []PoolStats {
+	var stats []PoolStats
+	for _, pool := range []*WorkerPool{workerPool, workerPoolMessages} {
+		if pool != nil {
+			stats = append(stats, pool.Stats())
+		}
+	}
+	return stats
+}
+
+// LiveConnections retorna as conexões abertas nos dois canais, agrupadas por usuário.
+func LiveConnections() map[int64][]ConnectionInfo {
+	connections := make(map[int64][]ConnectionInfo)
+	for channel, registry := range map[string]*Registry{"chat": UserConnections, "messages": UserConnectionsMessages} {
+		if registry == nil {
+			continue
+		}
+		for userID, infos := range registry.Connections() {
+			for _, info := range infos {
+				info.Channel = channel
+				connections[userID] = append(connections[userID], info)
+			}
+		}
+	}
+	return connections
+}
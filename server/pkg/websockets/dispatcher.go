@@ -0,0 +1,158 @@
+package websockets
+
+import (
+	"context"
+	"log/slog"
+	"messenger-pigeon-app/config/env"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/metrics"
+	"messenger-pigeon-app/pkg/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DispatchMode define como as mensagens são entregues às conexões.
+type DispatchMode string
+
+const (
+	// DispatchImmediate envia cada mensagem assim que ela é processada.
+	DispatchImmediate DispatchMode = "immediate"
+	// DispatchBatched agrupa as mensagens por destinatário e envia um lote por vez.
+	DispatchBatched DispatchMode = "batched"
+)
+
+// DispatcherConfig controla o modo de entrega e os limites de agrupamento.
+type DispatcherConfig struct {
+	Mode          DispatchMode
+	BatchSize     int           // Envia o lote de um destinatário quando atinge esse tamanho
+	FlushInterval time.Duration // Latência máxima de uma mensagem parada no lote
+}
+
+// DispatcherConfigFromEnv lê WS_DISPATCH_MODE, WS_BATCH_SIZE e WS_BATCH_INTERVAL.
+func DispatcherConfigFromEnv() DispatcherConfig {
+	mode := DispatchMode(env.String("WS_DISPATCH_MODE", string(DispatchImmediate)))
+	if mode != DispatchBatched {
+		mode = DispatchImmediate
+	}
+	return DispatcherConfig{
+		Mode:          mode,
+		BatchSize:     env.Int("WS_BATCH_SIZE", 10),
+		FlushInterval: env.Duration("WS_BATCH_INTERVAL", 50*time.Millisecond),
+	}
+}
+
+// Dispatcher entrega mensagens aos destinatários registrados em um Registry.
+// No modo em lote, cada destinatário tem seu próprio lote, de forma que um usuário
+// só recebe as mensagens endereçadas a ele.
+type Dispatcher struct {
+	channel  string // Canal WebSocket atendido ("chat" ou "messages"), usado nas métricas
+	registry *Registry
+	config   DispatcherConfig
+	incoming chan delivery
+}
+
+// NewDispatcher cria o dispatcher do canal e, no modo em lote, inicia o loop de agrupamento.
+func NewDispatcher(channel string, registry *Registry, config DispatcherConfig) *Dispatcher {
+	if config.BatchSize < 1 {
+		config.BatchSize = 1
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 50 * time.Millisecond
+	}
+
+	d := &Dispatcher{channel: channel, registry: registry, config: config}
+	if config.Mode == DispatchBatched {
+		d.incoming = make(chan delivery, 100)
+		go d.run()
+	}
+	return d
+}
+
+// Dispatch entrega a mensagem ao destinatário, imediatamente ou pelo lote.
+func (d *Dispatcher) Dispatch(ctx context.Context, message model.UserMessage) {
+	d.DispatchTo(ctx, int64(message.MessageTo), message)
+}
+
+// DispatchTo entrega a mensagem ao usuário informado, imediatamente ou pelo lote.
+func (d *Dispatcher) DispatchTo(ctx context.Context, recipient int64, message model.UserMessage) {
+	d.dispatch(delivery{recipient: recipient, message: message, enqueued: time.Now(), span: trace.SpanContextFromContext(ctx)})
+}
+
+// dispatch é chamado pelos workers do pool. Na entrega imediata, o span da entrega é filho
+// do span do enfileiramento; no lote, ele é criado no envio do lote, com links para cada mensagem.
+func (d *Dispatcher) dispatch(job delivery) {
+	if d.config.Mode != DispatchBatched {
+		ctx, span := tracing.Start(trace.ContextWithRemoteSpanContext(context.Background(), job.span), "worker_pool.dispatch",
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(
+				attribute.String("pigeon.channel", d.channel),
+				attribute.Int64("pigeon.recipient_id", job.recipient),
+				attribute.Int64("pigeon.queue_wait_ms", time.Since(job.enqueued).Milliseconds()),
+			),
+		)
+		defer span.End()
+		d.deliver(ctx, job.recipient, job.message, []delivery{job})
+		return
+	}
+	d.incoming <- job
+}
+
+func (d *Dispatcher) run() {
+	pending := make(map[int64][]delivery)
+	ticker := time.NewTicker(d.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case job := <-d.incoming:
+			recipient := job.recipient
+			pending[recipient] = append(pending[recipient], job)
+			if len(pending[recipient]) >= d.config.BatchSize {
+				d.deliverBatch(recipient, pending[recipient])
+				delete(pending, recipient)
+			}
+		case <-ticker.C:
+			for recipient, batch := range pending {
+				d.deliverBatch(recipient, batch)
+				delete(pending, recipient)
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) deliverBatch(recipient int64, jobs []delivery) {
+	batch := make([]model.UserMessage, len(jobs))
+	links := make([]trace.Link, 0, len(jobs))
+	for i, job := range jobs {
+		batch[i] = job.message
+		if job.span.IsValid() {
+			links = append(links, trace.Link{SpanContext: job.span})
+		}
+	}
+
+	ctx, span := tracing.Start(context.Background(), "worker_pool.dispatch_batch",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			attribute.String("pigeon.channel", d.channel),
+			attribute.Int64("pigeon.recipient_id", recipient),
+			attribute.Int("pigeon.batch_size", len(jobs)),
+		),
+	)
+	defer span.End()
+	d.deliver(ctx, recipient, batch, jobs)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, recipient int64, payload interface{}, jobs []delivery) {
+	connections := d.registry.Send(ctx, recipient, payload)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("pigeon.connections", connections))
+	// Com histórico de retomada, o evento fica guardado mesmo sem conexões
+	if connections == 0 && d.registry.Journal() == nil {
+		slog.DebugContext(ctx, "Recipient is not connected", "channel", d.channel, "recipient_id", recipient, "messages", len(jobs))
+	}
+	for _, job := range jobs {
+		metrics.MessageDispatched(d.channel, job.enqueued, connections > 0)
+	}
+}
@@ -0,0 +1,129 @@
+package websockets
+
+import (
+	"context"
+	"log/slog"
+	"messenger-pigeon-app/internal/model"
+	"testing"
+	"time"
+)
+
+// recordingConnection repassa os payloads recebidos a um canal.
+type recordingConnection struct {
+	fakeConnection
+	payloads chan interface{}
+}
+
+func newRecordingConnection(userID int64) *recordingConnection {
+	return &recordingConnection{fakeConnection: fakeConnection{userID: userID, sessionID: "s1"}, payloads: make(chan interface{}, 100)}
+}
+
+func (r *recordingConnection) Send(payload interface{}) bool {
+	r.fakeConnection.Send(payload)
+	r.payloads <- payload
+	return true
+}
+
+// next devolve o próximo payload legado recebido, ou falha se nada chegar a tempo.
+func (r *recordingConnection) next(t *testing.T, within time.Duration) interface{} {
+	t.Helper()
+	select {
+	case payload := <-r.payloads:
+		return payload.(frame).legacy
+	case <-time.After(within):
+		t.Fatalf("user %d received nothing within %v", r.userID, within)
+		return nil
+	}
+}
+
+// none falha se algum payload chegar durante o intervalo.
+func (r *recordingConnection) none(t *testing.T, during time.Duration) {
+	t.Helper()
+	select {
+	case payload := <-r.payloads:
+		t.Fatalf("user %d received %v, want nothing yet", r.userID, payload.(frame).legacy)
+	case <-time.After(during):
+	}
+}
+
+func TestDispatcherImmediate(t *testing.T) {
+	registry := NewRegistry(1)
+	recipient := newRecordingConnection(7)
+	registry.Register(recipient)
+	dispatcher := NewDispatcher("chat", registry, DispatcherConfig{Mode: DispatchImmediate})
+
+	dispatcher.Dispatch(context.Background(), model.UserMessage{MessageID: 1, MessageTo: 7})
+	dispatcher.Dispatch(context.Background(), model.UserMessage{MessageID: 2, MessageTo: 7})
+
+	// Cada mensagem chega sozinha, sem esperar as demais
+	for _, want := range []int{1, 2} {
+		message, ok := recipient.next(t, time.Second).(model.UserMessage)
+		if !ok || message.MessageID != want {
+			t.Fatalf("payload = %#v, want message %d", message, want)
+		}
+	}
+}
+
+func TestDispatcherBatchedFlushesOnSize(t *testing.T) {
+	registry := NewRegistry(1)
+	recipient := newRecordingConnection(7)
+	registry.Register(recipient)
+	dispatcher := NewDispatcher("chat", registry, DispatcherConfig{Mode: DispatchBatched, BatchSize: 3, FlushInterval: time.Hour})
+
+	dispatcher.Dispatch(context.Background(), model.UserMessage{MessageID: 1, MessageTo: 7})
+	dispatcher.Dispatch(context.Background(), model.UserMessage{MessageID: 2, MessageTo: 7})
+	recipient.none(t, 50*time.Millisecond)
+
+	dispatcher.Dispatch(context.Background(), model.UserMessage{MessageID: 3, MessageTo: 7})
+	batch, ok := recipient.next(t, time.Second).([]model.UserMessage)
+	if !ok || len(batch) != 3 {
+		t.Fatalf("payload = %#v, want a batch of 3", batch)
+	}
+	for i, message := range batch {
+		if message.MessageID != i+1 {
+			t.Errorf("batch[%d] is message %d, want %d", i, message.MessageID, i+1)
+		}
+	}
+}
+
+func TestDispatcherBatchedFlushesOnInterval(t *testing.T) {
+	registry := NewRegistry(1)
+	recipient := newRecordingConnection(7)
+	registry.Register(recipient)
+	dispatcher := NewDispatcher("chat", registry, DispatcherConfig{Mode: DispatchBatched, BatchSize: 10, FlushInterval: 20 * time.Millisecond})
+
+	dispatcher.Dispatch(context.Background(), model.UserMessage{MessageID: 1, MessageTo: 7})
+	dispatcher.Dispatch(context.Background(), model.UserMessage{MessageID: 2, MessageTo: 7})
+
+	// Abaixo do tamanho do lote, as mensagens saem no próximo tick
+	batch, ok := recipient.next(t, time.Second).([]model.UserMessage)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("payload = %#v, want a batch of 2", batch)
+	}
+}
+
+// Um destinatário lento, com o buffer de envio cheio, perde as próprias mensagens sem
+// atrasar os lotes dos outros destinatários.
+func TestDispatcherIsolatesSlowRecipient(t *testing.T) {
+	registry := NewRegistry(1)
+	// Cliente cuja goroutine de escrita não drena a fila, como uma conexão lenta
+	slow := &Client{userID: 7, sessionID: "s1", logger: slog.Default(), send: make(chan interface{}, 1), done: make(chan struct{})}
+	registry.Register(slow)
+	fast := newRecordingConnection(8)
+	registry.Register(fast)
+	dispatcher := NewDispatcher("chat", registry, DispatcherConfig{Mode: DispatchBatched, BatchSize: 1, FlushInterval: time.Hour})
+
+	for i := 1; i <= 5; i++ {
+		dispatcher.Dispatch(context.Background(), model.UserMessage{MessageID: i, MessageTo: 7})
+	}
+	dispatcher.Dispatch(context.Background(), model.UserMessage{MessageID: 6, MessageTo: 8})
+
+	batch, ok := fast.next(t, time.Second).([]model.UserMessage)
+	if !ok || len(batch) != 1 || batch[0].MessageID != 6 || batch[0].MessageTo != 8 {
+		t.Fatalf("fast recipient got %#v, want only message 6", batch)
+	}
+	fast.none(t, 20*time.Millisecond)
+	if queued := len(slow.send); queued != 1 {
+		t.Errorf("slow recipient has %d queued payloads, want 1", queued)
+	}
+}
@@ -0,0 +1,110 @@
+package websockets
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"messenger-pigeon-app/pkg/repository"
+	"messenger-pigeon-app/pkg/tracing"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MaxClientMessageIDLength é o tamanho máximo do clientMessageId, o mesmo da coluna client_message_id.
+const MaxClientMessageIDLength = 64
+
+// handleEvent trata um evento recebido no protocolo versionado. Erros são devolvidos ao
+// cliente como eventos "error", sem encerrar a conexão.
+func (c *Client) handleEvent(data []byte) {
+	var event inboundEnvelope
+	if decodeErr := c.codec.Unmarshal(data, &event); decodeErr != nil {
+		c.replyError("", err.New(err.CodeBadRequest, i18n.T(c.locale, "error.invalid_event")))
+		return
+	}
+
+	switch event.Type {
+	case EventMessageSend:
+		c.handleMessageSend(event, data)
+	default:
+		c.replyError(event.ID, err.New(err.CodeBadRequest, i18n.T(c.locale, "error.unknown_event", event.Type)))
+	}
+}
+
+// handleMessageSend salva e entrega a mensagem como o POST /v1/conversations/:id/messages.
+// O span continua o trace enviado pelo cliente no meta do evento, se houver.
+func (c *Client) handleMessageSend(event inboundEnvelope, data []byte) {
+	ctx, span := tracing.Start(tracing.Extract(context.Background(), event.Meta), "websocket "+string(event.Type),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.Int64("pigeon.user_id", c.userID)),
+	)
+	defer span.End()
+
+	var payload SendMessagePayload
+	if decodeErr := c.codec.Unmarshal(data, &inboundPayload{Payload: &payload}); decodeErr != nil {
+		c.replyError(event.ID, err.New(err.CodeBadRequest, i18n.T(c.locale, "error.invalid_event")))
+		return
+	}
+
+	ack, sendErr := SubmitMessage(ctx, c.userID, c.locale, payload)
+	if sendErr != nil {
+		c.replyError(event.ID, *sendErr)
+		return
+	}
+	c.reply(event.ID, EventMessageAck, ack)
+}
+
+// SubmitMessage valida e envia o payload de um message.send em nome do usuário. O erro já
+// vem no envelope da API, com a mensagem no idioma informado.
+func SubmitMessage(ctx context.Context, userID int64, locale string, payload SendMessagePayload) (MessageAckPayload, *err.ErrorResponse) {
+	to := strings.TrimSpace(payload.To)
+	content := strings.TrimSpace(payload.Content)
+	clientMessageID := strings.TrimSpace(payload.ClientMessageID)
+	fields := make(map[string]string)
+	if to == "" {
+		fields["to"] = i18n.T(locale, "validation.required")
+	}
+	if content == "" {
+		fields["content"] = i18n.T(locale, "validation.required")
+	}
+	if len(clientMessageID) > MaxClientMessageIDLength {
+		fields["clientMessageId"] = i18n.T(locale, "validation.max", strconv.Itoa(MaxClientMessageIDLength))
+	}
+	if len(fields) > 0 {
+		e := err.WithFields(err.CodeValidation, i18n.T(locale, "error.validation"), fields)
+		return MessageAckPayload{}, &e
+	}
+
+	messageID, replayed, sendErr := SendChatMessage(ctx, int(userID), to, content, clientMessageID)
+	if sendErr != nil {
+		var e err.ErrorResponse
+		switch {
+		case errors.Is(sendErr, repository.ErrUserNotFound):
+			e = err.New(err.CodeUserNotFound, i18n.T(locale, "error.user_not_found"))
+		case errors.Is(sendErr, ErrClientMessageConflict):
+			e = err.New(err.CodeClientMessageReused, i18n.T(locale, "error.client_message_id_reused"))
+		default:
+			slog.ErrorContext(ctx, "Error sending message", "user_id", userID, "error", sendErr)
+			e = err.New(err.CodeInternal, i18n.T(locale, "error.send_message_failed"))
+		}
+		return MessageAckPayload{}, &e
+	}
+
+	return MessageAckPayload{
+		MessageID:       messageID,
+		ClientMessageID: clientMessageID,
+		Replayed:        replayed,
+	}, nil
+}
+
+func (c *Client) reply(replyTo string, eventType EventType, payload interface{}) {
+	c.Send(NewReply(replyTo, eventType, payload))
+}
+
+func (c *Client) replyError(replyTo string, e err.ErrorResponse) {
+	c.reply(replyTo, EventError, e.Error)
+}
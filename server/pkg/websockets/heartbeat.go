@@ -0,0 +1,55 @@
+package websockets
+
+import (
+	"log/slog"
+	"messenger-pigeon-app/config/env"
+	"time"
+)
+
+// HeartbeatConfig controla os pings enviados pelo servidor e a detecção de conexões ociosas.
+type HeartbeatConfig struct {
+	PingInterval time.Duration // Intervalo entre os pings enviados pelo servidor
+	PongWait     time.Duration // Tempo máximo sem receber pong ou mensagem antes de considerar a conexão morta
+	WriteWait    time.Duration // Tempo máximo para concluir uma escrita
+	IdleTimeout  time.Duration // Fecha a conexão após esse tempo sem tráfego de mensagens (0 desativa)
+}
+
+// Configuração usada pelos clientes criados a partir de Initialize.
+var heartbeat = HeartbeatConfig{
+	PingInterval: 25 * time.Second,
+	PongWait:     60 * time.Second,
+	WriteWait:    10 * time.Second,
+	IdleTimeout:  5 * time.Minute,
+}
+
+// HeartbeatConfigFromEnv lê WS_PING_INTERVAL, WS_PONG_WAIT, WS_WRITE_WAIT e WS_IDLE_TIMEOUT.
+// Valores negativos ou nulos (exceto WS_IDLE_TIMEOUT=0, que desativa a ociosidade) são
+// ignorados com um aviso, já que os tickers de ping não aceitam intervalos não positivos.
+func HeartbeatConfigFromEnv() HeartbeatConfig {
+	config := HeartbeatConfig{
+		PingInterval: positiveDuration("WS_PING_INTERVAL", heartbeat.PingInterval),
+		PongWait:     positiveDuration("WS_PONG_WAIT", heartbeat.PongWait),
+		WriteWait:    positiveDuration("WS_WRITE_WAIT", heartbeat.WriteWait),
+		IdleTimeout:  env.Duration("WS_IDLE_TIMEOUT", heartbeat.IdleTimeout),
+	}
+	if config.IdleTimeout < 0 {
+		slog.Warn("Ignoring negative duration", "variable", "WS_IDLE_TIMEOUT", "default", heartbeat.IdleTimeout)
+		config.IdleTimeout = heartbeat.IdleTimeout
+	}
+
+	// O pong precisa ter tempo de chegar antes que o prazo de leitura expire.
+	if config.PingInterval >= config.PongWait {
+		config.PingInterval = config.PongWait * 9 / 10
+	}
+	return config
+}
+
+// positiveDuration lê a duração da variável, usando o padrão se ela não for positiva.
+func positiveDuration(key string, def time.Duration) time.Duration {
+	value := env.Duration(key, def)
+	if value <= 0 {
+		slog.Warn("Ignoring non-positive duration", "variable", key, "default", def)
+		return def
+	}
+	return value
+}
@@ -0,0 +1,41 @@
+package websockets
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeartbeatConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want HeartbeatConfig
+	}{
+		{"defaults", nil, heartbeat},
+		{"non-positive pong wait", map[string]string{"WS_PONG_WAIT": "0s"}, heartbeat},
+		{"negative pong wait", map[string]string{"WS_PONG_WAIT": "-5s"}, heartbeat},
+		{"non-positive ping interval", map[string]string{"WS_PING_INTERVAL": "0s", "WS_WRITE_WAIT": "-1s"}, heartbeat},
+		{"negative idle timeout", map[string]string{"WS_IDLE_TIMEOUT": "-1m"}, heartbeat},
+		{"idle timeout disabled", map[string]string{"WS_IDLE_TIMEOUT": "0s"}, HeartbeatConfig{
+			PingInterval: heartbeat.PingInterval, PongWait: heartbeat.PongWait, WriteWait: heartbeat.WriteWait,
+		}},
+		{"ping interval above pong wait", map[string]string{"WS_PING_INTERVAL": "20s", "WS_PONG_WAIT": "10s"}, HeartbeatConfig{
+			PingInterval: 9 * time.Second, PongWait: 10 * time.Second, WriteWait: heartbeat.WriteWait, IdleTimeout: heartbeat.IdleTimeout,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"WS_PING_INTERVAL", "WS_PONG_WAIT", "WS_WRITE_WAIT", "WS_IDLE_TIMEOUT"} {
+				t.Setenv(key, tt.env[key])
+			}
+			got := HeartbeatConfigFromEnv()
+			if got != tt.want {
+				t.Fatalf("HeartbeatConfigFromEnv() = %+v, want %+v", got, tt.want)
+			}
+			if got.PingInterval <= 0 {
+				t.Fatalf("PingInterval = %v, would panic time.NewTicker", got.PingInterval)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package websockets
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/metrics"
+	"messenger-pigeon-app/pkg/tracing"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// delivery é uma mensagem endereçada a um usuário, que pode ser o destinatário
+// ou o próprio remetente (eco para as suas outras conexões).
+type delivery struct {
+	recipient int64
+	message   model.UserMessage
+	enqueued  time.Time         // Para a métrica de latência de entrega
+	span      trace.SpanContext // Span do enfileiramento, pai do span de entrega no worker
+}
+
+// Pool de workers para processar mensagens
+type WorkerPool struct {
+	workers    int
+	jobQueue   chan delivery
+	dispatcher *Dispatcher
+	wg         sync.WaitGroup
+	stopped    atomic.Bool
+	alive      atomic.Int32 // Workers em execução
+}
+
+// PoolStats é o estado de um pool de workers, para os diagnósticos.
+type PoolStats struct {
+	Channel       string `json:"channel"`
+	Workers       int    `json:"workers"`
+	QueueDepth    int    `json:"queueDepth"`
+	QueueCapacity int    `json:"queueCapacity"`
+	Running       bool   `json:"running"`
+}
+
+func NewWorkerPool(numWorkers int, dispatcher *Dispatcher) *WorkerPool {
+	pool := &WorkerPool{
+		workers:    numWorkers,
+		jobQueue:   make(chan delivery, 100), // Buffer com 100 mensagens
+		dispatcher: dispatcher,
+	}
+	metrics.RegisterQueue(dispatcher.channel, func() int { return len(pool.jobQueue) })
+	pool.startWorkers()
+	return pool
+}
+
+func (pool *WorkerPool) startWorkers() {
+	for i := 0; i < pool.workers; i++ {
+		pool.wg.Add(1)
+		pool.alive.Add(1)
+		go func() {
+			defer pool.wg.Done()
+			defer pool.alive.Add(-1)
+			for job := range pool.jobQueue {
+				pool.dispatcher.dispatch(job)
+			}
+		}()
+	}
+}
+
+// Submit enfileira a mensagem para o seu destinatário.
+func (pool *WorkerPool) Submit(ctx context.Context, job model.UserMessage) {
+	pool.SubmitTo(ctx, int64(job.MessageTo), job)
+}
+
+// SubmitTo enfileira a mensagem para um usuário específico. O span do enfileiramento segue
+// com a entrega, para que a espera na fila apareça no trace de quem enviou a mensagem.
+func (pool *WorkerPool) SubmitTo(ctx context.Context, recipient int64, job model.UserMessage) {
+	_, span := tracing.Start(ctx, "worker_pool.enqueue",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("pigeon.channel", pool.dispatcher.channel),
+			attribute.Int64("pigeon.recipient_id", recipient),
+			attribute.Int("pigeon.queue_depth", len(pool.jobQueue)),
+		),
+	)
+	defer span.End()
+
+	select {
+	case pool.jobQueue <- delivery{recipient: recipient, message: job, enqueued: time.Now(), span: span.SpanContext()}:
+		// Mensagem enviada para o pool com sucesso
+	default:
+		// Buffer de mensagens cheio, mensagem descartada.
+		slog.WarnContext(ctx, "Job queue full, dropping message", "channel", pool.dispatcher.channel, "recipient_id", recipient, "message_id", job.MessageID, "queue_capacity", cap(pool.jobQueue))
+		metrics.QueueDropped(pool.dispatcher.channel)
+		span.AddEvent("dropped: job queue full")
+	}
+}
+
+// Stats retorna o tamanho atual da fila e a configuração do pool.
+func (pool *WorkerPool) Stats() PoolStats {
+	return PoolStats{
+		Channel:       pool.dispatcher.channel,
+		Workers:       pool.workers,
+		QueueDepth:    len(pool.jobQueue),
+		QueueCapacity: cap(pool.jobQueue),
+		Running:       !pool.stopped.Load() && pool.alive.Load() > 0,
+	}
+}
+
+// ready informa se o pool está entregando: com algum worker em execução e espaço na fila,
+// já que com a fila cheia as mensagens novas são descartadas.
+func (pool *WorkerPool) ready() error {
+	if pool == nil || pool.stopped.Load() || pool.alive.Load() == 0 {
+		return ErrNotRunning
+	}
+	if len(pool.jobQueue) >= cap(pool.jobQueue) {
+		return fmt.Errorf("%w (%s, %d queued)", ErrSaturated, pool.dispatcher.channel, len(pool.jobQueue))
+	}
+	return nil
+}
+
+func (pool *WorkerPool) Shutdown() {
+	pool.stopped.Store(true)
+	close(pool.jobQueue)
+	pool.wg.Wait()
+}
@@ -0,0 +1,75 @@
+package websockets
+
+import (
+	"context"
+	"errors"
+	"messenger-pigeon-app/internal/model"
+	"testing"
+	"time"
+)
+
+// blockingConnection segura o worker que entrega a ela até que release seja fechado.
+type blockingConnection struct {
+	fakeConnection
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingConnection) Send(payload interface{}) bool {
+	b.entered <- struct{}{}
+	<-b.release
+	return true
+}
+
+// O Ready falha com a fila cheia, volta quando os workers a esvaziam e falha de novo
+// quando o pool fica sem workers.
+func TestWorkerPoolReady(t *testing.T) {
+	registry := NewRegistry(1)
+	recipient := &blockingConnection{
+		fakeConnection: fakeConnection{userID: 7, sessionID: "s1"},
+		entered:        make(chan struct{}, 10),
+		release:        make(chan struct{}),
+	}
+	registry.Register(recipient)
+	pool := &WorkerPool{
+		workers:    1,
+		jobQueue:   make(chan delivery, 2),
+		dispatcher: NewDispatcher("test", registry, DispatcherConfig{Mode: DispatchImmediate}),
+	}
+	pool.startWorkers()
+
+	if err := pool.ready(); err != nil {
+		t.Fatalf("ready() = %v on an idle pool", err)
+	}
+
+	// O único worker fica preso na primeira entrega e as duas seguintes enchem a fila
+	pool.Submit(context.Background(), model.UserMessage{MessageID: 1, MessageTo: 7})
+	<-recipient.entered
+	pool.Submit(context.Background(), model.UserMessage{MessageID: 2, MessageTo: 7})
+	pool.Submit(context.Background(), model.UserMessage{MessageID: 3, MessageTo: 7})
+	if err := pool.ready(); !errors.Is(err, ErrSaturated) {
+		t.Fatalf("ready() = %v with a full queue, want ErrSaturated", err)
+	}
+
+	close(recipient.release)
+	deadline := time.Now().Add(time.Second)
+	for len(pool.jobQueue) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := pool.ready(); err != nil {
+		t.Fatalf("ready() = %v after the queue drained", err)
+	}
+
+	pool.Shutdown()
+	if err := pool.ready(); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("ready() = %v after Shutdown, want ErrNotRunning", err)
+	}
+	if pool.alive.Load() != 0 || pool.Stats().Running {
+		t.Errorf("alive = %d, running = %v after Shutdown, want no workers", pool.alive.Load(), pool.Stats().Running)
+	}
+
+	var missing *WorkerPool
+	if err := missing.ready(); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("ready() = %v on a nil pool, want ErrNotRunning", err)
+	}
+}
@@ -0,0 +1,151 @@
+package websockets
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/model"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Subprotocolos negociados pelo Sec-WebSocket-Protocol, em ordem de preferência do servidor.
+// Nos versionados todo frame é um Envelope, em JSON (frames de texto) ou MessagePack (frames
+// binários); com o legado Subprotocol (ou sem subprotocolo) os frames continuam sendo
+// model.UserMessage ou listas delas.
+const (
+	SubprotocolV1MsgPack = "pigeon.v1.msgpack"
+	SubprotocolV1JSON    = "pigeon.v1.json"
+	Subprotocol          = "pigeon"
+)
+
+// EventType identifica o formato do payload de um Envelope.
+type EventType string
+
+const (
+	// Cliente → servidor: envia uma mensagem (payload SendMessagePayload).
+	EventMessageSend EventType = "message.send"
+	// Servidor → cliente: confirma um message.send (payload MessageAckPayload).
+	EventMessageAck EventType = "message.ack"
+	// Servidor → cliente: mensagem recebida de outro usuário (payload model.UserMessage).
+	EventMessageCreated EventType = "message.created"
+	// Servidor → cliente: mensagem enviada pelo próprio usuário em outra conexão (payload model.UserMessage).
+	EventMessageSent EventType = "message.sent"
+	// Servidor → cliente: erro ao processar um evento do cliente (payload err.Error).
+	EventError EventType = "error"
+	// Servidor → cliente (SSE e long-poll): não é possível retomar do cursor informado (payload vazio).
+	EventSyncRequired EventType = "sync.required"
+)
+
+// Envelope é o frame do protocolo versionado. ID é único por evento; nas respostas a um
+// evento do cliente, ReplyTo traz o ID do evento respondido.
+type Envelope struct {
+	Type      EventType   `json:"type"`
+	ID        string      `json:"id"`
+	ReplyTo   string      `json:"replyTo,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+	Payload   interface{} `json:"payload"`
+	// Meta traz o contexto de trace W3C (traceparent, tracestate, baggage) do evento
+	Meta map[string]string `json:"meta,omitempty"`
+
+	seq uint64 // Sequência no Journal, quando o evento foi registrado nele
+}
+
+// inboundEnvelope é o cabeçalho do Envelope recebido do cliente. O payload é decodificado
+// depois, no tipo indicado por Type, com inboundPayload.
+type inboundEnvelope struct {
+	Type EventType         `json:"type"`
+	ID   string            `json:"id"`
+	Meta map[string]string `json:"meta"`
+}
+
+type inboundPayload struct {
+	Payload interface{} `json:"payload"`
+}
+
+// SendMessagePayload é o payload de message.send.
+type SendMessagePayload struct {
+	To              string `json:"to"` // username do destinatário
+	Content         string `json:"content"`
+	ClientMessageID string `json:"clientMessageId,omitempty"`
+}
+
+// MessageAckPayload é o payload de message.ack.
+type MessageAckPayload struct {
+	MessageID       int64  `json:"messageId"`
+	ClientMessageID string `json:"clientMessageId,omitempty"`
+	Replayed        bool   `json:"replayed"` // Reenvio de uma mensagem já salva
+}
+
+// Event descreve um evento do protocolo para a documentação AsyncAPI.
+type Event struct {
+	Type     EventType
+	Summary  string
+	ToServer bool        // Enviado pelo cliente
+	Payload  interface{} // Exemplo do payload
+}
+
+// Events lista todos os eventos do protocolo versionado.
+var Events = []Event{
+	{Type: EventMessageSend, Summary: "Envia uma mensagem; o clientMessageId torna o reenvio idempotente", ToServer: true, Payload: SendMessagePayload{}},
+	{Type: EventMessageAck, Summary: "Confirma o message.send com o id da mensagem salva", Payload: MessageAckPayload{}},
+	{Type: EventMessageCreated, Summary: "Mensagem recebida de outro usuário", Payload: model.UserMessage{}},
+	{Type: EventMessageSent, Summary: "Mensagem enviada pelo usuário em outra conexão", Payload: model.UserMessage{}},
+	{Type: EventError, Summary: "Erro ao processar um evento do cliente", Payload: err.Error{}},
+	{Type: EventSyncRequired, Summary: "Os eventos desde o cursor informado não estão mais disponíveis; recarregue o estado pela API REST (SSE e long-poll)", Payload: struct{}{}},
+}
+
+func newEnvelope(eventType EventType, payload interface{}) Envelope {
+	return Envelope{Type: eventType, ID: newEventID(), Timestamp: time.Now().UTC(), Payload: payload}
+}
+
+// NewReply cria a resposta a um evento do cliente, com ReplyTo apontando para ele.
+func NewReply(replyTo string, eventType EventType, payload interface{}) Envelope {
+	envelope := newEnvelope(eventType, payload)
+	envelope.ReplyTo = replyTo
+	return envelope
+}
+
+func newEventID() string {
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// frame é um payload do dispatcher já convertido nos eventos do protocolo versionado, para
+// que todas as conexões do usuário recebam os mesmos ids de evento.
+type frame struct {
+	legacy interface{} // Payload original, enviado às conexões do protocolo legado
+	events []Envelope
+	span   trace.SpanContext // Span da entrega, pai do span de escrita em cada conexão
+}
+
+// envelopes converte um payload da fila de saída nos eventos do protocolo versionado.
+// Um lote do dispatcher vira um evento por mensagem.
+func envelopes(payload interface{}) []Envelope {
+	switch value := payload.(type) {
+	case frame:
+		return value.events
+	case Envelope:
+		return []Envelope{value}
+	case model.UserMessage:
+		return []Envelope{messageEnvelope(value)}
+	case []model.UserMessage:
+		events := make([]Envelope, 0, len(value))
+		for _, message := range value {
+			events = append(events, messageEnvelope(message))
+		}
+		return events
+	default:
+		return nil
+	}
+}
+
+func messageEnvelope(message model.UserMessage) Envelope {
+	// MessageSession marca o eco para as conexões do remetente
+	if message.MessageSession {
+		return newEnvelope(EventMessageSent, message)
+	}
+	return newEnvelope(EventMessageCreated, message)
+}
@@ -0,0 +1,121 @@
+package websockets
+
+import (
+	"bytes"
+	"flag"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/model"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var goldenTimestamp = time.Date(2026, 10, 19, 10, 30, 0, 0, time.UTC)
+
+// goldenEvents tem um envelope de exemplo para cada tipo de Events, com o tipo do payload
+// em que o golden é decodificado de volta.
+var goldenEvents = map[EventType]struct {
+	envelope Envelope
+	decoded  func() interface{}
+}{
+	EventMessageSend: {
+		Envelope{Type: EventMessageSend, ID: "c-1", Timestamp: goldenTimestamp,
+			Payload: SendMessagePayload{To: "bruno", Content: "oi", ClientMessageID: "c-1"},
+			Meta:    map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}},
+		func() interface{} { return &SendMessagePayload{} },
+	},
+	EventMessageAck: {
+		Envelope{Type: EventMessageAck, ID: "e-1", ReplyTo: "c-1", Timestamp: goldenTimestamp,
+			Payload: MessageAckPayload{MessageID: 42, ClientMessageID: "c-1"}},
+		func() interface{} { return &MessageAckPayload{} },
+	},
+	EventMessageCreated: {
+		Envelope{Type: EventMessageCreated, ID: "e-2", Timestamp: goldenTimestamp,
+			Payload: model.UserMessage{MessageID: 42, MessageUserID: 8, UserID: 8, Content: "olá", Icon: []byte{0x89, 'P', 'N', 'G'},
+				CreatedBy: "bruno", Name: "Bruno", MessageBy: 8, MessageTo: 7, CreatedAt: "10:30", ClientMessageID: "c-2"}},
+		func() interface{} { return &model.UserMessage{} },
+	},
+	EventMessageSent: {
+		Envelope{Type: EventMessageSent, ID: "e-3", Timestamp: goldenTimestamp,
+			Payload: model.UserMessage{MessageSession: true, MessageID: 43, MessageUserID: 7, UserID: 7, Content: "oi",
+				CreatedBy: "ana", Name: "Ana", MessageBy: 7, MessageTo: 8, CreatedAt: "10:31"}},
+		func() interface{} { return &model.UserMessage{} },
+	},
+	EventError: {
+		Envelope{Type: EventError, ID: "e-4", ReplyTo: "c-1", Timestamp: goldenTimestamp,
+			Payload: err.Error{Code: err.CodeValidation, Message: "Invalid data", Fields: map[string]string{"to": "This field is required"}}},
+		func() interface{} { return &err.Error{} },
+	},
+	EventSyncRequired: {
+		Envelope{Type: EventSyncRequired, ID: "e-5", Timestamp: goldenTimestamp, Payload: struct{}{}},
+		func() interface{} { return &struct{}{} },
+	},
+}
+
+var goldenCodecs = map[string]Codec{"json": jsonCodec{}, "msgpack": msgpackCodec{}}
+
+// Cada evento do protocolo tem a codificação fixada em testdata/<tipo>.<codec>, e o golden
+// decodifica de volta no mesmo envelope. Rode com -update para regravar os arquivos.
+func TestEventGoldenFiles(t *testing.T) {
+	for _, event := range Events {
+		sample, ok := goldenEvents[event.Type]
+		if !ok {
+			t.Errorf("event %s has no golden sample", event.Type)
+			continue
+		}
+		for name, codec := range goldenCodecs {
+			t.Run(string(event.Type)+"."+name, func(t *testing.T) {
+				path := filepath.Join("testdata", string(event.Type)+"."+name)
+				encoded, marshalErr := codec.Marshal(sample.envelope)
+				if marshalErr != nil {
+					t.Fatal(marshalErr)
+				}
+				if *update {
+					if writeErr := os.WriteFile(path, encoded, 0o644); writeErr != nil {
+						t.Fatal(writeErr)
+					}
+				}
+
+				golden, readErr := os.ReadFile(path)
+				if readErr != nil {
+					t.Fatalf("%v (run go test -update to create it)", readErr)
+				}
+				if !bytes.Equal(encoded, golden) {
+					t.Fatalf("encoding changed:\n got %q\nwant %q", encoded, golden)
+				}
+				checkGoldenDecodes(t, codec, golden, sample.envelope, sample.decoded())
+			})
+		}
+	}
+}
+
+// checkGoldenDecodes decodifica o golden como o servidor decodifica os eventos do cliente:
+// primeiro o cabeçalho e depois o payload, no tipo do evento.
+func checkGoldenDecodes(t *testing.T, codec Codec, golden []byte, want Envelope, payload interface{}) {
+	t.Helper()
+	var header struct {
+		inboundEnvelope
+		ReplyTo   string    `json:"replyTo"`
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if decodeErr := codec.Unmarshal(golden, &header); decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if header.Type != want.Type || header.ID != want.ID || header.ReplyTo != want.ReplyTo || !header.Timestamp.Equal(want.Timestamp) {
+		t.Fatalf("decoded header = %+v, want %+v", header, want)
+	}
+	if len(header.Meta) != len(want.Meta) || (want.Meta != nil && !reflect.DeepEqual(header.Meta, want.Meta)) {
+		t.Fatalf("decoded meta = %v, want %v", header.Meta, want.Meta)
+	}
+
+	if decodeErr := codec.Unmarshal(golden, &inboundPayload{Payload: payload}); decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if got := reflect.ValueOf(payload).Elem().Interface(); !reflect.DeepEqual(got, want.Payload) {
+		t.Fatalf("decoded payload = %+v, want %+v", got, want.Payload)
+	}
+}
@@ -0,0 +1,216 @@
+package websockets
+
+import (
+	"context"
+	"messenger-pigeon-app/config/env"
+	"messenger-pigeon-app/pkg/tracing"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Número padrão de shards do registro de conexões (sobrescrito por WS_REGISTRY_SHARDS).
+const defaultRegistryShards = 64
+
+// Connection é uma conexão que recebe os eventos de um usuário: WebSocket (Client) ou
+// stream HTTP (StreamClient, para SSE e long-poll).
+type Connection interface {
+	UserID() int64
+	SessionID() string
+	Send(payload interface{}) bool
+	Close()
+	Info() ConnectionInfo
+}
+
+// ConnectionInfo descreve uma conexão aberta, para os diagnósticos de administração.
+type ConnectionInfo struct {
+	ID           string     `json:"id"`
+	SessionID    string     `json:"sessionId"`
+	Channel      string     `json:"channel,omitempty"`     // Registro em que a conexão está ("chat" ou "messages")
+	Transport    string     `json:"transport"`             // "websocket" ou "stream" (SSE, long-poll e gRPC)
+	Subprotocol  string     `json:"subprotocol,omitempty"` // Só nos WebSockets
+	OpenedAt     time.Time  `json:"openedAt"`
+	LastActivity *time.Time `json:"lastActivity,omitempty"` // Último tráfego de mensagens, só nos WebSockets
+}
+
+// Registry mapeia conexões por ID de usuário, distribuídas em shards
+// com lock próprio para que registros e buscas de usuários diferentes não disputem o mesmo mutex.
+// Um usuário pode ter várias conexões abertas (abas ou dispositivos diferentes).
+type Registry struct {
+	shards  []registryShard
+	journal *Journal // Histórico para retomada das conexões SSE e long-poll (opcional)
+}
+
+type registryShard struct {
+	mu      sync.RWMutex
+	clients map[int64]map[Connection]struct{}
+}
+
+// NewRegistry cria um registro com o número de shards informado (mínimo de 1).
+func NewRegistry(shards int) *Registry {
+	if shards < 1 {
+		shards = 1
+	}
+	r := &Registry{shards: make([]registryShard, shards)}
+	for i := range r.shards {
+		r.shards[i].clients = make(map[int64]map[Connection]struct{})
+	}
+	return r
+}
+
+// NewRegistryFromEnv cria um registro usando WS_REGISTRY_SHARDS como número de shards.
+func NewRegistryFromEnv() *Registry {
+	return NewRegistry(env.Int("WS_REGISTRY_SHARDS", defaultRegistryShards))
+}
+
+func (r *Registry) shard(userID int64) *registryShard {
+	return &r.shards[uint64(userID)%uint64(len(r.shards))]
+}
+
+// EnableReplay passa a registrar os eventos enviados no Journal, permitindo a retomada
+// pelas conexões SSE e long-poll. Deve ser chamado antes do registro das conexões.
+func (r *Registry) EnableReplay(journal *Journal) {
+	r.journal = journal
+}
+
+// Journal retorna o histórico de eventos do registro, ou nil se a retomada não estiver ativa.
+func (r *Registry) Journal() *Journal {
+	return r.journal
+}
+
+// Register adiciona a conexão ao conjunto de conexões do usuário.
+func (r *Registry) Register(client Connection) {
+	userID := client.UserID()
+	s := r.shard(userID)
+	s.mu.Lock()
+	clients, ok := s.clients[userID]
+	if !ok {
+		clients = make(map[Connection]struct{})
+		s.clients[userID] = clients
+	}
+	clients[client] = struct{}{}
+	s.mu.Unlock()
+}
+
+// Unregister remove a conexão do registro.
+func (r *Registry) Unregister(client Connection) {
+	userID := client.UserID()
+	s := r.shard(userID)
+	s.mu.Lock()
+	if clients, ok := s.clients[userID]; ok {
+		delete(clients, client)
+		if len(clients) == 0 {
+			delete(s.clients, userID)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// IsOnline informa se o usuário possui ao menos uma conexão registrada.
+func (r *Registry) IsOnline(userID int64) bool {
+	s := r.shard(userID)
+	s.mu.RLock()
+	_, ok := s.clients[userID]
+	s.mu.RUnlock()
+	return ok
+}
+
+// Send enfileira o payload em todas as conexões do usuário e retorna quantas o aceitaram.
+// Com a retomada ativa, os eventos são registrados no histórico mesmo sem conexões.
+// O contexto de trace de ctx vai no meta dos eventos e é o pai do span de escrita de cada conexão.
+func (r *Registry) Send(ctx context.Context, userID int64, payload interface{}) int {
+	events := envelopes(payload)
+	if meta := tracing.Inject(ctx); meta != nil {
+		for i := range events {
+			events[i].Meta = meta
+		}
+	}
+
+	s := r.shard(userID)
+	if r.journal != nil {
+		// A sequência é atribuída e o evento enfileirado sob o mesmo lock: senão, com vários
+		// workers, um evento de sequência menor pode chegar às conexões depois de um maior
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		events = r.journal.Append(userID, events)
+	} else {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+	}
+	payload = frame{legacy: payload, events: events, span: trace.SpanContextFromContext(ctx)}
+
+	delivered := 0
+	for client := range s.clients[userID] {
+		if client.Send(payload) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// CloseSessions fecha as conexões do usuário abertas pelas sessões informadas e retorna quantas foram fechadas.
+func (r *Registry) CloseSessions(userID int64, sessionIDs ...string) int {
+	revoked := make(map[string]bool, len(sessionIDs))
+	for _, id := range sessionIDs {
+		revoked[id] = true
+	}
+
+	s := r.shard(userID)
+	s.mu.RLock()
+	var targets []Connection
+	for client := range s.clients[userID] {
+		if revoked[client.SessionID()] {
+			targets = append(targets, client)
+		}
+	}
+	s.mu.RUnlock()
+
+	// Fechar a conexão encerra o loop de leitura, que remove o cliente do registro
+	for _, client := range targets {
+		client.Close()
+	}
+	return len(targets)
+}
+
+// Len retorna o número total de conexões registradas.
+func (r *Registry) Len() int {
+	total := 0
+	for i := range r.shards {
+		s := &r.shards[i]
+		s.mu.RLock()
+		for _, clients := range s.clients {
+			total += len(clients)
+		}
+		s.mu.RUnlock()
+	}
+	return total
+}
+
+// Connections retorna as conexões abertas, agrupadas por usuário.
+func (r *Registry) Connections() map[int64][]ConnectionInfo {
+	connections := make(map[int64][]ConnectionInfo)
+	r.Range(func(client Connection) bool {
+		connections[client.UserID()] = append(connections[client.UserID()], client.Info())
+		return true
+	})
+	return connections
+}
+
+// Range percorre todas as conexões, shard por shard, até que fn retorne false.
+// fn é chamada com o lock de leitura do shard adquirido e não deve bloquear.
+func (r *Registry) Range(fn func(client Connection) bool) {
+	for i := range r.shards {
+		s := &r.shards[i]
+		s.mu.RLock()
+		for _, clients := range s.clients {
+			for client := range clients {
+				if !fn(client) {
+					s.mu.RUnlock()
+					return
+				}
+			}
+		}
+		s.mu.RUnlock()
+	}
+}
@@ -0,0 +1,194 @@
+package websockets
+
+import (
+	"context"
+	"messenger-pigeon-app/internal/model"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeConnection é uma conexão em memória que só conta os payloads recebidos.
+type fakeConnection struct {
+	userID    int64
+	sessionID string
+	sent      atomic.Int64
+}
+
+func (f *fakeConnection) UserID() int64     { return f.userID }
+func (f *fakeConnection) SessionID() string { return f.sessionID }
+func (f *fakeConnection) Close()            {}
+func (f *fakeConnection) Info() ConnectionInfo {
+	return ConnectionInfo{SessionID: f.sessionID, Transport: "fake", OpenedAt: time.Time{}}
+}
+func (f *fakeConnection) Send(payload interface{}) bool {
+	f.sent.Add(1)
+	return true
+}
+
+// connectionIndex é o que os benchmarks exercitam nos dois desenhos do registro.
+type connectionIndex interface {
+	Register(client Connection)
+	Unregister(client Connection)
+	IsOnline(userID int64) bool
+	Send(ctx context.Context, userID int64, payload interface{}) int
+}
+
+// singleMapRegistry reproduz o desenho anterior ao Registry: um único mapa por usuário
+// protegido por um só mutex, disputado por todas as conexões.
+type singleMapRegistry struct {
+	mu      sync.RWMutex
+	clients map[int64]map[Connection]struct{}
+}
+
+func newSingleMapRegistry() *singleMapRegistry {
+	return &singleMapRegistry{clients: make(map[int64]map[Connection]struct{})}
+}
+
+func (r *singleMapRegistry) Register(client Connection) {
+	r.mu.Lock()
+	clients, ok := r.clients[client.UserID()]
+	if !ok {
+		clients = make(map[Connection]struct{})
+		r.clients[client.UserID()] = clients
+	}
+	clients[client] = struct{}{}
+	r.mu.Unlock()
+}
+
+func (r *singleMapRegistry) Unregister(client Connection) {
+	r.mu.Lock()
+	if clients, ok := r.clients[client.UserID()]; ok {
+		delete(clients, client)
+		if len(clients) == 0 {
+			delete(r.clients, client.UserID())
+		}
+	}
+	r.mu.Unlock()
+}
+
+func (r *singleMapRegistry) IsOnline(userID int64) bool {
+	r.mu.RLock()
+	_, ok := r.clients[userID]
+	r.mu.RUnlock()
+	return ok
+}
+
+func (r *singleMapRegistry) Send(ctx context.Context, userID int64, payload interface{}) int {
+	payload = frame{legacy: payload, events: envelopes(payload)}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	delivered := 0
+	for client := range r.clients[userID] {
+		if client.Send(payload) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// Usuários e conexões por usuário usados nos benchmarks
+const (
+	benchmarkUsers          = 10000
+	benchmarkConnsPerUser   = 3
+	benchmarkRegistryShards = defaultRegistryShards
+)
+
+// registryDesigns cria um índice vazio de cada desenho.
+var registryDesigns = []struct {
+	name string
+	new  func() connectionIndex
+}{
+	{"single_map", func() connectionIndex { return newSingleMapRegistry() }},
+	{"sharded", func() connectionIndex { return NewRegistry(benchmarkRegistryShards) }},
+}
+
+func populate(index connectionIndex) {
+	for user := int64(1); user <= benchmarkUsers; user++ {
+		for i := 0; i < benchmarkConnsPerUser; i++ {
+			index.Register(&fakeConnection{userID: user, sessionID: strconv.Itoa(i)})
+		}
+	}
+}
+
+func BenchmarkRegistryRegister(b *testing.B) {
+	for _, design := range registryDesigns {
+		b.Run(design.name, func(b *testing.B) {
+			index := design.new()
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					client := &fakeConnection{userID: next.Add(1) % benchmarkUsers}
+					index.Register(client)
+					index.Unregister(client)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkRegistryLookup(b *testing.B) {
+	for _, design := range registryDesigns {
+		b.Run(design.name, func(b *testing.B) {
+			index := design.new()
+			populate(index)
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					index.IsOnline(next.Add(1)%benchmarkUsers + 1)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkRegistryFanOut(b *testing.B) {
+	message := model.UserMessage{MessageID: 1, Content: "hello", MessageBy: 1, MessageTo: 2}
+	for _, design := range registryDesigns {
+		b.Run(design.name, func(b *testing.B) {
+			index := design.new()
+			populate(index)
+			var next atomic.Int64
+			ctx := context.Background()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					index.Send(ctx, next.Add(1)%benchmarkUsers+1, message)
+				}
+			})
+		})
+	}
+}
+
+// Conexões registradas e removidas concorrentemente em vários usuários terminam no estado certo.
+func TestRegistryConcurrentRegister(t *testing.T) {
+	registry := NewRegistry(8)
+	var wg sync.WaitGroup
+	clients := make([]*fakeConnection, 200)
+	for i := range clients {
+		clients[i] = &fakeConnection{userID: int64(i % 20)}
+		wg.Add(1)
+		go func(client *fakeConnection) {
+			defer wg.Done()
+			registry.Register(client)
+		}(clients[i])
+	}
+	wg.Wait()
+	if got := registry.Len(); got != len(clients) {
+		t.Fatalf("Len() = %d, want %d", got, len(clients))
+	}
+
+	for _, client := range clients[:100] {
+		registry.Unregister(client)
+	}
+	if got := registry.Len(); got != 100 {
+		t.Fatalf("Len() after unregister = %d, want 100", got)
+	}
+	if delivered := registry.Send(context.Background(), 3, model.UserMessage{}); delivered != 5 {
+		t.Fatalf("Send() delivered to %d connections, want 5", delivered)
+	}
+}
@@ -0,0 +1,160 @@
+package websockets
+
+import (
+	"fmt"
+	"messenger-pigeon-app/config/env"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Journal guarda, por usuário, os últimos eventos entregues, para que as conexões SSE e de
+// long-poll retomem a partir do Last-Event-ID (ou cursor) depois de uma reconexão. Os ids
+// dos eventos são cursores "<época>-<sequência>", com uma sequência única para o histórico
+// todo; a época muda a cada início do servidor, de forma que um cursor anterior ao reinício
+// é reconhecido como lacuna.
+type Journal struct {
+	size      int
+	retention time.Duration
+	epoch     string
+
+	mu    sync.Mutex
+	seq   uint64
+	swept uint64 // Maior sequência entre os históricos descartados pelo sweep
+	users map[int64]*userJournal
+}
+
+type userJournal struct {
+	events  []journalEntry // Em ordem de sequência, no máximo size entradas
+	dropped uint64         // Maior sequência descartada por exceder size
+	touched time.Time
+}
+
+type journalEntry struct {
+	event Envelope
+	at    time.Time
+}
+
+// NewJournal cria um histórico com até size eventos por usuário, mantidos por retention.
+func NewJournal(size int, retention time.Duration) *Journal {
+	if size < 1 {
+		size = 1
+	}
+	j := &Journal{
+		size:      size,
+		retention: retention,
+		epoch:     strconv.FormatInt(time.Now().UnixNano(), 36),
+		users:     make(map[int64]*userJournal),
+	}
+	if retention > 0 {
+		go j.sweep()
+	}
+	return j
+}
+
+// NewJournalFromEnv lê WS_REPLAY_SIZE (eventos por usuário) e WS_REPLAY_RETENTION.
+func NewJournalFromEnv() *Journal {
+	return NewJournal(env.Int("WS_REPLAY_SIZE", 100), env.Duration("WS_REPLAY_RETENTION", 5*time.Minute))
+}
+
+// Append registra os eventos do usuário, trocando os ids pelos cursores do histórico.
+func (j *Journal) Append(userID int64, events []Envelope) []Envelope {
+	now := time.Now()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	user, ok := j.users[userID]
+	if !ok {
+		user = &userJournal{}
+		j.users[userID] = user
+	}
+	user.touched = now
+
+	for i := range events {
+		j.seq++
+		events[i].ID = j.cursor(j.seq)
+		events[i].seq = j.seq
+		user.events = append(user.events, journalEntry{event: events[i], at: now})
+	}
+	if overflow := len(user.events) - j.size; overflow > 0 {
+		user.dropped = user.events[overflow-1].event.seq
+		user.events = append(user.events[:0:0], user.events[overflow:]...)
+	}
+	return events
+}
+
+// Since retorna os eventos do usuário posteriores ao cursor. ok é falso quando algum deles
+// não está mais no histórico (ou o cursor é inválido); o cliente deve então recarregar o
+// estado pela API REST.
+func (j *Journal) Since(userID int64, cursor string) (events []Envelope, ok bool) {
+	seq, valid := j.parse(cursor)
+	if !valid {
+		return nil, false
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if seq > j.seq {
+		return nil, false
+	}
+	user, exists := j.users[userID]
+	if !exists {
+		// O histórico do usuário pode ter sido descartado depois do cursor
+		return nil, seq >= j.swept
+	}
+	if user.dropped > seq {
+		return nil, false
+	}
+
+	cutoff := time.Now().Add(-j.retention)
+	for _, entry := range user.events {
+		if entry.event.seq <= seq {
+			continue
+		}
+		if j.retention > 0 && entry.at.Before(cutoff) {
+			return nil, false
+		}
+		events = append(events, entry.event)
+	}
+	return events, true
+}
+
+// Cursor retorna o cursor atual do histórico, para começar a acompanhar a partir de agora.
+func (j *Journal) Cursor() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.cursor(j.seq)
+}
+
+func (j *Journal) cursor(seq uint64) string {
+	return fmt.Sprintf("%s-%d", j.epoch, seq)
+}
+
+func (j *Journal) parse(cursor string) (uint64, bool) {
+	epoch, seq, found := strings.Cut(cursor, "-")
+	if !found || epoch != j.epoch {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil
+}
+
+// sweep descarta periodicamente os históricos sem eventos novos dentro da retenção.
+func (j *Journal) sweep() {
+	ticker := time.NewTicker(j.retention)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		j.mu.Lock()
+		for userID, user := range j.users {
+			if now.Sub(user.touched) <= j.retention {
+				continue
+			}
+			if last := len(user.events) - 1; last >= 0 && user.events[last].event.seq > j.swept {
+				j.swept = user.events[last].event.seq
+			}
+			delete(j.users, userID)
+		}
+		j.mu.Unlock()
+	}
+}
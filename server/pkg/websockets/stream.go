@@ -0,0 +1,285 @@
+package websockets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"messenger-pigeon-app/pkg/logging"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// StreamClient é uma conexão HTTP que recebe os mesmos eventos dos WebSockets versionados,
+// usada pelos fallbacks de Server-Sent Events e long-poll quando o proxy do cliente bloqueia
+// o upgrade. Fica registrada no Registry como qualquer outra conexão.
+type StreamClient struct {
+	id        string
+	opened    time.Time
+	logger    *slog.Logger
+	userID    int64
+	sessionID string
+	events    chan Envelope
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu       sync.Mutex
+	replayed map[uint64]struct{} // Sequências do backlog, para descartar as cópias que chegarem pela fila
+}
+
+// NewStreamClient cria a conexão de streaming do usuário. ctx é o da requisição que a abriu.
+func NewStreamClient(ctx context.Context, userID int64, sessionID string) *StreamClient {
+	id := logging.NewID()
+	return &StreamClient{
+		id:        id,
+		opened:    time.Now(),
+		logger:    connectionLogger(ctx, id, userID).With("transport", "stream"),
+		userID:    userID,
+		sessionID: sessionID,
+		events:    make(chan Envelope, clientSendBuffer),
+		done:      make(chan struct{}),
+	}
+}
+
+// ID retorna o id da conexão.
+func (s *StreamClient) ID() string {
+	return s.id
+}
+
+// UserID retorna o ID do usuário dono da conexão.
+func (s *StreamClient) UserID() int64 {
+	return s.userID
+}
+
+// SessionID retorna a sessão de login que abriu a conexão.
+func (s *StreamClient) SessionID() string {
+	return s.sessionID
+}
+
+// Info descreve a conexão para os diagnósticos.
+func (s *StreamClient) Info() ConnectionInfo {
+	return ConnectionInfo{ID: s.id, SessionID: s.sessionID, Transport: "stream", OpenedAt: s.opened}
+}
+
+// Send enfileira os eventos do payload sem bloquear. Com o buffer cheio a conexão é
+// encerrada, e o cliente retoma pelo último id recebido em vez de perder eventos.
+func (s *StreamClient) Send(payload interface{}) bool {
+	for _, event := range envelopes(payload) {
+		select {
+		case <-s.done:
+			return false
+		case s.events <- event:
+		default:
+			s.logger.Warn("Stream buffer full, closing stream")
+			s.Close()
+			return false
+		}
+	}
+	return true
+}
+
+// Close encerra a conexão. Pode ser chamado mais de uma vez.
+func (s *StreamClient) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// Done é fechado quando a conexão é encerrada (ex.: sessão revogada).
+func (s *StreamClient) Done() <-chan struct{} {
+	return s.done
+}
+
+// replay registra os eventos do backlog e os retorna. Eventos do histórico podem chegar
+// também pela fila, se foram enviados entre o registro e a leitura do histórico.
+func (s *StreamClient) replay(backlog []Envelope) []Envelope {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, event := range backlog {
+		if event.seq == 0 {
+			continue
+		}
+		if s.replayed == nil {
+			s.replayed = make(map[uint64]struct{})
+		}
+		s.replayed[event.seq] = struct{}{}
+	}
+	return backlog
+}
+
+// fresh informa se um evento da fila ainda não foi entregue pelo backlog. Só as cópias do
+// backlog são descartadas: a ordem entre os eventos da fila é a do Registry.
+func (s *StreamClient) fresh(event Envelope) bool {
+	if event.seq == 0 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.replayed[event.seq]; ok {
+		delete(s.replayed, event.seq)
+		return false
+	}
+	return true
+}
+
+// Subscribe registra a conexão e retorna os eventos posteriores ao cursor e o cursor de
+// partida. Sem cursor, a conexão acompanha a partir de agora. Se o histórico não cobre o
+// cursor, o backlog é um único sync.required, com o cursor atual como id.
+func Subscribe(registry *Registry, stream *StreamClient, cursor string) (backlog []Envelope, start string) {
+	// Registra antes de ler o histórico, para que nenhum evento fique entre os dois
+	registry.Register(stream)
+
+	journal := registry.Journal()
+	if journal == nil {
+		return nil, ""
+	}
+	if cursor == "" {
+		return nil, journal.Cursor()
+	}
+	if events, ok := journal.Since(stream.userID, cursor); ok {
+		return events, cursor
+	}
+
+	start = journal.Cursor()
+	resync := newEnvelope(EventSyncRequired, struct{}{})
+	resync.ID = start
+	return []Envelope{resync}, start
+}
+
+// Forward entrega o backlog e os eventos seguintes a send até que ctx termine, a conexão seja
+// encerrada ou send falhe. Usado pelo stream de eventos do gRPC.
+func Forward(ctx context.Context, stream *StreamClient, backlog []Envelope, send func(Envelope) error) error {
+	for _, event := range stream.replay(backlog) {
+		if err := send(event); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case event := <-stream.events:
+			if !stream.fresh(event) {
+				continue
+			}
+			if err := send(event); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stream.done:
+			return nil
+		}
+	}
+}
+
+// Intervalo de reconexão sugerido aos clientes SSE
+const sseRetry = 3 * time.Second
+
+// ServeSSE envia o backlog e os eventos seguintes como text/event-stream até que o cliente
+// desconecte ou a conexão seja encerrada. Comentários periódicos mantêm os proxies abertos.
+func ServeSSE(w http.ResponseWriter, r *http.Request, stream *StreamClient, backlog []Envelope) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		stream.logger.Error("Streaming not supported by the response writer")
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // Desativa o buffer do nginx
+	w.WriteHeader(http.StatusOK)
+
+	// Intervalo de reconexão do EventSource; também faz os proxies repassarem o início da resposta
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds()); err != nil {
+		return
+	}
+	for _, event := range stream.replay(backlog) {
+		if stream.writeSSE(w, event) != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(heartbeat.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event := <-stream.events:
+			if !stream.fresh(event) {
+				continue
+			}
+			if err := stream.writeSSE(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-stream.done:
+			return
+		}
+	}
+}
+
+func (s *StreamClient) writeSSE(w http.ResponseWriter, event Envelope) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error("Error encoding event", "event_type", event.Type, "error", err)
+		return nil
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// Poll espera até timeout pelos eventos do long-poll. Retorna o backlog imediatamente, se
+// houver; senão, os eventos que chegarem primeiro. cursor é o id do último evento retornado
+// ou, sem eventos, o cursor de partida.
+func Poll(ctx context.Context, stream *StreamClient, backlog []Envelope, start string, timeout time.Duration) (events []Envelope, cursor string) {
+	events = append(events, stream.replay(backlog)...)
+
+	if len(events) == 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+	wait:
+		for {
+			select {
+			case event := <-stream.events:
+				if stream.fresh(event) {
+					events = append(events, event)
+					break wait
+				}
+			case <-timer.C:
+				break wait
+			case <-ctx.Done():
+				break wait
+			case <-stream.done:
+				break wait
+			}
+		}
+	}
+
+	// Leva junto o que já estiver na fila
+	for drained := false; !drained; {
+		select {
+		case event := <-stream.events:
+			if stream.fresh(event) {
+				events = append(events, event)
+			}
+		default:
+			drained = true
+		}
+	}
+
+	cursor = start
+	if len(events) > 0 {
+		cursor = events[len(events)-1].ID
+	}
+	return events, cursor
+}
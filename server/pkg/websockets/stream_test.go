@@ -0,0 +1,217 @@
+package websockets
+
+import (
+	"context"
+	"encoding/json"
+	"messenger-pigeon-app/internal/model"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// yieldingConnection é uma fakeConnection que cede o processador em cada envio.
+type yieldingConnection struct {
+	fakeConnection
+}
+
+func (y *yieldingConnection) Send(payload interface{}) bool {
+	runtime.Gosched()
+	return y.fakeConnection.Send(payload)
+}
+
+// Com vários workers entregando ao mesmo usuário, a conexão de streaming recebe todos os
+// eventos, em ordem de sequência.
+func TestStreamReceivesConcurrentDispatchInOrder(t *testing.T) {
+	const workers, perWorker = 10, 6 // Cabe no buffer da conexão, que é encerrada se encher
+	registry := NewRegistry(1)
+	registry.EnableReplay(NewJournal(workers*perWorker, 0))
+	stream := NewStreamClient(context.Background(), 7, "s1")
+	backlog, _ := Subscribe(registry, stream, "")
+	// Outra aba do usuário que cede o processador a cada envio, abrindo espaço para que os
+	// workers se intercalem entre a atribuição da sequência e o enfileiramento
+	registry.Register(&yieldingConnection{fakeConnection: fakeConnection{userID: 7, sessionID: "s2"}})
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				registry.Send(context.Background(), 7, model.UserMessage{MessageID: w*perWorker + i, MessageTo: 7})
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	events, _ := Poll(context.Background(), stream, backlog, "", time.Second)
+	if len(events) != workers*perWorker {
+		t.Fatalf("received %d events, want %d", len(events), workers*perWorker)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].seq <= events[i-1].seq {
+			t.Fatalf("event %d has seq %d after %d", i, events[i].seq, events[i-1].seq)
+		}
+	}
+}
+
+// Só as cópias do backlog são descartadas; um evento da fila com sequência menor que a do
+// backlog continua sendo entregue.
+func TestStreamDropsOnlyReplayedDuplicates(t *testing.T) {
+	stream := NewStreamClient(context.Background(), 7, "s1")
+	stream.replay([]Envelope{{Type: EventMessageCreated, seq: 5}})
+
+	if !stream.fresh(Envelope{Type: EventMessageCreated, seq: 3}) {
+		t.Error("live event 3 dropped")
+	}
+	if stream.fresh(Envelope{Type: EventMessageCreated, seq: 5}) {
+		t.Error("live copy of replayed event 5 delivered")
+	}
+	if !stream.fresh(Envelope{Type: EventMessageCreated, seq: 6}) {
+		t.Error("live event 6 dropped")
+	}
+}
+
+// journalMessages registra count mensagens da usuária 7 no histórico e retorna seus ids.
+func journalMessages(registry *Registry, count int) []string {
+	ids := make([]string, count)
+	for i := range ids {
+		registry.Send(context.Background(), 7, model.UserMessage{MessageID: i + 1, MessageTo: 7})
+		ids[i] = registry.Journal().Cursor()
+	}
+	return ids
+}
+
+// A conexão que volta com o id do último evento recebido recebe só os eventos seguintes.
+func TestSubscribeResumesFromCursor(t *testing.T) {
+	registry := NewRegistry(1)
+	registry.EnableReplay(NewJournal(10, 0))
+	ids := journalMessages(registry, 3)
+
+	stream := NewStreamClient(context.Background(), 7, "s1")
+	backlog, start := Subscribe(registry, stream, ids[0])
+	if start != ids[0] {
+		t.Errorf("start = %q, want %q", start, ids[0])
+	}
+	if len(backlog) != 2 || backlog[0].ID != ids[1] || backlog[1].ID != ids[2] {
+		t.Fatalf("backlog = %+v, want events %s and %s", backlog, ids[1], ids[2])
+	}
+	for _, event := range backlog {
+		if event.Type != EventMessageCreated {
+			t.Errorf("event type = %q, want %q", event.Type, EventMessageCreated)
+		}
+	}
+
+	// Do último id em diante, não há backlog
+	if backlog, _ := Subscribe(registry, NewStreamClient(context.Background(), 7, "s1"), ids[2]); len(backlog) != 0 {
+		t.Errorf("backlog after the last event = %+v, want none", backlog)
+	}
+}
+
+// Quando o histórico não cobre o cursor, a conexão recebe apenas um sync.required, com o
+// cursor atual como id, para recarregar o estado pela API REST.
+func TestSubscribeGapRequiresSync(t *testing.T) {
+	registry := NewRegistry(1)
+	journal := NewJournal(2, 0)
+	registry.EnableReplay(journal)
+	ids := journalMessages(registry, 4)
+	epoch, _, _ := strings.Cut(journal.Cursor(), "-")
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"overflow", ids[0]},              // O evento 2 já saiu do histórico, que guarda só os eventos 3 e 4
+		{"previous epoch", "0-3"},         // Cursor de antes do reinício do servidor
+		{"future sequence", epoch + "-9"}, // Sequência que o servidor nunca emitiu
+		{"malformed", "not-a-cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := journal.Since(7, tt.cursor); ok {
+				t.Fatalf("Since(%q) ok, want a gap", tt.cursor)
+			}
+			backlog, start := Subscribe(registry, NewStreamClient(context.Background(), 7, "s1"), tt.cursor)
+			if len(backlog) != 1 || backlog[0].Type != EventSyncRequired {
+				t.Fatalf("backlog = %+v, want a single %s", backlog, EventSyncRequired)
+			}
+			if backlog[0].ID != journal.Cursor() || start != journal.Cursor() {
+				t.Errorf("sync.required id = %q, start = %q, want the current cursor %q", backlog[0].ID, start, journal.Cursor())
+			}
+		})
+	}
+
+	// A partir do evento 2, o histórico ainda tem tudo o que falta
+	if events, ok := journal.Since(7, ids[1]); !ok || len(events) != 2 || events[0].ID != ids[2] || events[1].ID != ids[3] {
+		t.Errorf("Since(%q) = %+v, %v, want events %s and %s", ids[1], events, ok, ids[2], ids[3])
+	}
+}
+
+// Sem eventos, o long-poll responde vazio ao fim da espera, mantendo o cursor de partida.
+func TestPollTimesOutWithoutEvents(t *testing.T) {
+	registry := NewRegistry(1)
+	registry.EnableReplay(NewJournal(10, 0))
+	stream := NewStreamClient(context.Background(), 7, "s1")
+	backlog, start := Subscribe(registry, stream, "")
+
+	const timeout = 50 * time.Millisecond
+	began := time.Now()
+	events, cursor := Poll(context.Background(), stream, backlog, start, timeout)
+	if elapsed := time.Since(began); elapsed < timeout {
+		t.Errorf("Poll returned after %v, want at least %v", elapsed, timeout)
+	}
+	if len(events) != 0 || cursor != start {
+		t.Errorf("Poll = %+v, %q, want no events and cursor %q", events, cursor, start)
+	}
+
+	// Um evento durante a espera encerra o long-poll antes do prazo
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		registry.Send(context.Background(), 7, model.UserMessage{MessageID: 1, MessageTo: 7})
+	}()
+	events, cursor = Poll(context.Background(), stream, nil, start, time.Minute)
+	if len(events) != 1 || cursor != events[0].ID {
+		t.Errorf("Poll = %+v, %q, want the sent event and its id as cursor", events, cursor)
+	}
+}
+
+// Cada evento sai no formato do text/event-stream: id, event e o envelope JSON em data.
+func TestServeSSEWireFormat(t *testing.T) {
+	registry := NewRegistry(1)
+	registry.EnableReplay(NewJournal(10, 0))
+	ids := journalMessages(registry, 2)
+	stream := NewStreamClient(context.Background(), 7, "s1")
+	epoch, _, _ := strings.Cut(ids[0], "-")
+	backlog, _ := Subscribe(registry, stream, epoch+"-0")
+	stream.Close() // Só o backlog é escrito
+
+	rec := httptest.NewRecorder()
+	ServeSSE(rec, httptest.NewRequest("GET", "/v1/events", nil), stream, backlog)
+
+	if got := rec.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", got)
+	}
+	blocks := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n\n"), "\n\n")
+	if len(blocks) != 3 || blocks[0] != "retry: 3000" {
+		t.Fatalf("body = %q, want the retry line and two events", rec.Body.String())
+	}
+	for i, block := range blocks[1:] {
+		lines := strings.Split(block, "\n")
+		if len(lines) != 3 || lines[0] != "id: "+ids[i] || lines[1] != "event: "+string(EventMessageCreated) || !strings.HasPrefix(lines[2], "data: ") {
+			t.Fatalf("event %d = %q, want id, event and data lines", i, block)
+		}
+		var envelope struct {
+			Type    EventType         `json:"type"`
+			ID      string            `json:"id"`
+			Payload model.UserMessage `json:"payload"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &envelope); err != nil {
+			t.Fatalf("event %d data is not JSON: %v", i, err)
+		}
+		if envelope.ID != ids[i] || envelope.Type != EventMessageCreated || envelope.Payload.MessageID != i+1 {
+			t.Errorf("event %d data = %+v", i, envelope)
+		}
+	}
+}
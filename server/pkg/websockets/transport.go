@@ -0,0 +1,49 @@
+package websockets
+
+import (
+	"compress/flate"
+	"messenger-pigeon-app/config/env"
+)
+
+// TransportConfig controla os buffers, a compressão permessage-deflate e o tamanho máximo
+// das mensagens recebidas.
+type TransportConfig struct {
+	ReadBufferSize       int   // Buffer de leitura do handshake e dos frames
+	WriteBufferSize      int   // Buffer de escrita dos frames
+	Compression          bool  // Aceita negociar permessage-deflate com o cliente
+	CompressionLevel     int   // Nível do flate, de -2 (só Huffman) a 9
+	CompressionThreshold int   // Mensagens menores que isso (em bytes) são enviadas sem compressão
+	MaxMessageSize       int64 // Mensagens recebidas maiores que isso encerram a conexão
+}
+
+// Configuração usada pelo upgrader e pelos clientes criados a partir de Initialize.
+var transport = TransportConfig{
+	ReadBufferSize:       1024,
+	WriteBufferSize:      1024,
+	Compression:          true,
+	CompressionLevel:     flate.BestSpeed,
+	CompressionThreshold: 256,
+	MaxMessageSize:       64 << 10,
+}
+
+// TransportConfigFromEnv lê WS_READ_BUFFER_SIZE, WS_WRITE_BUFFER_SIZE, WS_COMPRESSION,
+// WS_COMPRESSION_LEVEL, WS_COMPRESSION_THRESHOLD e WS_MAX_MESSAGE_SIZE.
+func TransportConfigFromEnv() TransportConfig {
+	config := TransportConfig{
+		ReadBufferSize:       env.Int("WS_READ_BUFFER_SIZE", transport.ReadBufferSize),
+		WriteBufferSize:      env.Int("WS_WRITE_BUFFER_SIZE", transport.WriteBufferSize),
+		Compression:          env.Bool("WS_COMPRESSION", transport.Compression),
+		CompressionLevel:     env.Int("WS_COMPRESSION_LEVEL", transport.CompressionLevel),
+		CompressionThreshold: env.Int("WS_COMPRESSION_THRESHOLD", transport.CompressionThreshold),
+		MaxMessageSize:       int64(env.Int("WS_MAX_MESSAGE_SIZE", int(transport.MaxMessageSize))),
+	}
+
+	// O gorilla/websocket só aceita os níveis entre flate.HuffmanOnly e flate.BestCompression
+	if config.CompressionLevel < flate.HuffmanOnly || config.CompressionLevel > flate.BestCompression {
+		config.CompressionLevel = transport.CompressionLevel
+	}
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = transport.MaxMessageSize
+	}
+	return config
+}
@@ -0,0 +1,151 @@
+package websockets
+
+import (
+	"context"
+	"errors"
+	"messenger-pigeon-app/internal/model"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// useTransportFromEnv aplica a TransportConfig do ambiente ao upgrader e aos novos clientes,
+// como o Initialize, e restaura a anterior ao fim do teste.
+func useTransportFromEnv(t *testing.T) {
+	t.Helper()
+	previous := transport
+	transport = TransportConfigFromEnv()
+	initializeUpgrader()
+	t.Cleanup(func() {
+		transport = previous
+		initializeUpgrader()
+	})
+}
+
+// countingConn conta os bytes lidos da conexão, para medir o tamanho dos frames no fio.
+type countingConn struct {
+	net.Conn
+	read atomic.Int64
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+func TestCompressionNegotiation(t *testing.T) {
+	for _, tt := range []struct {
+		env  string
+		want bool
+	}{
+		{"", true},
+		{"true", true},
+		{"false", false},
+	} {
+		t.Run("WS_COMPRESSION="+tt.env, func(t *testing.T) {
+			t.Setenv("WS_COMPRESSION", tt.env)
+			useTransportFromEnv(t)
+			url := serveClient(t, 7, func(client *Client) { client.ReadMessages(func(context.Context, model.UserMessage) {}) })
+
+			dialer := websocket.Dialer{EnableCompression: true}
+			conn, resp, err := dialer.Dial(url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			negotiated := strings.Contains(resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+			if negotiated != tt.want {
+				t.Errorf("permessage-deflate negotiated = %v, want %v (extensions %q)", negotiated, tt.want, resp.Header.Get("Sec-WebSocket-Extensions"))
+			}
+		})
+	}
+}
+
+// Só as mensagens a partir de WS_COMPRESSION_THRESHOLD bytes saem comprimidas.
+func TestCompressionThreshold(t *testing.T) {
+	t.Setenv("WS_COMPRESSION_THRESHOLD", "512")
+	useTransportFromEnv(t)
+	clients := make(chan *Client, 1)
+	url := serveClient(t, 7, func(client *Client) {
+		clients <- client
+		client.ReadMessages(func(context.Context, model.UserMessage) {})
+	})
+
+	var counted *countingConn
+	dialer := websocket.Dialer{
+		EnableCompression: true,
+		NetDial: func(network, addr string) (net.Conn, error) {
+			conn, err := net.Dial(network, addr)
+			if err != nil {
+				return nil, err
+			}
+			counted = &countingConn{Conn: conn}
+			return counted, nil
+		},
+	}
+	conn, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := <-clients
+
+	// Bytes no fio para uma mensagem JSON de size caracteres repetidos
+	wireSize := func(size int) int64 {
+		t.Helper()
+		before := counted.read.Load()
+		client.Send(strings.Repeat("a", size-2)) // As aspas do JSON completam o tamanho
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != size {
+			t.Fatalf("received %d bytes, want %d", len(data), size)
+		}
+		return counted.read.Load() - before
+	}
+
+	if wire := wireSize(400); wire < 400 {
+		t.Errorf("400-byte message used %d bytes on the wire, want it uncompressed", wire)
+	}
+	if wire := wireSize(4000); wire >= 400 {
+		t.Errorf("4000-byte message used %d bytes on the wire, want it compressed", wire)
+	}
+}
+
+// Uma mensagem acima de WS_MAX_MESSAGE_SIZE fecha a conexão com o código 1009.
+func TestOversizeMessageClosesWith1009(t *testing.T) {
+	t.Setenv("WS_MAX_MESSAGE_SIZE", "1024")
+	useTransportFromEnv(t)
+	handled := make(chan model.UserMessage, 1)
+	url := serveClient(t, 7, func(client *Client) {
+		client.ReadMessages(func(_ context.Context, message model.UserMessage) { handled <- message })
+	})
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"content":"`+strings.Repeat("a", 2048)+`"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseMessageTooBig {
+		t.Fatalf("read error = %v, want close 1009", err)
+	}
+	select {
+	case message := <-handled:
+		t.Fatalf("oversize message was handled: %+v", message)
+	default:
+	}
+}
@@ -0,0 +1,92 @@
+package websockets
+
+import (
+	"log/slog"
+	"messenger-pigeon-app/config/env"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Clientes que enviam o token pelo cabeçalho Sec-WebSocket-Protocol devem oferecer também um
+// dos subprotocolos do servidor, por exemplo:
+// new WebSocket(url, ["pigeon.v1.json", "pigeon.token." + accessToken]).
+var upgrader = newUpgrader(nil)
+
+// newUpgrader cria o upgrader que só aceita as origens informadas. Uma lista vazia aceita
+// apenas a mesma origem do host e "*" aceita qualquer origem. Buffers e compressão seguem
+// a TransportConfig atual.
+func newUpgrader(allowedOrigins []string) websocket.Upgrader {
+	return websocket.Upgrader{
+		ReadBufferSize:    transport.ReadBufferSize,
+		WriteBufferSize:   transport.WriteBufferSize,
+		EnableCompression: transport.Compression,
+		Subprotocols:      []string{SubprotocolV1MsgPack, SubprotocolV1JSON, Subprotocol},
+		CheckOrigin:       originChecker(allowedOrigins),
+	}
+}
+
+// Lê WS_ALLOWED_ORIGINS (lista separada por vírgulas, ex.: "https://app.example.com").
+func initializeUpgrader() {
+	upgrader = newUpgrader(env.List("WS_ALLOWED_ORIGINS", nil))
+}
+
+func originChecker(allowedOrigins []string) func(r *http.Request) bool {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			// Clientes que não são navegadores não enviam Origin.
+			return true
+		}
+		if allowed["*"] || allowed[strings.ToLower(origin)] {
+			return true
+		}
+		if len(allowed) > 0 {
+			return false
+		}
+
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+}
+
+// OriginMiddleware rejeita o handshake de origens não permitidas antes da autenticação,
+// para que um ticket não seja consumido por uma página de outra origem.
+func OriginMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !upgrader.CheckOrigin(c.Request) {
+			slog.WarnContext(c.Request.Context(), "WebSocket origin not allowed", "origin", c.GetHeader("Origin"))
+			c.AbortWithStatusJSON(http.StatusForbidden, i18n.Error(c, err.CodeOriginNotAllowed, "error.origin_not_allowed"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// Cabeçalhos definidos pelos middlewares que seguem na resposta do handshake
+var upgradeHeaders = []string{"Deprecation", "Link", "Sunset"}
+
+// Upgrade converte a requisição HTTP em uma conexão WebSocket, validando a origem.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
+	// O upgrader escreve a resposta do handshake por conta própria e ignora w.Header()
+	var header http.Header
+	for _, name := range upgradeHeaders {
+		if values := w.Header().Values(name); len(values) > 0 {
+			if header == nil {
+				header = http.Header{}
+			}
+			header[name] = values
+		}
+	}
+	return upgrader.Upgrade(w, r, header)
+}
@@ -1,236 +1,139 @@
 package websockets
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"messenger-pigeon-app/internal/err"
+	"messenger-pigeon-app/internal/i18n"
 	"messenger-pigeon-app/internal/model"
+	"messenger-pigeon-app/pkg/metrics"
 	"messenger-pigeon-app/pkg/repository"
 	"net/http"
 
-	"sync"
-	"time"
-
 	"github.com/gin-gonic/gin"
-	"github.com/gorilla/websocket"
 )
 
 // Mapeamento de conexões WebSocket por ID de usuário
 var (
-	UserConnections map[int64]*websocket.Conn
+	UserConnections *Registry
 	workerPool      *WorkerPool
 )
 
-func init() {
-	UserConnections = make(map[int64]*websocket.Conn)
-	workerPool = NewWorkerPool(10) // Pool com 10 workers
-	go handleWebSocketMessages()
-}
-
-// Pool de workers para processar mensagens
-type WorkerPool struct {
-	workers  int
-	jobQueue chan model.UserMessage
-	wg       sync.WaitGroup
-}
-
-func NewWorkerPool(numWorkers int) *WorkerPool {
-	pool := &WorkerPool{
-		workers:  numWorkers,
-		jobQueue: make(chan model.UserMessage, 100), // Buffer com 100 mensagens
-	}
-	pool.startWorkers()
-	return pool
-}
-
-func (pool *WorkerPool) startWorkers() {
-	for i := 0; i < pool.workers; i++ {
-		pool.wg.Add(1)
-		go func() {
-			defer pool.wg.Done()
-			for job := range pool.jobQueue {
-				processChatMessage(job)
-			}
-		}()
-	}
-}
+// Initialize cria os registros de conexões e os pools de workers dos dois canais WebSocket.
+// Deve ser chamado depois que as variáveis de ambiente forem carregadas.
+func Initialize() {
+	heartbeat = HeartbeatConfigFromEnv()
+	transport = TransportConfigFromEnv()
+	initializeUpgrader()
+	config := DispatcherConfigFromEnv()
 
-func (pool *WorkerPool) Submit(job model.UserMessage) {
-	select {
-	case pool.jobQueue <- job:
-		// Mensagem enviada para o pool com sucesso
-	default:
-		// Buffer de mensagens cheio, mensagem será descartada ou reprocessada.
-		log.Println()
-	}
-}
+	UserConnections = NewRegistryFromEnv()
+	UserConnections.EnableReplay(NewJournalFromEnv())
+	workerPool = NewWorkerPool(10, NewDispatcher("chat", UserConnections, config)) // Pool com 10 workers
 
-func (pool *WorkerPool) Shutdown() {
-	close(pool.jobQueue)
-	pool.wg.Wait()
-}
-
-func processChatMessage(message model.UserMessage) {
-	// Processar a mensagem e enviar via WebSocket
-	if conn, ok := UserConnections[int64(message.MessageTo)]; ok {
-		err := conn.WriteJSON(message)
-		if err != nil {
-			log.Println("Error sending message:", err)
-		}
-	} else {
-		log.Println("Recipient is not connected")
-	}
+	initializeMessages(config)
 }
 
 // Função para enviar mensagens para o pool
-func sendChatMessage(message model.UserMessage) {
-	workerPool.Submit(message)
+func sendChatMessage(ctx context.Context, message model.UserMessage) {
+	workerPool.Submit(ctx, message)
 }
 
-// Função para lidar com as mensagens WebSocket de forma eficiente em lote
-func handleWebSocketMessages() {
-	batch := make([]model.UserMessage, 0, 10) // Processar lotes de 10 mensagens
-	ticker := time.NewTicker(50 * time.Millisecond)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case message := <-workerPool.jobQueue:
-			batch = append(batch, message)
-			if len(batch) >= 10 {
-				flushChatMessages(batch)
-				batch = batch[:0] // Limpar o batch
-			}
-		case <-ticker.C:
-			if len(batch) > 0 {
-				flushChatMessages(batch)
-				batch = batch[:0]
-			}
-		}
-	}
+// Lê as mensagens da conexão e as encaminha para o pool. O controle de inatividade
+// (pings, prazo de leitura e ociosidade) é feito pelo próprio Client.
+func HandleChatMessages(client *Client) {
+	client.ReadMessages(sendChatMessage)
 }
 
-func flushChatMessages(batch []model.UserMessage) {
-	for _, message := range batch {
-		if conn, ok := UserConnections[int64(message.MessageTo)]; ok {
-			// Enviar todas as mensagens em um único payload JSON
-			err := conn.WriteJSON(batch)
-			if err != nil {
-				log.Println("Error sending messages:", err)
-			}
-		} else {
-			log.Println("Recipient is not connected")
-		}
+// Helper para extrair o ID do usuário do contexto (definido como int pelos middlewares de autenticação)
+func GetUserIDFromContext(c *gin.Context) int {
+	userId, exists := c.Get("id")
+	if !exists {
+		slog.WarnContext(c.Request.Context(), "User ID not found in session")
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, err.CodeUnauthorized, "error.user_not_in_session"))
+		return 0
 	}
-}
 
-// Função para iniciar o controle de inatividade
-func StartInactivityTimer(ws *websocket.Conn, userID int) {
-	inactivityDuration := 30 * time.Second
-	inactivityTimer := time.NewTimer(inactivityDuration)
-
-	for {
-		select {
-		case <-inactivityTimer.C:
-			// Fechar a conexão após 30 segundos de inatividade
-			log.Println("Closing connection due to inactivity:", userID)
-			ws.Close()
-			delete(UserConnections, int64(userID))
-			return
-		case <-time.After(1 * time.Second): // Checa a cada segundo se a conexão ainda está ativa
-			if _, isConnected := UserConnections[int64(userID)]; !isConnected {
-				inactivityTimer.Stop()
-				return
-			}
-		}
+	id, ok := userId.(int)
+	if !ok || id <= 0 {
+		slog.WarnContext(c.Request.Context(), "Invalid user ID in session", "user_id", userId)
+		c.JSON(http.StatusUnauthorized, i18n.Error(c, err.CodeUnauthorized, "error.invalid_user_id"))
+		return 0
 	}
-}
-
-// Função para gerenciar o timeout de conexão ociosa com o PongHandler e redefinir o timer de inatividade
-func HandleChatMessages(ws *websocket.Conn, userID int) {
-	defer ws.Close()
-
-	ws.SetReadDeadline(time.Now().Add(60 * time.Second))
-	ws.SetPongHandler(func(appData string) error {
-		ws.SetReadDeadline(time.Now().Add(60 * time.Second))
-		return nil
-	})
-
-	for {
-		var msg model.UserMessage
-		err := ws.ReadJSON(&msg)
-		if err != nil {
-			log.Println("Error receiving message:", err)
-			return
-		}
 
-		// Redefinir o timer de inatividade sempre que uma mensagem for recebida
-		go ResetInactivityTimer(userID)
-
-		sendChatMessage(msg)
-	}
+	return id
 }
 
-// Função para redefinir o timer de inatividade
-func ResetInactivityTimer(userID int) {
-	if _, ok := UserConnections[int64(userID)]; ok {
-		log.Println("Resetting user idle timer:", userID)
-	}
+// Helper para extrair a sessão de login do contexto
+func GetSessionIDFromContext(c *gin.Context) string {
+	return c.GetString("sid")
 }
 
-// Helper para extrair o ID do usuário do contexto
-func GetUserIDFromContext(c *gin.Context) int {
-	userId, exists := c.Get("id")
-	if !exists {
-		log.Println("User ID not found in session")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in session"})
-		return 0
+// DisconnectSessions fecha as conexões WebSocket, dos dois canais, abertas pelas sessões informadas.
+func DisconnectSessions(userID int, sessionIDs ...string) {
+	if len(sessionIDs) == 0 {
+		return
 	}
-
-	var id int
-	idFloat, ok := userId.(float64)
-	if !ok {
-		id = int(idFloat)
-
-	} else {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+	closed := UserConnections.CloseSessions(int64(userID), sessionIDs...)
+	closed += UserConnectionsMessages.CloseSessions(int64(userID), sessionIDs...)
+	if closed > 0 {
+		slog.Info("Closed connections after session revocation", "user_id", userID, "connections", closed)
 	}
-
-	return id
 }
 
-func SendChatMessage(senderID int, receiverUsername, content string) (int64, error) {
+// ErrClientMessageConflict indica que o clientMessageId já foi usado pelo remetente em outra mensagem.
+var ErrClientMessageConflict = errors.New("client message id already used for a different message")
+
+// SendChatMessage salva a mensagem e a entrega ao destinatário e às outras conexões do remetente.
+// Com clientMessageID, um reenvio da mesma mensagem não é gravado de novo: devolve o id
+// original com replayed verdadeiro e não entrega a mensagem outra vez.
+func SendChatMessage(ctx context.Context, senderID int, receiverUsername, content, clientMessageID string) (messageID int64, replayed bool, err error) {
 	// Obtém o ID do usuário destinatário
-	receiverID, err := repository.MessageGetUserIDByUsername(receiverUsername)
+	receiverID, err := repository.MessageGetUserIDByUsername(ctx, receiverUsername)
 	if err != nil {
-		log.Println("Error getting recipient ID:", err)
-		return 0, err
+		slog.ErrorContext(ctx, "Error getting recipient ID", "sender_id", senderID, "error", err)
+		return 0, false, err
 	}
 
 	// Cria a mensagem
 	message := model.UserMessage{
-		MessageBy: senderID,
-		MessageTo: receiverID,
-		Content:   content,
+		MessageBy:       senderID,
+		MessageTo:       receiverID,
+		Content:         content,
+		ClientMessageID: clientMessageID,
 	}
 
 	// Salva a mensagem no banco de dados
-	messageID, err := repository.SaveMessage(message)
+	messageID, err = repository.SaveMessage(ctx, message)
+	if errors.Is(err, repository.ErrDuplicateClientMessage) {
+		original, lookupErr := repository.GetMessageByClientID(ctx, senderID, clientMessageID)
+		if lookupErr != nil {
+			slog.ErrorContext(ctx, "Error loading replayed message", "sender_id", senderID, "error", lookupErr)
+			return 0, false, lookupErr
+		}
+		if original.MessageTo != receiverID || original.Content != content {
+			return 0, false, ErrClientMessageConflict
+		}
+		return int64(original.MessageID), true, nil
+	}
 	if err != nil {
-		log.Println("Error saving message", err)
-		return 0, err
+		slog.ErrorContext(ctx, "Error saving message", "sender_id", senderID, "recipient_id", receiverID, "error", err)
+		return 0, false, err
 	}
-
-	// Verifica se o destinatário está online
-	conn, isOnline := UserConnections[int64(receiverID)]
-	if isOnline {
-		// Envia a mensagem via WebSocket
-		go func() {
-			if err := conn.WriteJSON(message); err != nil {
-				log.Printf("Error sending message via WebSocket to user %d: %v", receiverID, err)
-			}
-		}()
-	} else {
-		log.Printf("The %d recipient is not online. The message was only stored in the database.", receiverID)
+	message.MessageID = int(messageID)
+	metrics.MessageSent()
+
+	// Entrega pelo pool mesmo com o destinatário offline, para que a mensagem entre no
+	// histórico de retomada das conexões SSE e long-poll
+	sendChatMessage(ctx, message)
+
+	// Eco para as conexões do remetente, que conciliam a mensagem otimista pelo clientMessageId
+	if senderID != receiverID {
+		echo := message
+		echo.MessageSession = true
+		workerPool.SubmitTo(ctx, int64(senderID), echo)
 	}
 
-	return messageID, nil
+	return messageID, false, nil
 }